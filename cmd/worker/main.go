@@ -0,0 +1,34 @@
+package main
+
+import (
+	"log"
+
+	"github.com/nickkcj/orbit-backend/internal/bootstrap"
+	"github.com/nickkcj/orbit-backend/internal/config"
+)
+
+// The worker binary runs background processing only, so it can be scaled
+// apart from the API. PROCESS_ROLE=scheduler turns it into the scheduler.
+func main() {
+	cfg := config.Load()
+
+	role := bootstrap.RoleWorker
+	if cfg.ProcessRole == string(bootstrap.RoleScheduler) {
+		role = bootstrap.RoleScheduler
+	} else if cfg.ProcessRole != "" && cfg.ProcessRole != string(bootstrap.RoleWorker) {
+		log.Fatalf("worker binary cannot run process role %q (use worker or scheduler)", cfg.ProcessRole)
+	}
+
+	app, err := bootstrap.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := app.Run(role); err != nil {
+		app.Close()
+		log.Fatal(err)
+	}
+
+	app.Close()
+	log.Println("Shutdown complete")
+}
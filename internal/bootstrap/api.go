@@ -0,0 +1,52 @@
+package bootstrap
+
+import (
+	"log"
+
+	"github.com/labstack/echo/v4"
+	echoMiddleware "github.com/labstack/echo/v4/middleware"
+
+	"github.com/nickkcj/orbit-backend/internal/handler"
+	"github.com/nickkcj/orbit-backend/internal/middleware"
+	"github.com/nickkcj/orbit-backend/internal/websocket"
+	"github.com/nickkcj/orbit-backend/internal/worker"
+)
+
+// newHTTPServer builds the Echo server with every route; the hub must exist
+func (a *App) newHTTPServer() *echo.Echo {
+	cfg := a.Config
+	services := a.Services
+
+	handlers := handler.New(services, a.TaskClient)
+	a.inspector = worker.NewInspector(a.RedisOpt)
+	handlers.UseInspector(a.inspector)
+
+	authMiddleware := middleware.NewAuthMiddleware(services.Auth)
+	tenantMiddleware := middleware.NewTenantMiddleware(services.Tenant, cfg.BaseDomain)
+	permissionMiddleware := middleware.NewPermissionMiddleware(services.Permission)
+	adminMiddleware := middleware.NewAdminMiddleware(cfg.PlatformAdminEmails)
+
+	wsAuthenticator := websocket.NewAuthenticator(services.Auth, services.Tenant, services.Member)
+	wsTopics := websocket.NewTopicAuthorizer(services.Permission, services.Post, services.Category, services.Course, services.Enrollment)
+	wsChat := websocket.NewLessonChat(services.LessonChat, services.Permission)
+	// Browsers may connect from the tenant's subdomain, the base domain and the frontend
+	wsOrigins := websocket.NewOriginPolicy(cfg.BaseDomain, append([]string{cfg.FrontendURL}, cfg.WSAllowedOrigins...)...)
+	wsHandler := websocket.NewHandler(a.hub, wsAuthenticator, wsTopics, wsChat, wsOrigins)
+
+	// Tickets must be redeemable on any instance
+	if a.Cache != nil {
+		wsAuthenticator.UseTicketStore(websocket.NewRedisTicketStore(a.Cache.GetClient()))
+		log.Println("WebSocket tickets shared through Redis")
+	}
+
+	e := echo.New()
+	e.HideBanner = true
+
+	e.Use(echoMiddleware.Logger())
+	e.Use(echoMiddleware.Recover())
+	e.Use(echoMiddleware.CORS())
+
+	handlers.RegisterRoutes(e, authMiddleware, tenantMiddleware, permissionMiddleware, adminMiddleware, wsHandler)
+
+	return e
+}
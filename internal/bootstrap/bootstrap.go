@@ -0,0 +1,172 @@
+package bootstrap
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hibiken/asynq"
+	_ "github.com/lib/pq"
+
+	"github.com/nickkcj/orbit-backend/internal/cache"
+	"github.com/nickkcj/orbit-backend/internal/config"
+	"github.com/nickkcj/orbit-backend/internal/database"
+	"github.com/nickkcj/orbit-backend/internal/events"
+	"github.com/nickkcj/orbit-backend/internal/service"
+	"github.com/nickkcj/orbit-backend/internal/websocket"
+	"github.com/nickkcj/orbit-backend/internal/worker"
+)
+
+// App holds the infrastructure and services shared by every process role
+type App struct {
+	Config     *config.Config
+	Conn       *sql.DB
+	DB         *database.Queries
+	RedisOpt   asynq.RedisClientOpt
+	Cache      *cache.RedisCache // Nil when Redis is unavailable
+	Events     *events.Bus
+	Services   *service.Services
+	TaskClient *worker.TaskClient
+
+	hub       *websocket.Hub
+	inspector *worker.Inspector // API role only
+}
+
+// New connects to the database and Redis and wires the services
+func New(cfg *config.Config) (*App, error) {
+	if cfg.DatabaseURL == "" {
+		return nil, fmt.Errorf("DATABASE_URL environment variable is required")
+	}
+
+	// Database connection with connection pool settings
+	conn, err := sql.Open("postgres", cfg.DatabaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	conn.SetMaxOpenConns(25)
+	conn.SetMaxIdleConns(5)
+	conn.SetConnMaxLifetime(5 * time.Minute)
+
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	log.Println("Connected to database")
+
+	app := &App{
+		Config: cfg,
+		Conn:   conn,
+		DB:     database.New(conn),
+		RedisOpt: asynq.RedisClientOpt{
+			Addr:     ParseRedisAddr(cfg.RedisURL),
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+		},
+		Events: events.NewBus(),
+	}
+
+	// Redis cache (optional)
+	var redisCache cache.Cache
+	app.Cache, err = cache.NewRedisCache(app.RedisOpt.Addr, cfg.RedisPassword, cfg.RedisDB)
+	if err != nil {
+		log.Printf("Warning: Failed to initialize Redis cache: %v (caching disabled)", err)
+		app.Cache = nil
+	} else {
+		redisCache = app.Cache
+		log.Println("Redis cache initialized")
+	}
+
+	storageConfig := &service.StorageConfig{
+		AccountID:       cfg.R2AccountID,
+		AccessKeyID:     cfg.R2AccessKeyID,
+		SecretAccessKey: cfg.R2SecretAccessKey,
+		BucketName:      cfg.R2BucketName,
+	}
+
+	streamConfig := &service.StreamConfig{
+		AccountID:     cfg.CloudflareAccountID,
+		APIToken:      cfg.CloudflareStreamAPIToken,
+		SigningKey:    cfg.CloudflareStreamSigningKey,
+		WebhookSecret: cfg.CloudflareStreamWebhookSecret,
+	}
+
+	var googleConfig *service.GoogleOAuthConfig
+	if cfg.GoogleClientID != "" && cfg.GoogleClientSecret != "" {
+		googleConfig = &service.GoogleOAuthConfig{
+			ClientID:     cfg.GoogleClientID,
+			ClientSecret: cfg.GoogleClientSecret,
+			RedirectURL:  cfg.GoogleRedirectURL,
+			FrontendURL:  cfg.FrontendURL,
+		}
+		log.Println("Google OAuth configured")
+	}
+
+	sessionConfig := &service.SessionConfig{
+		AccessTokenTTL:  cfg.AccessTokenTTL,
+		RefreshTokenTTL: cfg.RefreshTokenTTL,
+	}
+
+	// Email config (SMTP, or a file sink in development)
+	emailConfig := &service.EmailConfig{
+		SMTPHost:     cfg.SMTPHost,
+		SMTPPort:     cfg.SMTPPort,
+		SMTPUsername: cfg.SMTPUsername,
+		SMTPPassword: cfg.SMTPPassword,
+		From:         cfg.EmailFrom,
+		OutboxDir:    cfg.EmailOutboxDir,
+		BaseDomain:   cfg.BaseDomain,
+		APIURL:       cfg.APIURL,
+		FrontendURL:  cfg.FrontendURL,
+	}
+
+	pushConfig := &service.PushConfig{
+		PublicKey:  cfg.VAPIDPublicKey,
+		PrivateKey: cfg.VAPIDPrivateKey,
+		Subject:    cfg.VAPIDSubject,
+	}
+
+	app.Services = service.New(app.DB, cfg.JWTSecret, storageConfig, streamConfig, googleConfig, sessionConfig, emailConfig, pushConfig, redisCache, app.Events)
+	app.TaskClient = worker.NewTaskClient(app.RedisOpt)
+
+	return app, nil
+}
+
+// Close releases the task client, Redis and the database, in that order
+func (a *App) Close() {
+	log.Println("Closing task client...")
+	if err := a.TaskClient.Close(); err != nil {
+		log.Printf("Task client close error: %v", err)
+	}
+	if a.inspector != nil {
+		if err := a.inspector.Close(); err != nil {
+			log.Printf("Task inspector close error: %v", err)
+		}
+	}
+
+	if a.Cache != nil {
+		log.Println("Closing Redis cache...")
+		if err := a.Cache.Close(); err != nil {
+			log.Printf("Redis cache close error: %v", err)
+		}
+	}
+
+	log.Println("Closing database connection...")
+	if err := a.Conn.Close(); err != nil {
+		log.Printf("Database close error: %v", err)
+	}
+}
+
+// ParseRedisAddr extracts the host:port from a Redis URL
+func ParseRedisAddr(redisURL string) string {
+	if addr, ok := strings.CutPrefix(redisURL, "redis://"); ok {
+		return addr
+	}
+	// TLS
+	if addr, ok := strings.CutPrefix(redisURL, "rediss://"); ok {
+		return addr
+	}
+	return redisURL
+}
@@ -0,0 +1,30 @@
+package bootstrap
+
+import "fmt"
+
+// Role selects which components a process runs
+type Role string
+
+const (
+	RoleAPI       Role = "api"       // HTTP API and WebSocket connections
+	RoleWorker    Role = "worker"    // Task processing and the outbox relay
+	RoleScheduler Role = "scheduler" // Recurring job enqueueing
+	RoleAll       Role = "all"       // Everything in one process
+)
+
+// ParseRole validates a PROCESS_ROLE value; empty means RoleAll
+func ParseRole(value string) (Role, error) {
+	switch role := Role(value); role {
+	case "":
+		return RoleAll, nil
+	case RoleAPI, RoleWorker, RoleScheduler, RoleAll:
+		return role, nil
+	default:
+		return "", fmt.Errorf("unknown process role %q (expected api, worker, scheduler or all)", value)
+	}
+}
+
+// Runs reports whether the role includes the given component role
+func (r Role) Runs(component Role) bool {
+	return r == RoleAll || r == component
+}
@@ -0,0 +1,175 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/nickkcj/orbit-backend/internal/outbox"
+	"github.com/nickkcj/orbit-backend/internal/websocket"
+	"github.com/nickkcj/orbit-backend/internal/worker"
+)
+
+// component is a long-running part of a process. start must not block.
+type component struct {
+	name  string
+	start func() error
+	stop  func(ctx context.Context)
+}
+
+// Run starts the components of the role, waits for SIGINT or SIGTERM and
+// stops them in reverse order within the configured shutdown timeout
+func (a *App) Run(role Role) error {
+	components, err := a.components(role)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Starting process (role: %s)", role)
+
+	var started []component
+	for _, c := range components {
+		if err := c.start(); err != nil {
+			// A single-purpose process is useless without its component; the
+			// all-in-one process keeps serving what it can, as in development
+			if role != RoleAll {
+				a.stop(started)
+				return fmt.Errorf("failed to start %s: %w", c.name, err)
+			}
+			log.Printf("%s error: %v", c.name, err)
+			continue
+		}
+		started = append(started, c)
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	log.Println("Shutting down gracefully...")
+	a.stop(started)
+	return nil
+}
+
+func (a *App) stop(started []component) {
+	ctx, cancel := context.WithTimeout(context.Background(), a.Config.ShutdownTimeout)
+	defer cancel()
+
+	for i := len(started) - 1; i >= 0; i-- {
+		log.Printf("Stopping %s...", started[i].name)
+		started[i].stop(ctx)
+	}
+}
+
+// components lists what the role runs in start order: the hub first so events
+// published by the others are delivered, the HTTP server last so requests
+// stop before the background machinery.
+func (a *App) components(role Role) ([]component, error) {
+	var components []component
+
+	// Every role that publishes domain events needs the hub: worker processes
+	// have no WebSocket clients but relay events to API instances through the
+	// Redis backplane
+	if role.Runs(RoleAPI) || role.Runs(RoleWorker) {
+		hub := a.newHub()
+		hubCtx, hubCancel := context.WithCancel(context.Background())
+		components = append(components, component{
+			name:  "WebSocket hub",
+			start: func() error { go hub.Run(hubCtx); return nil },
+			stop:  func(context.Context) { hubCancel() },
+		})
+		a.hub = hub
+	}
+
+	if role.Runs(RoleWorker) {
+		workerServer := worker.NewWorker(a.RedisOpt, a.Config.WorkerConcurrency, a.Services, a.TaskClient)
+		components = append(components, component{
+			name:  "background worker",
+			start: workerServer.Start,
+			stop:  func(context.Context) { workerServer.Shutdown() },
+		})
+
+		// Outbox relay moves committed background tasks into the queue
+		relay := outbox.NewRelay(a.DB, a.TaskClient, a.Config.OutboxPollInterval, a.Config.OutboxBatchSize)
+		relayCtx, relayCancel := context.WithCancel(context.Background())
+		components = append(components, component{
+			name:  "outbox relay",
+			start: func() error { go relay.Run(relayCtx); return nil },
+			stop:  func(context.Context) { relayCancel() },
+		})
+	}
+
+	if role.Runs(RoleScheduler) {
+		scheduler, err := a.newScheduler()
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, component{
+			name:  "scheduler",
+			start: scheduler.Start,
+			stop:  func(context.Context) { scheduler.Shutdown() },
+		})
+	}
+
+	if role.Runs(RoleAPI) {
+		e := a.newHTTPServer()
+		components = append(components, component{
+			name: "HTTP server",
+			start: func() error {
+				go func() {
+					log.Printf("Server starting on port %s (base domain: %s)", a.Config.Port, a.Config.BaseDomain)
+					if err := e.Start(":" + a.Config.Port); err != nil && err != http.ErrServerClosed {
+						log.Printf("HTTP server error: %v", err)
+					}
+				}()
+				return nil
+			},
+			stop: func(ctx context.Context) {
+				if err := e.Shutdown(ctx); err != nil {
+					log.Printf("HTTP server shutdown error: %v", err)
+				}
+			},
+		})
+	}
+
+	return components, nil
+}
+
+// newHub creates the WebSocket hub, subscribed to domain events and sharing
+// state with other instances through Redis when available
+func (a *App) newHub() *websocket.Hub {
+	hub := websocket.NewHub()
+	a.Events.Subscribe(hub.HandleEvent)
+
+	if a.Cache != nil {
+		hub.UseBackplane(websocket.NewRedisBackplane(a.Cache.GetClient()))
+		hub.UsePresenceStore(websocket.NewRedisPresenceStore(a.Cache.GetClient()))
+		hub.UseReplayLog(websocket.NewRedisReplayLog(a.Cache.GetClient()))
+		log.Println("WebSocket Redis backplane enabled")
+	}
+	return hub
+}
+
+func (a *App) newScheduler() (*worker.Scheduler, error) {
+	jobs, err := worker.MaintenanceJobs(worker.MaintenanceConfig{
+		PurgeNotificationsSpec: a.Config.CronPurgeNotifications,
+		NotificationRetention:  a.Config.NotificationRetention,
+		PruneWebhooksSpec:      a.Config.CronPruneWebhooks,
+		WebhookRetention:       a.Config.WebhookRetention,
+		ExpireVideosSpec:       a.Config.CronExpireVideos,
+		StaleVideoAfter:        a.Config.StaleVideoAfter,
+		AnalyticsRollupSpec:    a.Config.CronAnalyticsRollup,
+		AnalyticsRollupDays:    a.Config.AnalyticsRollupDays,
+		ReconcileVideosSpec:    a.Config.CronReconcileVideos,
+		VideoReconcileAfter:    a.Config.VideoReconcileAfter,
+		ScheduleDigestsSpec:    a.Config.CronScheduleDigests,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build scheduled jobs: %w", err)
+	}
+	return worker.NewScheduler(a.RedisOpt, jobs)
+}
@@ -12,6 +12,7 @@ const (
 	PrefixPermission = "perms"
 	PrefixPosts      = "posts"
 	PrefixMember     = "member"
+	PrefixSession    = "session"
 )
 
 // Cache TTLs
@@ -20,6 +21,7 @@ const (
 	TTLPermissions = 5 * 60  // 5 minutes in seconds
 	TTLPosts       = 1 * 60  // 1 minute in seconds
 	TTLMember      = 5 * 60  // 5 minutes in seconds
+	TTLSession     = 30      // 30 seconds
 )
 
 // TenantBySlugKey returns the cache key for tenant by slug
@@ -47,6 +49,11 @@ func MemberKey(tenantID, userID uuid.UUID) string {
 	return fmt.Sprintf("%s:%s:%s", PrefixMember, tenantID, userID)
 }
 
+// SessionKey returns the cache key marking a session as valid for its user
+func SessionKey(userID, sessionID uuid.UUID) string {
+	return fmt.Sprintf("%s:%s:%s", PrefixSession, userID, sessionID)
+}
+
 // Pattern builders for bulk invalidation
 
 // TenantPattern returns pattern to invalidate all tenant cache
@@ -63,3 +70,8 @@ func PermissionsTenantPattern(tenantID uuid.UUID) string {
 func PostsTenantPattern(tenantID uuid.UUID) string {
 	return fmt.Sprintf("%s:*:%s:*", PrefixPosts, tenantID)
 }
+
+// UserSessionsPattern returns pattern to invalidate all sessions of a user
+func UserSessionsPattern(userID uuid.UUID) string {
+	return fmt.Sprintf("%s:%s:*", PrefixSession, userID)
+}
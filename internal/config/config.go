@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -15,6 +16,15 @@ type Config struct {
 	JWTSecret   string
 	BaseDomain  string
 	FrontendURL string
+	APIURL      string // Public base URL of this API, used in links sent by email
+	ProcessRole string // api, worker, scheduler or all (default)
+
+	// Platform operators allowed on /api/v1/admin
+	PlatformAdminEmails []string
+
+	// Sessions: short-lived access tokens, refresh tokens valid while used
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
 
 	// Google OAuth
 	GoogleClientID     string
@@ -36,14 +46,45 @@ type Config struct {
 	WorkerConcurrency int
 	ShutdownTimeout   time.Duration
 
+	// Outbox relay
+	OutboxPollInterval time.Duration
+	OutboxBatchSize    int
+
+	// Scheduler (cron specs in UTC; "off" disables a job)
+	CronPurgeNotifications string
+	CronPruneWebhooks      string
+	CronExpireVideos       string
+	CronAnalyticsRollup    string
+	CronReconcileVideos    string
+	CronScheduleDigests    string // Hourly is enough: members pick the hour
+	NotificationRetention  time.Duration
+	WebhookRetention       time.Duration
+	StaleVideoAfter        time.Duration
+	AnalyticsRollupDays    int
+	VideoReconcileAfter    time.Duration // Uploading/processing this long triggers a provider poll
+
 	// WebSocket
-	WSPingInterval time.Duration
-	WSWriteTimeout time.Duration
+	WSPingInterval   time.Duration
+	WSWriteTimeout   time.Duration
+	WSAllowedOrigins []string
+
+	// Email
+	SMTPHost       string
+	SMTPPort       int
+	SMTPUsername   string
+	SMTPPassword   string
+	EmailFrom      string
+	EmailOutboxDir string
+
+	// Web Push (VAPID keys are base64url; push is off without them)
+	VAPIDPublicKey  string
+	VAPIDPrivateKey string
+	VAPIDSubject    string
 
 	// Cloudflare Stream
-	CloudflareAccountID         string
-	CloudflareStreamAPIToken    string
-	CloudflareStreamSigningKey  string
+	CloudflareAccountID           string
+	CloudflareStreamAPIToken      string
+	CloudflareStreamSigningKey    string
 	CloudflareStreamWebhookSecret string
 }
 
@@ -58,6 +99,14 @@ func Load() *Config {
 		JWTSecret:   getEnv("JWT_SECRET", "change-me-in-production"),
 		BaseDomain:  getEnv("BASE_DOMAIN", "orbit.app.br"),
 		FrontendURL: getEnv("FRONTEND_URL", "http://localhost:3000"),
+		APIURL:      getEnv("API_URL", "http://localhost:8080"),
+		ProcessRole: getEnv("PROCESS_ROLE", ""),
+
+		PlatformAdminEmails: getEnvList("PLATFORM_ADMIN_EMAILS"),
+
+		// Sessions
+		AccessTokenTTL:  getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: getEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
 
 		// Google OAuth
 		GoogleClientID:     getEnv("GOOGLE_CLIENT_ID", ""),
@@ -79,14 +128,45 @@ func Load() *Config {
 		WorkerConcurrency: getEnvInt("WORKER_CONCURRENCY", 10),
 		ShutdownTimeout:   getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
 
+		// Outbox relay
+		OutboxPollInterval: getEnvDuration("OUTBOX_POLL_INTERVAL", 1*time.Second),
+		OutboxBatchSize:    getEnvInt("OUTBOX_BATCH_SIZE", 100),
+
+		// Scheduler
+		CronPurgeNotifications: getEnv("CRON_PURGE_NOTIFICATIONS", "0 3 * * *"),
+		CronPruneWebhooks:      getEnv("CRON_PRUNE_WEBHOOKS", "30 3 * * *"),
+		CronExpireVideos:       getEnv("CRON_EXPIRE_VIDEOS", "*/15 * * * *"),
+		CronAnalyticsRollup:    getEnv("CRON_ANALYTICS_ROLLUP", "5 * * * *"),
+		CronReconcileVideos:    getEnv("CRON_RECONCILE_VIDEOS", "*/10 * * * *"),
+		CronScheduleDigests:    getEnv("CRON_SCHEDULE_DIGESTS", "0 * * * *"),
+		NotificationRetention:  getEnvDuration("NOTIFICATION_RETENTION", 30*24*time.Hour),
+		WebhookRetention:       getEnvDuration("WEBHOOK_RETENTION", 30*24*time.Hour),
+		StaleVideoAfter:        getEnvDuration("STALE_VIDEO_AFTER", 24*time.Hour),
+		AnalyticsRollupDays:    getEnvInt("ANALYTICS_ROLLUP_DAYS", 2),
+		VideoReconcileAfter:    getEnvDuration("VIDEO_RECONCILE_AFTER", 30*time.Minute),
+
 		// WebSocket
-		WSPingInterval: getEnvDuration("WS_PING_INTERVAL", 30*time.Second),
-		WSWriteTimeout: getEnvDuration("WS_WRITE_TIMEOUT", 10*time.Second),
+		WSPingInterval:   getEnvDuration("WS_PING_INTERVAL", 30*time.Second),
+		WSWriteTimeout:   getEnvDuration("WS_WRITE_TIMEOUT", 10*time.Second),
+		WSAllowedOrigins: getEnvList("WS_ALLOWED_ORIGINS"),
+
+		// Email
+		SMTPHost:       getEnv("SMTP_HOST", ""),
+		SMTPPort:       getEnvInt("SMTP_PORT", 587),
+		SMTPUsername:   getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:   getEnv("SMTP_PASSWORD", ""),
+		EmailFrom:      getEnv("EMAIL_FROM", "Orbit <no-reply@orbit.app.br>"),
+		EmailOutboxDir: getEnv("EMAIL_OUTBOX_DIR", ""),
+
+		// Web Push
+		VAPIDPublicKey:  getEnv("VAPID_PUBLIC_KEY", ""),
+		VAPIDPrivateKey: getEnv("VAPID_PRIVATE_KEY", ""),
+		VAPIDSubject:    getEnv("VAPID_SUBJECT", "mailto:no-reply@orbit.app.br"),
 
 		// Cloudflare Stream
-		CloudflareAccountID:         getEnv("CLOUDFLARE_ACCOUNT_ID", ""),
-		CloudflareStreamAPIToken:    getEnv("CLOUDFLARE_STREAM_API_TOKEN", ""),
-		CloudflareStreamSigningKey:  getEnv("CLOUDFLARE_STREAM_SIGNING_KEY", ""),
+		CloudflareAccountID:           getEnv("CLOUDFLARE_ACCOUNT_ID", ""),
+		CloudflareStreamAPIToken:      getEnv("CLOUDFLARE_STREAM_API_TOKEN", ""),
+		CloudflareStreamSigningKey:    getEnv("CLOUDFLARE_STREAM_SIGNING_KEY", ""),
 		CloudflareStreamWebhookSecret: getEnv("CLOUDFLARE_STREAM_WEBHOOK_SECRET", ""),
 	}
 }
@@ -98,6 +178,16 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 func getEnvInt(key string, fallback int) int {
 	if value := os.Getenv(key); value != "" {
 		if intVal, err := strconv.Atoi(value); err == nil {
@@ -243,3 +243,33 @@ func (q *Queries) GetTopPosts(ctx context.Context, arg GetTopPostsParams) ([]Get
 	}
 	return items, nil
 }
+
+const rollupTenantDailyStats = `-- name: RollupTenantDailyStats :execrows
+INSERT INTO tenant_daily_stats (tenant_id, day, new_members, new_posts, new_comments, computed_at)
+SELECT
+    t.id,
+    d.day::date,
+    (SELECT COUNT(*) FROM tenant_members tm
+        WHERE tm.tenant_id = t.id AND tm.joined_at >= d.day AND tm.joined_at < d.day + INTERVAL '1 day'),
+    (SELECT COUNT(*) FROM posts p
+        WHERE p.tenant_id = t.id AND p.status = 'published' AND p.created_at >= d.day AND p.created_at < d.day + INTERVAL '1 day'),
+    (SELECT COUNT(*) FROM comments c JOIN posts p ON c.post_id = p.id
+        WHERE p.tenant_id = t.id AND c.created_at >= d.day AND c.created_at < d.day + INTERVAL '1 day'),
+    NOW()
+FROM tenants t
+CROSS JOIN generate_series($1::date, CURRENT_DATE, INTERVAL '1 day') AS d(day)
+WHERE t.status = 'active'
+ON CONFLICT (tenant_id, day) DO UPDATE SET
+    new_members = EXCLUDED.new_members,
+    new_posts = EXCLUDED.new_posts,
+    new_comments = EXCLUDED.new_comments,
+    computed_at = EXCLUDED.computed_at
+`
+
+func (q *Queries) RollupTenantDailyStats(ctx context.Context, since time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, rollupTenantDailyStats, since)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
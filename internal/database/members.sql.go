@@ -17,7 +17,7 @@ import (
 const addMember = `-- name: AddMember :one
 INSERT INTO tenant_members (tenant_id, user_id, role_id, display_name)
 VALUES ($1, $2, $3, $4)
-RETURNING id, tenant_id, user_id, role_id, display_name, bio, status, joined_at, updated_at
+RETURNING id, tenant_id, user_id, role_id, display_name, bio, status, joined_at, updated_at, handle
 `
 
 type AddMemberParams struct {
@@ -45,6 +45,7 @@ func (q *Queries) AddMember(ctx context.Context, arg AddMemberParams) (TenantMem
 		&i.Status,
 		&i.JoinedAt,
 		&i.UpdatedAt,
+		&i.Handle,
 	)
 	return i, err
 }
@@ -61,7 +62,7 @@ func (q *Queries) CountMembersByTenant(ctx context.Context, tenantID uuid.UUID)
 }
 
 const getMember = `-- name: GetMember :one
-SELECT id, tenant_id, user_id, role_id, display_name, bio, status, joined_at, updated_at FROM tenant_members WHERE tenant_id = $1 AND user_id = $2
+SELECT id, tenant_id, user_id, role_id, display_name, bio, status, joined_at, updated_at, handle FROM tenant_members WHERE tenant_id = $1 AND user_id = $2
 `
 
 type GetMemberParams struct {
@@ -82,13 +83,41 @@ func (q *Queries) GetMember(ctx context.Context, arg GetMemberParams) (TenantMem
 		&i.Status,
 		&i.JoinedAt,
 		&i.UpdatedAt,
+		&i.Handle,
+	)
+	return i, err
+}
+
+const getMemberByHandle = `-- name: GetMemberByHandle :one
+SELECT id, tenant_id, user_id, role_id, display_name, bio, status, joined_at, updated_at, handle FROM tenant_members WHERE tenant_id = $1 AND handle = $2
+`
+
+type GetMemberByHandleParams struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	Handle   string    `json:"handle"`
+}
+
+func (q *Queries) GetMemberByHandle(ctx context.Context, arg GetMemberByHandleParams) (TenantMember, error) {
+	row := q.db.QueryRowContext(ctx, getMemberByHandle, arg.TenantID, arg.Handle)
+	var i TenantMember
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.UserID,
+		&i.RoleID,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Status,
+		&i.JoinedAt,
+		&i.UpdatedAt,
+		&i.Handle,
 	)
 	return i, err
 }
 
 const getMemberProfile = `-- name: GetMemberProfile :one
 SELECT
-    tm.id, tm.tenant_id, tm.user_id, tm.role_id, tm.display_name, tm.bio, tm.status, tm.joined_at, tm.updated_at,
+    tm.id, tm.tenant_id, tm.user_id, tm.role_id, tm.display_name, tm.bio, tm.status, tm.joined_at, tm.updated_at, tm.handle,
     u.email,
     u.name as user_name,
     u.avatar_url as user_avatar,
@@ -116,6 +145,7 @@ type GetMemberProfileRow struct {
 	Status      string         `json:"status"`
 	JoinedAt    time.Time      `json:"joined_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
+	Handle      string         `json:"handle"`
 	Email       string         `json:"email"`
 	UserName    string         `json:"user_name"`
 	UserAvatar  sql.NullString `json:"user_avatar"`
@@ -137,6 +167,7 @@ func (q *Queries) GetMemberProfile(ctx context.Context, arg GetMemberProfilePara
 		&i.Status,
 		&i.JoinedAt,
 		&i.UpdatedAt,
+		&i.Handle,
 		&i.Email,
 		&i.UserName,
 		&i.UserAvatar,
@@ -149,7 +180,7 @@ func (q *Queries) GetMemberProfile(ctx context.Context, arg GetMemberProfilePara
 
 const getMemberWithRole = `-- name: GetMemberWithRole :one
 SELECT
-    tm.id, tm.tenant_id, tm.user_id, tm.role_id, tm.display_name, tm.bio, tm.status, tm.joined_at, tm.updated_at,
+    tm.id, tm.tenant_id, tm.user_id, tm.role_id, tm.display_name, tm.bio, tm.status, tm.joined_at, tm.updated_at, tm.handle,
     r.slug as role_slug,
     r.name as role_name,
     r.priority as role_priority
@@ -173,6 +204,7 @@ type GetMemberWithRoleRow struct {
 	Status       string         `json:"status"`
 	JoinedAt     time.Time      `json:"joined_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
+	Handle       string         `json:"handle"`
 	RoleSlug     string         `json:"role_slug"`
 	RoleName     string         `json:"role_name"`
 	RolePriority int32          `json:"role_priority"`
@@ -191,6 +223,7 @@ func (q *Queries) GetMemberWithRole(ctx context.Context, arg GetMemberWithRolePa
 		&i.Status,
 		&i.JoinedAt,
 		&i.UpdatedAt,
+		&i.Handle,
 		&i.RoleSlug,
 		&i.RoleName,
 		&i.RolePriority,
@@ -200,7 +233,7 @@ func (q *Queries) GetMemberWithRole(ctx context.Context, arg GetMemberWithRolePa
 
 const listMembersByTenant = `-- name: ListMembersByTenant :many
 SELECT
-    tm.id, tm.tenant_id, tm.user_id, tm.role_id, tm.display_name, tm.bio, tm.status, tm.joined_at, tm.updated_at,
+    tm.id, tm.tenant_id, tm.user_id, tm.role_id, tm.display_name, tm.bio, tm.status, tm.joined_at, tm.updated_at, tm.handle,
     u.email,
     u.name as user_name,
     u.avatar_url as user_avatar,
@@ -223,6 +256,7 @@ type ListMembersByTenantRow struct {
 	Status      string         `json:"status"`
 	JoinedAt    time.Time      `json:"joined_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
+	Handle      string         `json:"handle"`
 	Email       string         `json:"email"`
 	UserName    string         `json:"user_name"`
 	UserAvatar  sql.NullString `json:"user_avatar"`
@@ -249,6 +283,7 @@ func (q *Queries) ListMembersByTenant(ctx context.Context, tenantID uuid.UUID) (
 			&i.Status,
 			&i.JoinedAt,
 			&i.UpdatedAt,
+			&i.Handle,
 			&i.Email,
 			&i.UserName,
 			&i.UserAvatar,
@@ -357,11 +392,100 @@ func (q *Queries) RemoveMember(ctx context.Context, arg RemoveMemberParams) erro
 	return err
 }
 
+const searchMembersForMention = `-- name: SearchMembersForMention :many
+SELECT
+    tm.user_id,
+    tm.handle,
+    tm.display_name,
+    u.name as user_name,
+    u.avatar_url as user_avatar
+FROM tenant_members tm
+JOIN users u ON tm.user_id = u.id
+WHERE tm.tenant_id = $1 AND tm.status = 'active'
+AND (tm.handle LIKE $2 OR tm.display_name ILIKE $2 OR u.name ILIKE $2)
+ORDER BY tm.handle
+LIMIT $3
+`
+
+type SearchMembersForMentionParams struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	Handle   string    `json:"handle"`
+	Limit    int32     `json:"limit"`
+}
+
+type SearchMembersForMentionRow struct {
+	UserID      uuid.UUID      `json:"user_id"`
+	Handle      string         `json:"handle"`
+	DisplayName sql.NullString `json:"display_name"`
+	UserName    string         `json:"user_name"`
+	UserAvatar  sql.NullString `json:"user_avatar"`
+}
+
+func (q *Queries) SearchMembersForMention(ctx context.Context, arg SearchMembersForMentionParams) ([]SearchMembersForMentionRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchMembersForMention, arg.TenantID, arg.Handle, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchMembersForMentionRow
+	for rows.Next() {
+		var i SearchMembersForMentionRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Handle,
+			&i.DisplayName,
+			&i.UserName,
+			&i.UserAvatar,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateMemberHandle = `-- name: UpdateMemberHandle :one
+UPDATE tenant_members
+SET handle = $3, updated_at = NOW()
+WHERE tenant_id = $1 AND user_id = $2
+RETURNING id, tenant_id, user_id, role_id, display_name, bio, status, joined_at, updated_at, handle
+`
+
+type UpdateMemberHandleParams struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	UserID   uuid.UUID `json:"user_id"`
+	Handle   string    `json:"handle"`
+}
+
+func (q *Queries) UpdateMemberHandle(ctx context.Context, arg UpdateMemberHandleParams) (TenantMember, error) {
+	row := q.db.QueryRowContext(ctx, updateMemberHandle, arg.TenantID, arg.UserID, arg.Handle)
+	var i TenantMember
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.UserID,
+		&i.RoleID,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Status,
+		&i.JoinedAt,
+		&i.UpdatedAt,
+		&i.Handle,
+	)
+	return i, err
+}
+
 const updateMemberProfile = `-- name: UpdateMemberProfile :one
 UPDATE tenant_members
 SET display_name = $3, bio = $4, updated_at = NOW()
 WHERE tenant_id = $1 AND user_id = $2
-RETURNING id, tenant_id, user_id, role_id, display_name, bio, status, joined_at, updated_at
+RETURNING id, tenant_id, user_id, role_id, display_name, bio, status, joined_at, updated_at, handle
 `
 
 type UpdateMemberProfileParams struct {
@@ -389,6 +513,7 @@ func (q *Queries) UpdateMemberProfile(ctx context.Context, arg UpdateMemberProfi
 		&i.Status,
 		&i.JoinedAt,
 		&i.UpdatedAt,
+		&i.Handle,
 	)
 	return i, err
 }
@@ -397,7 +522,7 @@ const updateMemberRole = `-- name: UpdateMemberRole :one
 UPDATE tenant_members
 SET role_id = $3, updated_at = NOW()
 WHERE tenant_id = $1 AND user_id = $2
-RETURNING id, tenant_id, user_id, role_id, display_name, bio, status, joined_at, updated_at
+RETURNING id, tenant_id, user_id, role_id, display_name, bio, status, joined_at, updated_at, handle
 `
 
 type UpdateMemberRoleParams struct {
@@ -419,6 +544,7 @@ func (q *Queries) UpdateMemberRole(ctx context.Context, arg UpdateMemberRolePara
 		&i.Status,
 		&i.JoinedAt,
 		&i.UpdatedAt,
+		&i.Handle,
 	)
 	return i, err
 }
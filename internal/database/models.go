@@ -13,6 +13,13 @@ import (
 	"github.com/sqlc-dev/pqtype"
 )
 
+type AuthorFollow struct {
+	TenantID   uuid.UUID `json:"tenant_id"`
+	AuthorID   uuid.UUID `json:"author_id"`
+	FollowerID uuid.UUID `json:"follower_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
 type Category struct {
 	ID          uuid.UUID      `json:"id"`
 	TenantID    uuid.UUID      `json:"tenant_id"`
@@ -26,6 +33,13 @@ type Category struct {
 	UpdatedAt   time.Time      `json:"updated_at"`
 }
 
+type CategoryFollow struct {
+	TenantID   uuid.UUID `json:"tenant_id"`
+	CategoryID uuid.UUID `json:"category_id"`
+	UserID     uuid.UUID `json:"user_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
 type Comment struct {
 	ID         uuid.UUID     `json:"id"`
 	TenantID   uuid.UUID     `json:"tenant_id"`
@@ -41,6 +55,34 @@ type Comment struct {
 	UpdatedAt  time.Time     `json:"updated_at"`
 }
 
+type Conversation struct {
+	ID            uuid.UUID      `json:"id"`
+	TenantID      uuid.UUID      `json:"tenant_id"`
+	IsGroup       bool           `json:"is_group"`
+	Title         sql.NullString `json:"title"`
+	CreatedBy     uuid.UUID      `json:"created_by"`
+	DirectKey     sql.NullString `json:"direct_key"`
+	LastMessageAt sql.NullTime   `json:"last_message_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+type ConversationMessage struct {
+	ID             uuid.UUID `json:"id"`
+	TenantID       uuid.UUID `json:"tenant_id"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	SenderID       uuid.UUID `json:"sender_id"`
+	Content        string    `json:"content"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type ConversationParticipant struct {
+	ConversationID uuid.UUID `json:"conversation_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	LastReadAt     time.Time `json:"last_read_at"`
+	JoinedAt       time.Time `json:"joined_at"`
+}
+
 type Course struct {
 	ID           uuid.UUID      `json:"id"`
 	TenantID     uuid.UUID      `json:"tenant_id"`
@@ -74,6 +116,27 @@ type CourseEnrollment struct {
 	UpdatedAt             time.Time     `json:"updated_at"`
 }
 
+type DigestSetting struct {
+	TenantID   uuid.UUID      `json:"tenant_id"`
+	UserID     uuid.UUID      `json:"user_id"`
+	Frequency  string         `json:"frequency"`
+	Hour       int32          `json:"hour"`
+	Weekday    int32          `json:"weekday"`
+	Timezone   string         `json:"timezone"`
+	LastPeriod sql.NullString `json:"last_period"`
+	LastSentAt sql.NullTime   `json:"last_sent_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+type EmailVerificationToken struct {
+	TokenHash string       `json:"token_hash"`
+	UserID    uuid.UUID    `json:"user_id"`
+	Email     string       `json:"email"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
 type Lesson struct {
 	ID              uuid.UUID      `json:"id"`
 	TenantID        uuid.UUID      `json:"tenant_id"`
@@ -90,6 +153,20 @@ type Lesson struct {
 	UpdatedAt       time.Time      `json:"updated_at"`
 }
 
+type LessonChatMessage struct {
+	ID                    uuid.UUID     `json:"id"`
+	TenantID              uuid.UUID     `json:"tenant_id"`
+	LessonID              uuid.UUID     `json:"lesson_id"`
+	AuthorID              uuid.UUID     `json:"author_id"`
+	Content               string        `json:"content"`
+	VideoTimestampSeconds sql.NullInt32 `json:"video_timestamp_seconds"`
+	Status                string        `json:"status"`
+	DeletedBy             uuid.NullUUID `json:"deleted_by"`
+	EditedAt              sql.NullTime  `json:"edited_at"`
+	CreatedAt             time.Time     `json:"created_at"`
+	UpdatedAt             time.Time     `json:"updated_at"`
+}
+
 type LessonProgress struct {
 	ID                   uuid.UUID     `json:"id"`
 	TenantID             uuid.UUID     `json:"tenant_id"`
@@ -113,6 +190,30 @@ type Like struct {
 	CreatedAt time.Time     `json:"created_at"`
 }
 
+type LoginCode struct {
+	CodeHash  string    `json:"code_hash"`
+	SessionID uuid.UUID `json:"session_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MemberBlock struct {
+	TenantID  uuid.UUID `json:"tenant_id"`
+	BlockerID uuid.UUID `json:"blocker_id"`
+	BlockedID uuid.UUID `json:"blocked_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Mention struct {
+	ID              uuid.UUID     `json:"id"`
+	TenantID        uuid.UUID     `json:"tenant_id"`
+	PostID          uuid.UUID     `json:"post_id"`
+	CommentID       uuid.NullUUID `json:"comment_id"`
+	AuthorID        uuid.UUID     `json:"author_id"`
+	MentionedUserID uuid.UUID     `json:"mentioned_user_id"`
+	CreatedAt       time.Time     `json:"created_at"`
+}
+
 type Module struct {
 	ID          uuid.UUID      `json:"id"`
 	TenantID    uuid.UUID      `json:"tenant_id"`
@@ -126,15 +227,44 @@ type Module struct {
 }
 
 type Notification struct {
-	ID        uuid.UUID             `json:"id"`
-	TenantID  uuid.UUID             `json:"tenant_id"`
-	UserID    uuid.UUID             `json:"user_id"`
-	Type      string                `json:"type"`
-	Title     string                `json:"title"`
-	Message   sql.NullString        `json:"message"`
-	Data      pqtype.NullRawMessage `json:"data"`
-	ReadAt    sql.NullTime          `json:"read_at"`
-	CreatedAt time.Time             `json:"created_at"`
+	ID         uuid.UUID             `json:"id"`
+	TenantID   uuid.UUID             `json:"tenant_id"`
+	UserID     uuid.UUID             `json:"user_id"`
+	Type       string                `json:"type"`
+	Title      string                `json:"title"`
+	Message    sql.NullString        `json:"message"`
+	Data       pqtype.NullRawMessage `json:"data"`
+	ReadAt     sql.NullTime          `json:"read_at"`
+	CreatedAt  time.Time             `json:"created_at"`
+	GroupKey   sql.NullString        `json:"group_key"`
+	ActorCount int32                 `json:"actor_count"`
+}
+
+type NotificationGroupActor struct {
+	NotificationID uuid.UUID `json:"notification_id"`
+	ActorID        uuid.UUID `json:"actor_id"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type NotificationPreference struct {
+	TenantID  uuid.UUID `json:"tenant_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Type      string    `json:"type"`
+	InApp     bool      `json:"in_app"`
+	Email     bool      `json:"email"`
+	Push      bool      `json:"push"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type OutboxMessage struct {
+	ID          uuid.UUID       `json:"id"`
+	TaskType    string          `json:"task_type"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int32           `json:"attempts"`
+	LastError   sql.NullString  `json:"last_error"`
+	AvailableAt time.Time       `json:"available_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	FailedAt    sql.NullTime    `json:"failed_at"`
 }
 
 type Permission struct {
@@ -166,6 +296,24 @@ type Post struct {
 	UpdatedAt     time.Time      `json:"updated_at"`
 }
 
+type PushSubscription struct {
+	ID         uuid.UUID      `json:"id"`
+	UserID     uuid.UUID      `json:"user_id"`
+	Endpoint   string         `json:"endpoint"`
+	P256dh     string         `json:"p256dh"`
+	Auth       string         `json:"auth"`
+	UserAgent  sql.NullString `json:"user_agent"`
+	CreatedAt  time.Time      `json:"created_at"`
+	LastUsedAt sql.NullTime   `json:"last_used_at"`
+}
+
+type RefreshToken struct {
+	TokenHash string       `json:"token_hash"`
+	SessionID uuid.UUID    `json:"session_id"`
+	CreatedAt time.Time    `json:"created_at"`
+	RotatedAt sql.NullTime `json:"rotated_at"`
+}
+
 type Role struct {
 	ID          uuid.UUID      `json:"id"`
 	TenantID    uuid.UUID      `json:"tenant_id"`
@@ -185,6 +333,19 @@ type RolePermission struct {
 	CreatedAt    time.Time `json:"created_at"`
 }
 
+type Session struct {
+	ID            uuid.UUID      `json:"id"`
+	UserID        uuid.UUID      `json:"user_id"`
+	Device        sql.NullString `json:"device"`
+	IpAddress     sql.NullString `json:"ip_address"`
+	UserAgent     sql.NullString `json:"user_agent"`
+	CreatedAt     time.Time      `json:"created_at"`
+	LastSeenAt    time.Time      `json:"last_seen_at"`
+	ExpiresAt     time.Time      `json:"expires_at"`
+	RevokedAt     sql.NullTime   `json:"revoked_at"`
+	RevokedReason sql.NullString `json:"revoked_reason"`
+}
+
 type Tenant struct {
 	ID                   uuid.UUID             `json:"id"`
 	Slug                 string                `json:"slug"`
@@ -201,6 +362,15 @@ type Tenant struct {
 	UpdatedAt            time.Time             `json:"updated_at"`
 }
 
+type TenantDailyStat struct {
+	TenantID    uuid.UUID `json:"tenant_id"`
+	Day         time.Time `json:"day"`
+	NewMembers  int32     `json:"new_members"`
+	NewPosts    int32     `json:"new_posts"`
+	NewComments int32     `json:"new_comments"`
+	ComputedAt  time.Time `json:"computed_at"`
+}
+
 type TenantMember struct {
 	ID          uuid.UUID      `json:"id"`
 	TenantID    uuid.UUID      `json:"tenant_id"`
@@ -211,6 +381,7 @@ type TenantMember struct {
 	Status      string         `json:"status"`
 	JoinedAt    time.Time      `json:"joined_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
+	Handle      string         `json:"handle"`
 }
 
 type User struct {
@@ -226,24 +397,25 @@ type User struct {
 }
 
 type Video struct {
-	ID              uuid.UUID      `json:"id"`
-	TenantID        uuid.UUID      `json:"tenant_id"`
-	UploaderID      uuid.UUID      `json:"uploader_id"`
-	Title           string         `json:"title"`
-	Description     sql.NullString `json:"description"`
-	ExternalID      sql.NullString `json:"external_id"`
-	Provider        string         `json:"provider"`
-	OriginalUrl     sql.NullString `json:"original_url"`
-	PlaybackUrl     sql.NullString `json:"playback_url"`
-	ThumbnailUrl    sql.NullString `json:"thumbnail_url"`
-	DurationSeconds sql.NullInt32  `json:"duration_seconds"`
-	FileSizeBytes   sql.NullInt64  `json:"file_size_bytes"`
-	Resolution      sql.NullString `json:"resolution"`
-	Status          string         `json:"status"`
-	ErrorMessage    sql.NullString `json:"error_message"`
-	PostID          uuid.NullUUID  `json:"post_id"`
-	CreatedAt       time.Time      `json:"created_at"`
-	UpdatedAt       time.Time      `json:"updated_at"`
+	ID               uuid.UUID      `json:"id"`
+	TenantID         uuid.UUID      `json:"tenant_id"`
+	UploaderID       uuid.UUID      `json:"uploader_id"`
+	Title            string         `json:"title"`
+	Description      sql.NullString `json:"description"`
+	ExternalID       sql.NullString `json:"external_id"`
+	Provider         string         `json:"provider"`
+	OriginalUrl      sql.NullString `json:"original_url"`
+	PlaybackUrl      sql.NullString `json:"playback_url"`
+	ThumbnailUrl     sql.NullString `json:"thumbnail_url"`
+	DurationSeconds  sql.NullInt32  `json:"duration_seconds"`
+	FileSizeBytes    sql.NullInt64  `json:"file_size_bytes"`
+	Resolution       sql.NullString `json:"resolution"`
+	Status           string         `json:"status"`
+	ErrorMessage     sql.NullString `json:"error_message"`
+	PostID           uuid.NullUUID  `json:"post_id"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	LastReconciledAt sql.NullTime   `json:"last_reconciled_at"`
 }
 
 type WebhookEvent struct {
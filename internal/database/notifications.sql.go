@@ -8,11 +8,43 @@ package database
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/sqlc-dev/pqtype"
 )
 
+const addNotificationGroupActor = `-- name: AddNotificationGroupActor :execrows
+INSERT INTO notification_group_actors (notification_id, actor_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+type AddNotificationGroupActorParams struct {
+	NotificationID uuid.UUID `json:"notification_id"`
+	ActorID        uuid.UUID `json:"actor_id"`
+}
+
+// No row means the actor is already counted in the group
+func (q *Queries) AddNotificationGroupActor(ctx context.Context, arg AddNotificationGroupActorParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, addNotificationGroupActor, arg.NotificationID, arg.ActorID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countNotificationGroupActors = `-- name: CountNotificationGroupActors :one
+SELECT COUNT(*) FROM notification_group_actors WHERE notification_id = $1
+`
+
+func (q *Queries) CountNotificationGroupActors(ctx context.Context, notificationID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countNotificationGroupActors, notificationID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countUnreadNotifications = `-- name: CountUnreadNotifications :one
 SELECT COUNT(*) FROM notifications
 WHERE tenant_id = $1 AND user_id = $2 AND read_at IS NULL
@@ -31,9 +63,9 @@ func (q *Queries) CountUnreadNotifications(ctx context.Context, arg CountUnreadN
 }
 
 const createNotification = `-- name: CreateNotification :one
-INSERT INTO notifications (tenant_id, user_id, type, title, message, data)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, tenant_id, user_id, type, title, message, data, read_at, created_at
+INSERT INTO notifications (tenant_id, user_id, type, title, message, data, group_key)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, tenant_id, user_id, type, title, message, data, read_at, created_at, group_key, actor_count
 `
 
 type CreateNotificationParams struct {
@@ -43,6 +75,7 @@ type CreateNotificationParams struct {
 	Title    string                `json:"title"`
 	Message  sql.NullString        `json:"message"`
 	Data     pqtype.NullRawMessage `json:"data"`
+	GroupKey sql.NullString        `json:"group_key"`
 }
 
 func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotificationParams) (Notification, error) {
@@ -53,6 +86,7 @@ func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotification
 		arg.Title,
 		arg.Message,
 		arg.Data,
+		arg.GroupKey,
 	)
 	var i Notification
 	err := row.Scan(
@@ -65,6 +99,8 @@ func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotification
 		&i.Data,
 		&i.ReadAt,
 		&i.CreatedAt,
+		&i.GroupKey,
+		&i.ActorCount,
 	)
 	return i, err
 }
@@ -83,18 +119,21 @@ func (q *Queries) DeleteNotification(ctx context.Context, arg DeleteNotification
 	return err
 }
 
-const deleteOldNotifications = `-- name: DeleteOldNotifications :exec
+const deleteOldNotifications = `-- name: DeleteOldNotifications :execrows
 DELETE FROM notifications
-WHERE created_at < NOW() - INTERVAL '30 days' AND read_at IS NOT NULL
+WHERE created_at < $1 AND read_at IS NOT NULL
 `
 
-func (q *Queries) DeleteOldNotifications(ctx context.Context) error {
-	_, err := q.db.ExecContext(ctx, deleteOldNotifications)
-	return err
+func (q *Queries) DeleteOldNotifications(ctx context.Context, createdAt time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOldNotifications, createdAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const getNotification = `-- name: GetNotification :one
-SELECT id, tenant_id, user_id, type, title, message, data, read_at, created_at FROM notifications WHERE id = $1
+SELECT id, tenant_id, user_id, type, title, message, data, read_at, created_at, group_key, actor_count FROM notifications WHERE id = $1
 `
 
 func (q *Queries) GetNotification(ctx context.Context, id uuid.UUID) (Notification, error) {
@@ -110,12 +149,47 @@ func (q *Queries) GetNotification(ctx context.Context, id uuid.UUID) (Notificati
 		&i.Data,
 		&i.ReadAt,
 		&i.CreatedAt,
+		&i.GroupKey,
+		&i.ActorCount,
+	)
+	return i, err
+}
+
+const getOpenNotificationGroup = `-- name: GetOpenNotificationGroup :one
+SELECT id, tenant_id, user_id, type, title, message, data, read_at, created_at, group_key, actor_count FROM notifications
+WHERE user_id = $1 AND group_key = $2 AND read_at IS NULL AND created_at > $3
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+type GetOpenNotificationGroupParams struct {
+	UserID    uuid.UUID      `json:"user_id"`
+	GroupKey  sql.NullString `json:"group_key"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// The newest unread notification of the group still inside the window
+func (q *Queries) GetOpenNotificationGroup(ctx context.Context, arg GetOpenNotificationGroupParams) (Notification, error) {
+	row := q.db.QueryRowContext(ctx, getOpenNotificationGroup, arg.UserID, arg.GroupKey, arg.CreatedAt)
+	var i Notification
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.UserID,
+		&i.Type,
+		&i.Title,
+		&i.Message,
+		&i.Data,
+		&i.ReadAt,
+		&i.CreatedAt,
+		&i.GroupKey,
+		&i.ActorCount,
 	)
 	return i, err
 }
 
 const listNotificationsByUser = `-- name: ListNotificationsByUser :many
-SELECT id, tenant_id, user_id, type, title, message, data, read_at, created_at FROM notifications
+SELECT id, tenant_id, user_id, type, title, message, data, read_at, created_at, group_key, actor_count FROM notifications
 WHERE tenant_id = $1 AND user_id = $2
 ORDER BY created_at DESC
 LIMIT $3 OFFSET $4
@@ -152,6 +226,8 @@ func (q *Queries) ListNotificationsByUser(ctx context.Context, arg ListNotificat
 			&i.Data,
 			&i.ReadAt,
 			&i.CreatedAt,
+			&i.GroupKey,
+			&i.ActorCount,
 		); err != nil {
 			return nil, err
 		}
@@ -167,7 +243,7 @@ func (q *Queries) ListNotificationsByUser(ctx context.Context, arg ListNotificat
 }
 
 const listUnreadNotifications = `-- name: ListUnreadNotifications :many
-SELECT id, tenant_id, user_id, type, title, message, data, read_at, created_at FROM notifications
+SELECT id, tenant_id, user_id, type, title, message, data, read_at, created_at, group_key, actor_count FROM notifications
 WHERE tenant_id = $1 AND user_id = $2 AND read_at IS NULL
 ORDER BY created_at DESC
 `
@@ -196,6 +272,8 @@ func (q *Queries) ListUnreadNotifications(ctx context.Context, arg ListUnreadNot
 			&i.Data,
 			&i.ReadAt,
 			&i.CreatedAt,
+			&i.GroupKey,
+			&i.ActorCount,
 		); err != nil {
 			return nil, err
 		}
@@ -210,6 +288,16 @@ func (q *Queries) ListUnreadNotifications(ctx context.Context, arg ListUnreadNot
 	return items, nil
 }
 
+const lockNotificationGroup = `-- name: LockNotificationGroup :exec
+SELECT pg_advisory_xact_lock(hashtextextended($1::text, 0))
+`
+
+// Serializes grouping of one recipient's group until the transaction ends
+func (q *Queries) LockNotificationGroup(ctx context.Context, lockKey string) error {
+	_, err := q.db.ExecContext(ctx, lockNotificationGroup, lockKey)
+	return err
+}
+
 const markAllNotificationsRead = `-- name: MarkAllNotificationsRead :exec
 UPDATE notifications
 SET read_at = NOW()
@@ -241,3 +329,44 @@ func (q *Queries) MarkNotificationRead(ctx context.Context, arg MarkNotification
 	_, err := q.db.ExecContext(ctx, markNotificationRead, arg.ID, arg.UserID)
 	return err
 }
+
+const updateNotificationGroup = `-- name: UpdateNotificationGroup :one
+UPDATE notifications
+SET title = $2, message = $3, data = $4, actor_count = $5, created_at = NOW()
+WHERE id = $1
+RETURNING id, tenant_id, user_id, type, title, message, data, read_at, created_at, group_key, actor_count
+`
+
+type UpdateNotificationGroupParams struct {
+	ID         uuid.UUID             `json:"id"`
+	Title      string                `json:"title"`
+	Message    sql.NullString        `json:"message"`
+	Data       pqtype.NullRawMessage `json:"data"`
+	ActorCount int32                 `json:"actor_count"`
+}
+
+// Folds a new event in; created_at moves so the group shows as recent again
+func (q *Queries) UpdateNotificationGroup(ctx context.Context, arg UpdateNotificationGroupParams) (Notification, error) {
+	row := q.db.QueryRowContext(ctx, updateNotificationGroup,
+		arg.ID,
+		arg.Title,
+		arg.Message,
+		arg.Data,
+		arg.ActorCount,
+	)
+	var i Notification
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.UserID,
+		&i.Type,
+		&i.Title,
+		&i.Message,
+		&i.Data,
+		&i.ReadAt,
+		&i.CreatedAt,
+		&i.GroupKey,
+		&i.ActorCount,
+	)
+	return i, err
+}
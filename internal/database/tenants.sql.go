@@ -295,3 +295,17 @@ func (q *Queries) UpdateTenantSettings(ctx context.Context, arg UpdateTenantSett
 	)
 	return i, err
 }
+
+const updateTenantStatus = `-- name: UpdateTenantStatus :exec
+UPDATE tenants SET status = $2, updated_at = NOW() WHERE id = $1
+`
+
+type UpdateTenantStatusParams struct {
+	ID     uuid.UUID `json:"id"`
+	Status string    `json:"status"`
+}
+
+func (q *Queries) UpdateTenantStatus(ctx context.Context, arg UpdateTenantStatusParams) error {
+	_, err := q.db.ExecContext(ctx, updateTenantStatus, arg.ID, arg.Status)
+	return err
+}
@@ -0,0 +1,31 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// InTx runs fn with queries bound to a transaction that commits when fn
+// returns nil and rolls back otherwise. Queries that already run inside a
+// transaction pass themselves through, so calls can nest.
+func (q *Queries) InTx(ctx context.Context, fn func(*Queries) error) error {
+	db, ok := q.db.(*sql.DB)
+	if !ok {
+		return fn(q)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(q.WithTx(tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
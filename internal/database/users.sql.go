@@ -142,6 +142,20 @@ func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPassword
 	return err
 }
 
+const updateUserStatus = `-- name: UpdateUserStatus :exec
+UPDATE users SET status = $2, updated_at = NOW() WHERE id = $1
+`
+
+type UpdateUserStatusParams struct {
+	ID     uuid.UUID `json:"id"`
+	Status string    `json:"status"`
+}
+
+func (q *Queries) UpdateUserStatus(ctx context.Context, arg UpdateUserStatusParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserStatus, arg.ID, arg.Status)
+	return err
+}
+
 const verifyUserEmail = `-- name: VerifyUserEmail :exec
 UPDATE users SET email_verified_at = NOW(), updated_at = NOW() WHERE id = $1
 `
@@ -30,7 +30,7 @@ func (q *Queries) AttachVideoToPost(ctx context.Context, arg AttachVideoToPostPa
 const createVideo = `-- name: CreateVideo :one
 INSERT INTO videos (tenant_id, uploader_id, title, description, provider, status)
 VALUES ($1, $2, $3, $4, $5, 'pending')
-RETURNING id, tenant_id, uploader_id, title, description, external_id, provider, original_url, playback_url, thumbnail_url, duration_seconds, file_size_bytes, resolution, status, error_message, post_id, created_at, updated_at
+RETURNING id, tenant_id, uploader_id, title, description, external_id, provider, original_url, playback_url, thumbnail_url, duration_seconds, file_size_bytes, resolution, status, error_message, post_id, created_at, updated_at, last_reconciled_at
 `
 
 type CreateVideoParams struct {
@@ -69,6 +69,7 @@ func (q *Queries) CreateVideo(ctx context.Context, arg CreateVideoParams) (Video
 		&i.PostID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.LastReconciledAt,
 	)
 	return i, err
 }
@@ -82,8 +83,22 @@ func (q *Queries) DeleteVideo(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const expireStaleVideos = `-- name: ExpireStaleVideos :execrows
+UPDATE videos
+SET status = 'failed', error_message = 'upload was not completed', updated_at = NOW()
+WHERE status IN ('pending', 'uploading') AND updated_at < $1
+`
+
+func (q *Queries) ExpireStaleVideos(ctx context.Context, updatedAt time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, expireStaleVideos, updatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getVideoByExternalID = `-- name: GetVideoByExternalID :one
-SELECT id, tenant_id, uploader_id, title, description, external_id, provider, original_url, playback_url, thumbnail_url, duration_seconds, file_size_bytes, resolution, status, error_message, post_id, created_at, updated_at FROM videos WHERE provider = $1 AND external_id = $2
+SELECT id, tenant_id, uploader_id, title, description, external_id, provider, original_url, playback_url, thumbnail_url, duration_seconds, file_size_bytes, resolution, status, error_message, post_id, created_at, updated_at, last_reconciled_at FROM videos WHERE provider = $1 AND external_id = $2
 `
 
 type GetVideoByExternalIDParams struct {
@@ -113,12 +128,13 @@ func (q *Queries) GetVideoByExternalID(ctx context.Context, arg GetVideoByExtern
 		&i.PostID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.LastReconciledAt,
 	)
 	return i, err
 }
 
 const getVideoByID = `-- name: GetVideoByID :one
-SELECT id, tenant_id, uploader_id, title, description, external_id, provider, original_url, playback_url, thumbnail_url, duration_seconds, file_size_bytes, resolution, status, error_message, post_id, created_at, updated_at FROM videos WHERE id = $1
+SELECT id, tenant_id, uploader_id, title, description, external_id, provider, original_url, playback_url, thumbnail_url, duration_seconds, file_size_bytes, resolution, status, error_message, post_id, created_at, updated_at, last_reconciled_at FROM videos WHERE id = $1
 `
 
 func (q *Queries) GetVideoByID(ctx context.Context, id uuid.UUID) (Video, error) {
@@ -143,12 +159,50 @@ func (q *Queries) GetVideoByID(ctx context.Context, id uuid.UUID) (Video, error)
 		&i.PostID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.LastReconciledAt,
 	)
 	return i, err
 }
 
+const listStuckVideos = `-- name: ListStuckVideos :many
+SELECT id FROM videos
+WHERE status IN ('uploading', 'processing') AND external_id IS NOT NULL AND updated_at < $1
+AND (last_reconciled_at IS NULL OR last_reconciled_at < $1)
+ORDER BY last_reconciled_at NULLS FIRST, updated_at
+LIMIT $2
+`
+
+type ListStuckVideosParams struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	Limit     int32     `json:"limit"`
+}
+
+// Least recently polled first, so videos that stay stuck do not starve newer ones
+func (q *Queries) ListStuckVideos(ctx context.Context, arg ListStuckVideosParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, listStuckVideos, arg.UpdatedAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listVideosByPost = `-- name: ListVideosByPost :many
-SELECT id, tenant_id, uploader_id, title, description, external_id, provider, original_url, playback_url, thumbnail_url, duration_seconds, file_size_bytes, resolution, status, error_message, post_id, created_at, updated_at FROM videos WHERE post_id = $1 AND status = 'ready'
+SELECT id, tenant_id, uploader_id, title, description, external_id, provider, original_url, playback_url, thumbnail_url, duration_seconds, file_size_bytes, resolution, status, error_message, post_id, created_at, updated_at, last_reconciled_at FROM videos WHERE post_id = $1 AND status = 'ready'
 `
 
 func (q *Queries) ListVideosByPost(ctx context.Context, postID uuid.NullUUID) ([]Video, error) {
@@ -179,6 +233,7 @@ func (q *Queries) ListVideosByPost(ctx context.Context, postID uuid.NullUUID) ([
 			&i.PostID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.LastReconciledAt,
 		); err != nil {
 			return nil, err
 		}
@@ -275,6 +330,55 @@ func (q *Queries) ListVideosByTenant(ctx context.Context, arg ListVideosByTenant
 	return items, nil
 }
 
+const markVideoReconciled = `-- name: MarkVideoReconciled :exec
+UPDATE videos SET last_reconciled_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) MarkVideoReconciled(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, markVideoReconciled, id)
+	return err
+}
+
+const setVideoExternalID = `-- name: SetVideoExternalID :one
+UPDATE videos
+SET external_id = $2, status = $3, updated_at = NOW()
+WHERE id = $1
+RETURNING id, tenant_id, uploader_id, title, description, external_id, provider, original_url, playback_url, thumbnail_url, duration_seconds, file_size_bytes, resolution, status, error_message, post_id, created_at, updated_at, last_reconciled_at
+`
+
+type SetVideoExternalIDParams struct {
+	ID         uuid.UUID      `json:"id"`
+	ExternalID sql.NullString `json:"external_id"`
+	Status     string         `json:"status"`
+}
+
+func (q *Queries) SetVideoExternalID(ctx context.Context, arg SetVideoExternalIDParams) (Video, error) {
+	row := q.db.QueryRowContext(ctx, setVideoExternalID, arg.ID, arg.ExternalID, arg.Status)
+	var i Video
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.UploaderID,
+		&i.Title,
+		&i.Description,
+		&i.ExternalID,
+		&i.Provider,
+		&i.OriginalUrl,
+		&i.PlaybackUrl,
+		&i.ThumbnailUrl,
+		&i.DurationSeconds,
+		&i.FileSizeBytes,
+		&i.Resolution,
+		&i.Status,
+		&i.ErrorMessage,
+		&i.PostID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LastReconciledAt,
+	)
+	return i, err
+}
+
 const updateVideoAfterProcessing = `-- name: UpdateVideoAfterProcessing :one
 UPDATE videos
 SET
@@ -286,7 +390,7 @@ SET
     status = 'ready',
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, tenant_id, uploader_id, title, description, external_id, provider, original_url, playback_url, thumbnail_url, duration_seconds, file_size_bytes, resolution, status, error_message, post_id, created_at, updated_at
+RETURNING id, tenant_id, uploader_id, title, description, external_id, provider, original_url, playback_url, thumbnail_url, duration_seconds, file_size_bytes, resolution, status, error_message, post_id, created_at, updated_at, last_reconciled_at
 `
 
 type UpdateVideoAfterProcessingParams struct {
@@ -327,6 +431,7 @@ func (q *Queries) UpdateVideoAfterProcessing(ctx context.Context, arg UpdateVide
 		&i.PostID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.LastReconciledAt,
 	)
 	return i, err
 }
@@ -335,7 +440,7 @@ const updateVideoStatus = `-- name: UpdateVideoStatus :one
 UPDATE videos
 SET status = $2, error_message = $3, updated_at = NOW()
 WHERE id = $1
-RETURNING id, tenant_id, uploader_id, title, description, external_id, provider, original_url, playback_url, thumbnail_url, duration_seconds, file_size_bytes, resolution, status, error_message, post_id, created_at, updated_at
+RETURNING id, tenant_id, uploader_id, title, description, external_id, provider, original_url, playback_url, thumbnail_url, duration_seconds, file_size_bytes, resolution, status, error_message, post_id, created_at, updated_at, last_reconciled_at
 `
 
 type UpdateVideoStatusParams struct {
@@ -366,6 +471,7 @@ func (q *Queries) UpdateVideoStatus(ctx context.Context, arg UpdateVideoStatusPa
 		&i.PostID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.LastReconciledAt,
 	)
 	return i, err
 }
@@ -9,6 +9,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -48,6 +49,18 @@ func (q *Queries) CreateWebhookEvent(ctx context.Context, arg CreateWebhookEvent
 	return i, err
 }
 
+const deleteWebhookEventsBefore = `-- name: DeleteWebhookEventsBefore :execrows
+DELETE FROM webhook_events WHERE created_at < $1
+`
+
+func (q *Queries) DeleteWebhookEventsBefore(ctx context.Context, createdAt time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteWebhookEventsBefore, createdAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getWebhookEventByKey = `-- name: GetWebhookEventByKey :one
 SELECT id, provider, event_type, payload, status, processed_at, error_message, idempotency_key, created_at FROM webhook_events WHERE provider = $1 AND idempotency_key = $2
 `
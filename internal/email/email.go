@@ -0,0 +1,120 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Message is a rendered email ready to be handed to a Transport
+type Message struct {
+	From    string // "Name <address>"
+	To      string
+	ToName  string
+	Subject string
+	HTML    string
+	Text    string
+	Headers map[string]string // Extra headers, e.g. List-Unsubscribe
+}
+
+// Transport delivers messages. Implementations must be safe for concurrent use.
+type Transport interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// IsPermanent reports whether a delivery error will not go away on retry,
+// such as a rejected recipient
+func IsPermanent(err error) bool {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code >= 500
+	}
+	return false
+}
+
+// Bytes encodes the message as a multipart/alternative MIME document
+func (m Message) Bytes() ([]byte, error) {
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address: %w", err)
+	}
+	to := mail.Address{Name: m.ToName, Address: m.To}
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	headers := map[string]string{
+		"From":         from.String(),
+		"To":           to.String(),
+		"Subject":      mime.QEncoding.Encode("utf-8", m.Subject),
+		"Date":         time.Now().Format(time.RFC1123Z),
+		"Message-ID":   messageID(from.Address),
+		"MIME-Version": "1.0",
+		"Content-Type": "multipart/alternative; boundary=" + writer.Boundary(),
+	}
+	for key, value := range m.Headers {
+		headers[key] = value
+	}
+
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var out bytes.Buffer
+	for _, key := range keys {
+		fmt.Fprintf(&out, "%s: %s\r\n", key, headers[key])
+	}
+	out.WriteString("\r\n")
+
+	if err := writePart(writer, "text/plain; charset=utf-8", m.Text); err != nil {
+		return nil, err
+	}
+	if err := writePart(writer, "text/html; charset=utf-8", m.HTML); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	out.Write(buf.Bytes())
+	return out.Bytes(), nil
+}
+
+func writePart(writer *multipart.Writer, contentType, body string) error {
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+func messageID(address string) string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	domain := "localhost"
+	if at := strings.LastIndexByte(address, '@'); at >= 0 {
+		domain = address[at+1:]
+	}
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
@@ -0,0 +1,245 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpCatcher is a minimal SMTP server that records delivered messages
+type smtpCatcher struct {
+	listener net.Listener
+	reject   string // Recipient answered with 550
+	messages chan string
+}
+
+func newSMTPCatcher(t *testing.T) *smtpCatcher {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	c := &smtpCatcher{listener: listener, messages: make(chan string, 4)}
+	t.Cleanup(func() { listener.Close() })
+	go c.serve()
+	return c
+}
+
+func (c *smtpCatcher) config() SMTPConfig {
+	addr := c.listener.Addr().(*net.TCPAddr)
+	return SMTPConfig{Host: "127.0.0.1", Port: addr.Port}
+}
+
+func (c *smtpCatcher) serve() {
+	for {
+		conn, err := c.listener.Accept()
+		if err != nil {
+			return
+		}
+		go c.session(conn)
+	}
+}
+
+func (c *smtpCatcher) session(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 catcher ready")
+
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			tp.PrintfLine("250 catcher")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			if c.reject != "" && strings.Contains(line, c.reject) {
+				tp.PrintfLine("550 no such user")
+				continue
+			}
+			tp.PrintfLine("250 ok")
+		case cmd == "DATA":
+			tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			c.messages <- string(data)
+			tp.PrintfLine("250 queued")
+		case cmd == "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("250 ok")
+		}
+	}
+}
+
+func testMessage(to string) Message {
+	return Message{
+		From:    "Acme <no-reply@orbit.app.br>",
+		To:      to,
+		ToName:  "Ana",
+		Subject: "Olá, Ana",
+		HTML:    "<p>Oi</p>",
+		Text:    "Oi",
+		Headers: map[string]string{"List-Unsubscribe": "<https://orbit.app.br/u/1>"},
+	}
+}
+
+func TestSMTPTransportDelivers(t *testing.T) {
+	catcher := newSMTPCatcher(t)
+	transport := NewSMTPTransport(catcher.config())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := transport.Send(ctx, testMessage("ana@example.com")); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	select {
+	case raw := <-catcher.messages:
+		for _, want := range []string{
+			"To: \"Ana\" <ana@example.com>",
+			"From: \"Acme\" <no-reply@orbit.app.br>",
+			"Subject: =?utf-8?q?Ol=C3=A1,_Ana?=",
+			"List-Unsubscribe: <https://orbit.app.br/u/1>",
+			"multipart/alternative",
+			"text/plain; charset=utf-8",
+			"text/html; charset=utf-8",
+		} {
+			if !strings.Contains(raw, want) {
+				t.Fatalf("message missing %q:\n%s", want, raw)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("catcher received no message")
+	}
+}
+
+func TestSMTPTransportRejectedRecipientIsPermanent(t *testing.T) {
+	catcher := newSMTPCatcher(t)
+	catcher.reject = "gone@example.com"
+	transport := NewSMTPTransport(catcher.config())
+
+	err := transport.Send(context.Background(), testMessage("gone@example.com"))
+	if err == nil || !IsPermanent(err) {
+		t.Fatalf("expected permanent error, got %v", err)
+	}
+}
+
+func TestFileTransportWritesEML(t *testing.T) {
+	dir := t.TempDir()
+	transport, err := NewFileTransport(dir)
+	if err != nil {
+		t.Fatalf("new file transport: %v", err)
+	}
+	if err := transport.Send(context.Background(), testMessage("ana@example.com")); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 || !strings.HasSuffix(entries[0].Name(), ".eml") {
+		t.Fatalf("expected one .eml file, got %v (%v)", entries, err)
+	}
+
+	raw, err := os.ReadFile(dir + "/" + entries[0].Name())
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if _, err := textproto.NewReader(bufio.NewReader(strings.NewReader(string(raw)))).ReadMIMEHeader(); err != nil {
+		t.Fatalf("invalid headers: %v", err)
+	}
+}
+
+func TestTemplatesRenderBranding(t *testing.T) {
+	templates, err := LoadTemplates()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	brand := Branding{
+		Name:         "Acme",
+		LogoURL:      "https://cdn.example.com/logo.png",
+		URL:          "https://acme.orbit.app.br",
+		PrimaryColor: "#ff0055",
+		AccentColor:  "#00ff55",
+	}
+	rendered, err := templates.Render("invitation", brand, map[string]interface{}{
+		"inviter_name": "Bruno",
+		"url":          "https://acme.orbit.app.br/join?code=" + strconv.Itoa(42),
+	})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+
+	if rendered.Subject != "Bruno convidou você para Acme" {
+		t.Fatalf("unexpected subject %q", rendered.Subject)
+	}
+	for _, want := range []string{"#ff0055", "https://cdn.example.com/logo.png", "join?code=42"} {
+		if !strings.Contains(rendered.HTML, want) {
+			t.Fatalf("html missing %q", want)
+		}
+	}
+	if !strings.Contains(rendered.Text, "https://acme.orbit.app.br/join?code=42") {
+		t.Fatalf("text missing link:\n%s", rendered.Text)
+	}
+
+	if _, err := templates.Render("invitation", brand, nil); !errors.Is(err, ErrRenderTemplate) {
+		t.Fatalf("expected missing data to fail, got %v", err)
+	}
+	if _, err := templates.Render("nope", brand, nil); !errors.Is(err, ErrUnknownTemplate) {
+		t.Fatalf("expected unknown template, got %v", err)
+	}
+}
+
+func TestDigestTemplateRendersQueuedData(t *testing.T) {
+	templates, err := LoadTemplates()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	// Digest data reaches the renderer through the JSON task payload
+	var data map[string]interface{}
+	err = json.Unmarshal([]byte(`{
+		"title": "Seu resumo semanal",
+		"name": "Ana",
+		"unread_count": 7,
+		"notifications": [{"title": "Nova curtida", "message": "Bruno e mais 2 pessoas curtiram seu post"}],
+		"posts": [{"title": "Boas-vindas", "author": "Carla", "likes": 12, "comments": 3, "url": "https://acme.orbit.app.br/posts/boas-vindas"}],
+		"courses": [],
+		"url": "https://acme.orbit.app.br",
+		"notifications_url": "https://acme.orbit.app.br/notifications",
+		"unsubscribe_url": "https://api.orbit.app.br/api/v1/notifications/unsubscribe?token=abc"
+	}`), &data)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	rendered, err := templates.Render("digest", Branding{Name: "Acme", PrimaryColor: "#ff0055"}, data)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+
+	if rendered.Subject != "Seu resumo semanal em Acme" {
+		t.Fatalf("unexpected subject %q", rendered.Subject)
+	}
+	for _, want := range []string{"7 notificações não lidas", "Bruno e mais 2 pessoas", "12 curtidas", "posts/boas-vindas", "token=abc"} {
+		if !strings.Contains(rendered.HTML, want) {
+			t.Fatalf("html missing %q", want)
+		}
+	}
+	if strings.Contains(rendered.Text, "Continue aprendendo") {
+		t.Fatalf("text lists an empty section:\n%s", rendered.Text)
+	}
+}
@@ -0,0 +1,45 @@
+package email
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileTransport writes each message as an .eml file, for development and tests
+type FileTransport struct {
+	dir string
+}
+
+func NewFileTransport(dir string) (*FileTransport, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create email outbox dir: %w", err)
+	}
+	return &FileTransport{dir: dir}, nil
+}
+
+func (t *FileTransport) Send(ctx context.Context, msg Message) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), hex.EncodeToString(suffix))
+
+	return os.WriteFile(filepath.Join(t.dir, name), data, 0o644)
+}
+
+// LogTransport only logs the envelope; used when no transport is configured
+type LogTransport struct{}
+
+func (LogTransport) Send(ctx context.Context, msg Message) error {
+	log.Printf("[EMAIL] To=%s Subject=%q (not delivered, no transport configured)", msg.To, msg.Subject)
+	return nil
+}
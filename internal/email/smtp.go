@@ -0,0 +1,100 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPConfig configures delivery through an SMTP relay
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
+// SMTPTransport sends mail through an SMTP server. Port 465 uses implicit
+// TLS; other ports upgrade with STARTTLS when the server offers it, so a local
+// catcher such as Mailpit works without certificates.
+type SMTPTransport struct {
+	cfg         SMTPConfig
+	dialTimeout time.Duration
+}
+
+func NewSMTPTransport(cfg SMTPConfig) *SMTPTransport {
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	return &SMTPTransport{cfg: cfg, dialTimeout: 10 * time.Second}
+}
+
+func (t *SMTPTransport) Send(ctx context.Context, msg Message) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return fmt.Errorf("invalid from address: %w", err)
+	}
+
+	conn, err := t.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, t.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && t.cfg.Port != 465 {
+		if err := client.StartTLS(&tls.Config{ServerName: t.cfg.Host}); err != nil {
+			return fmt.Errorf("starttls failed: %w", err)
+		}
+	}
+	if t.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", t.cfg.Username, t.cfg.Password, t.cfg.Host)); err != nil {
+			return fmt.Errorf("smtp auth failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func (t *SMTPTransport) dial(ctx context.Context) (net.Conn, error) {
+	addr := net.JoinHostPort(t.cfg.Host, strconv.Itoa(t.cfg.Port))
+	dialer := &net.Dialer{Timeout: t.dialTimeout}
+	if t.cfg.Port == 465 {
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: t.cfg.Host}}
+		return tlsDialer.DialContext(ctx, "tcp", addr)
+	}
+	return dialer.DialContext(ctx, "tcp", addr)
+}
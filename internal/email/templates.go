@@ -0,0 +1,138 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates/*
+var templateFS embed.FS
+
+var (
+	ErrUnknownTemplate = errors.New("unknown email template")
+	ErrRenderTemplate  = errors.New("failed to render email template")
+)
+
+// Branding is the look of the community an email is sent on behalf of
+type Branding struct {
+	Name         string
+	LogoURL      string
+	URL          string
+	PrimaryColor string
+	AccentColor  string
+}
+
+// Rendered holds the parts of a rendered template
+type Rendered struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// templateData is what every template receives
+type templateData struct {
+	Brand Branding
+	Data  map[string]interface{}
+}
+
+// Templates renders the embedded HTML and text templates. Each email is a
+// pair of <name>.html and <name>.txt files wrapped in the shared layouts; the
+// text file also defines the "subject".
+type Templates struct {
+	html map[string]*htmltemplate.Template
+	text map[string]*texttemplate.Template
+}
+
+func LoadTemplates() (*Templates, error) {
+	htmlLayout, err := templateFS.ReadFile("templates/layout.html")
+	if err != nil {
+		return nil, err
+	}
+	textLayout, err := templateFS.ReadFile("templates/layout.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := templateFS.ReadDir("templates")
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Templates{
+		html: make(map[string]*htmltemplate.Template),
+		text: make(map[string]*texttemplate.Template),
+	}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".html")
+		if name == entry.Name() || name == "layout" {
+			continue
+		}
+
+		htmlBody, err := templateFS.ReadFile(path.Join("templates", name+".html"))
+		if err != nil {
+			return nil, err
+		}
+		textBody, err := templateFS.ReadFile(path.Join("templates", name+".txt"))
+		if err != nil {
+			return nil, fmt.Errorf("template %s has no text version: %w", name, err)
+		}
+
+		ht, err := htmltemplate.New("layout").Option("missingkey=error").Parse(string(htmlLayout))
+		if err == nil {
+			_, err = ht.Parse(string(htmlBody))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s.html: %w", name, err)
+		}
+
+		tt, err := texttemplate.New("layout").Option("missingkey=error").Parse(string(textLayout))
+		if err == nil {
+			_, err = tt.Parse(string(textBody))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s.txt: %w", name, err)
+		}
+
+		t.html[name] = ht
+		t.text[name] = tt
+	}
+
+	return t, nil
+}
+
+// Render executes the named template with the tenant branding
+func (t *Templates) Render(name string, brand Branding, data map[string]interface{}) (Rendered, error) {
+	ht, ok := t.html[name]
+	if !ok {
+		return Rendered{}, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+	tt := t.text[name]
+
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	input := templateData{Brand: brand, Data: data}
+
+	var subject, text, html bytes.Buffer
+	if err := tt.ExecuteTemplate(&subject, "subject", input); err != nil {
+		return Rendered{}, fmt.Errorf("%w %s subject: %v", ErrRenderTemplate, name, err)
+	}
+	if err := tt.ExecuteTemplate(&text, "layout", input); err != nil {
+		return Rendered{}, fmt.Errorf("%w %s.txt: %v", ErrRenderTemplate, name, err)
+	}
+	if err := ht.ExecuteTemplate(&html, "layout", input); err != nil {
+		return Rendered{}, fmt.Errorf("%w %s.html: %v", ErrRenderTemplate, name, err)
+	}
+
+	return Rendered{
+		Subject: strings.TrimSpace(subject.String()),
+		HTML:    html.String(),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+	}, nil
+}
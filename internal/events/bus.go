@@ -0,0 +1,111 @@
+package events
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Type identifies a domain event
+type Type string
+
+const (
+	// Posts
+	PostCreated Type = "post.created"
+	PostUpdated Type = "post.updated"
+	PostDeleted Type = "post.deleted"
+
+	// Comments
+	CommentCreated Type = "comment.created"
+	CommentDeleted Type = "comment.deleted"
+
+	// Likes
+	LikeUpdated Type = "like.updated"
+
+	// Notifications
+	NotificationCreated Type = "notification.created"
+	NotificationUpdated Type = "notification.updated" // A grouped notification absorbed another event
+
+	// Access changes
+	MemberStatusChanged Type = "member.status_changed"
+	MemberRoleChanged   Type = "member.role_changed"
+	MemberRemoved       Type = "member.removed"
+	TenantStatusChanged Type = "tenant.status_changed"
+	UserStatusChanged   Type = "user.status_changed" // TenantID is unset: applies to every tenant
+
+	// Lesson chat
+	LessonChatCreated Type = "lesson_chat.created"
+	LessonChatUpdated Type = "lesson_chat.updated"
+	LessonChatDeleted Type = "lesson_chat.deleted"
+
+	// Direct messages (sent to each participant)
+	DirectMessageCreated Type = "direct_message.created"
+	ConversationRead     Type = "conversation.read"
+)
+
+// Event is a domain change raised by a service after a successful write
+type Event struct {
+	Type     Type
+	TenantID uuid.UUID
+
+	// UserID targets a single user (all their connections). Nil means tenant-wide.
+	UserID *uuid.UUID
+
+	Payload    any
+	OccurredAt time.Time
+}
+
+// Handler receives published events. Handlers run synchronously on the
+// publisher's goroutine, so they must not block.
+type Handler func(ctx context.Context, event Event)
+
+// Bus is an in-process publish/subscribe dispatcher for domain events
+type Bus struct {
+	handlers []Handler
+	mu       sync.RWMutex
+}
+
+// NewBus creates a new event bus
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers a handler for all events
+func (b *Bus) Subscribe(handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish dispatches an event to every subscriber. A nil bus is a no-op so
+// services can be constructed without realtime support.
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	if b == nil {
+		return
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	b.mu.RLock()
+	handlers := make([]Handler, len(b.handlers))
+	copy(handlers, b.handlers)
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		b.dispatch(ctx, handler, event)
+	}
+}
+
+// dispatch runs a single handler, isolating the publisher from handler panics
+func (b *Bus) dispatch(ctx context.Context, handler Handler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[EVENTS] Handler panic for %s: %v", event.Type, r)
+		}
+	}()
+	handler(ctx, event)
+}
@@ -0,0 +1,121 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PostPayload accompanies post.created and post.updated events
+type PostPayload struct {
+	ID         uuid.UUID
+	Title      string
+	AuthorID   uuid.UUID
+	AuthorName string
+	CategoryID *uuid.UUID
+	CreatedAt  time.Time
+}
+
+// PostDeletedPayload accompanies post.deleted events
+type PostDeletedPayload struct {
+	ID uuid.UUID
+}
+
+// CommentPayload accompanies comment.created events
+type CommentPayload struct {
+	ID         uuid.UUID
+	PostID     uuid.UUID
+	AuthorID   uuid.UUID
+	AuthorName string
+	ParentID   *uuid.UUID
+	CreatedAt  time.Time
+}
+
+// CommentDeletedPayload accompanies comment.deleted events
+type CommentDeletedPayload struct {
+	ID     uuid.UUID
+	PostID uuid.UUID
+}
+
+// LikePayload accompanies like.updated events
+type LikePayload struct {
+	TargetType string // "post" or "comment"
+	TargetID   uuid.UUID
+	PostID     uuid.UUID // The post itself, or the post the comment belongs to
+	LikeCount  int
+}
+
+// NotificationPayload accompanies notification.created and notification.updated events
+type NotificationPayload struct {
+	ID         uuid.UUID
+	Type       string
+	Title      string
+	Message    string
+	Data       json.RawMessage
+	ActorCount int
+	CreatedAt  time.Time
+}
+
+// LessonChatPayload accompanies lesson_chat.created and lesson_chat.updated events
+type LessonChatPayload struct {
+	ID             uuid.UUID
+	LessonID       uuid.UUID
+	AuthorID       uuid.UUID
+	AuthorName     string
+	Content        string
+	VideoTimestamp *int32
+	CreatedAt      time.Time
+	EditedAt       *time.Time
+}
+
+// LessonChatDeletedPayload accompanies lesson_chat.deleted events
+type LessonChatDeletedPayload struct {
+	ID       uuid.UUID
+	LessonID uuid.UUID
+}
+
+// MemberStatusPayload accompanies member.status_changed events
+type MemberStatusPayload struct {
+	UserID uuid.UUID
+	Status string // "active", "suspended" or "banned"
+}
+
+// MemberRolePayload accompanies member.role_changed events
+type MemberRolePayload struct {
+	UserID uuid.UUID
+	RoleID uuid.UUID
+}
+
+// MemberRemovedPayload accompanies member.removed events
+type MemberRemovedPayload struct {
+	UserID uuid.UUID
+}
+
+// TenantStatusPayload accompanies tenant.status_changed events
+type TenantStatusPayload struct {
+	Status string // "active", "suspended" or "deleted"
+}
+
+// UserStatusPayload accompanies user.status_changed events
+type UserStatusPayload struct {
+	UserID uuid.UUID
+	Status string // "active", "suspended" or "deleted"
+}
+
+// DirectMessagePayload accompanies direct_message.created events
+type DirectMessagePayload struct {
+	ID             uuid.UUID
+	ConversationID uuid.UUID
+	SenderID       uuid.UUID
+	SenderName     string
+	Content        string
+	CreatedAt      time.Time
+}
+
+// ConversationReadPayload accompanies conversation.read events, so the
+// reader's other sessions can clear their unread badge
+type ConversationReadPayload struct {
+	ConversationID uuid.UUID
+	UnreadCount    int64 // Unread messages left across all conversations
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/labstack/echo/v4"
+
+	"github.com/nickkcj/orbit-backend/internal/worker"
+)
+
+// UseInspector enables the background task admin endpoints
+func (h *Handler) UseInspector(inspector *worker.Inspector) {
+	h.inspector = inspector
+}
+
+// ListQueues returns the stats of every task queue
+// Endpoint: GET /admin/queues
+func (h *Handler) ListQueues(c echo.Context) error {
+	queues, err := h.inspector.Queues()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to inspect queues"})
+	}
+
+	return c.JSON(http.StatusOK, queues)
+}
+
+// ListTasks returns pending, retrying and archived tasks with their last errors
+// Endpoint: GET /admin/tasks?queue=default&state=archived&type=notification:send&tenant_id=<id>&page=1&page_size=20
+func (h *Handler) ListTasks(c echo.Context) error {
+	filter := worker.TaskFilter{
+		Queue: c.QueryParam("queue"),
+		State: c.QueryParam("state"),
+		Type:  c.QueryParam("type"),
+	}
+	if raw := c.QueryParam("tenant_id"); raw != "" {
+		tenantID, err := uuid.Parse(raw)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid tenant_id"})
+		}
+		filter.TenantID = &tenantID
+	}
+
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	if page <= 0 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.QueryParam("page_size"))
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	tasks, err := h.inspector.ListTasks(filter, page, pageSize)
+	if err != nil {
+		return taskAdminError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"tasks":     tasks,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// GetTask returns a single task with its payload
+// Endpoint: GET /admin/queues/:queue/tasks/:id
+func (h *Handler) GetTask(c echo.Context) error {
+	task, err := h.inspector.GetTask(c.Param("queue"), c.Param("id"))
+	if err != nil {
+		return taskAdminError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, task)
+}
+
+// RetryArchivedTask moves an archived task back to pending
+// Endpoint: POST /admin/queues/:queue/archived/:id/retry
+func (h *Handler) RetryArchivedTask(c echo.Context) error {
+	if err := h.inspector.RetryArchived(c.Param("queue"), c.Param("id")); err != nil {
+		return taskAdminError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// DeleteArchivedTask drops an archived task
+// Endpoint: DELETE /admin/queues/:queue/archived/:id
+func (h *Handler) DeleteArchivedTask(c echo.Context) error {
+	if err := h.inspector.DeleteArchived(c.Param("queue"), c.Param("id")); err != nil {
+		return taskAdminError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RetryAllArchivedTasks moves every archived task of a queue back to pending
+// Endpoint: POST /admin/queues/:queue/retry-archived
+func (h *Handler) RetryAllArchivedTasks(c echo.Context) error {
+	count, err := h.inspector.RetryAllArchived(c.Param("queue"))
+	if err != nil {
+		return taskAdminError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]int{"retried": count})
+}
+
+// DeleteAllArchivedTasks drops every archived task of a queue
+// Endpoint: DELETE /admin/queues/:queue/archived
+func (h *Handler) DeleteAllArchivedTasks(c echo.Context) error {
+	count, err := h.inspector.DeleteAllArchived(c.Param("queue"))
+	if err != nil {
+		return taskAdminError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]int{"deleted": count})
+}
+
+// taskAdminError maps inspector errors onto HTTP responses
+func taskAdminError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, asynq.ErrQueueNotFound):
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "queue not found"})
+	case errors.Is(err, asynq.ErrTaskNotFound):
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "task not found"})
+	case errors.Is(err, worker.ErrUnknownTaskState):
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "state must be pending, retry or archived"})
+	case errors.Is(err, worker.ErrTaskNotArchived):
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to inspect tasks"})
+	}
+}
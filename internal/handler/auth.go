@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
@@ -12,11 +13,13 @@ type RegisterRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=8"`
 	Name     string `json:"name" validate:"required"`
+	Device   string `json:"device"` // Optional label shown in the session list
 }
 
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+	Device   string `json:"device"`
 }
 
 func (h *Handler) Register(c echo.Context) error {
@@ -37,7 +40,7 @@ func (h *Handler) Register(c echo.Context) error {
 		Email:    req.Email,
 		Password: req.Password,
 		Name:     req.Name,
-	})
+	}, sessionMeta(c, req.Device))
 	if err != nil {
 		if err == service.ErrUserAlreadyExists {
 			return c.JSON(http.StatusConflict, ErrorResponse{Error: "user already exists"})
@@ -61,7 +64,7 @@ func (h *Handler) Login(c echo.Context) error {
 	result, err := h.services.Auth.Login(c.Request().Context(), service.LoginInput{
 		Email:    req.Email,
 		Password: req.Password,
-	})
+	}, sessionMeta(c, req.Device))
 	if err != nil {
 		if err == service.ErrInvalidCredentials {
 			return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "invalid credentials"})
@@ -148,11 +151,83 @@ func (h *Handler) GoogleCallback(c echo.Context) error {
 	}
 
 	// Login or register user
-	result, err := h.services.Auth.LoginOrRegisterWithGoogle(c.Request().Context(), googleUser)
+	loginCode, err := h.services.Auth.LoginOrRegisterWithGoogle(c.Request().Context(), googleUser, sessionMeta(c, ""))
 	if err != nil {
 		return c.Redirect(http.StatusTemporaryRedirect, frontendURL+"/login?error=auth_failed")
 	}
 
-	// Redirect to frontend with token
-	return c.Redirect(http.StatusTemporaryRedirect, frontendURL+"/login?token="+result.Token)
+	// Redirect to frontend with a one-time code; tokens never go in the URL
+	return c.Redirect(http.StatusTemporaryRedirect, frontendURL+"/login?code="+loginCode)
+}
+
+type ExchangeLoginCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// ExchangeLoginCode trades the one-time code from GoogleCallback for tokens
+// Endpoint: POST /auth/google/exchange
+func (h *Handler) ExchangeLoginCode(c echo.Context) error {
+	var req ExchangeLoginCodeRequest
+	if err := c.Bind(&req); err != nil || req.Code == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "code is required"})
+	}
+
+	result, err := h.services.Auth.ExchangeLoginCode(c.Request().Context(), req.Code)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidLoginCode) {
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to exchange login code"})
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// ============================================================================
+// Email Verification Handlers
+// ============================================================================
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// SendVerificationEmail sends the current user a new verification link
+// Endpoint: POST /auth/verification-email
+func (h *Handler) SendVerificationEmail(c echo.Context) error {
+	user := GetUserFromContext(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "authentication required"})
+	}
+
+	err := h.services.Auth.SendVerificationEmail(c.Request().Context(), user.ID)
+	if err != nil {
+		if errors.Is(err, service.ErrEmailAlreadyVerified) {
+			return c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		}
+		if errors.Is(err, service.ErrVerificationRecentlySent) {
+			return c.JSON(http.StatusTooManyRequests, ErrorResponse{Error: err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to send verification email"})
+	}
+
+	return c.NoContent(http.StatusAccepted)
+}
+
+// VerifyEmail confirms the address with the token from the verification link
+// Endpoint: POST /auth/verify-email
+func (h *Handler) VerifyEmail(c echo.Context) error {
+	var req VerifyEmailRequest
+	if err := c.Bind(&req); err != nil || req.Token == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "token is required"})
+	}
+
+	err := h.services.Auth.VerifyEmail(c.Request().Context(), req.Token)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidVerificationToken) {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to verify email"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
 }
@@ -1,8 +1,6 @@
 package handler
 
 import (
-	"context"
-	"log"
 	"net/http"
 	"strconv"
 
@@ -10,7 +8,6 @@ import (
 	"github.com/labstack/echo/v4"
 
 	"github.com/nickkcj/orbit-backend/internal/service"
-	"github.com/nickkcj/orbit-backend/internal/worker/tasks"
 )
 
 type CreateCommentRequest struct {
@@ -51,11 +48,9 @@ func (h *Handler) CreateComment(c echo.Context) error {
 		Content:  req.Content,
 	}
 
-	var parentID *uuid.UUID
 	if req.ParentID != "" {
 		pid, err := uuid.Parse(req.ParentID)
 		if err == nil {
-			parentID = &pid
 			input.ParentID = &pid
 		}
 	}
@@ -65,58 +60,6 @@ func (h *Handler) CreateComment(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 	}
 
-	// Enqueue notification task asynchronously
-	go func() {
-		ctx := context.Background() // Use fresh context, not request context
-
-		// Get the post to notify the author
-		post, err := h.services.Post.GetByID(ctx, postID)
-		if err != nil {
-			return
-		}
-
-		var payload tasks.NotificationPayload
-
-		if parentID != nil {
-			// This is a reply - notify parent comment author
-			parentComment, err := h.services.Comment.GetByID(ctx, *parentID)
-			if err == nil && parentComment.AuthorID != user.ID {
-				payload = tasks.NotificationPayload{
-					Type:        "reply",
-					TenantID:    tenant.ID,
-					RecipientID: parentComment.AuthorID,
-					PostID:      &postID,
-					PostTitle:   post.Title,
-					CommentID:   &comment.ID,
-					AuthorName:  user.Name,
-				}
-			}
-		} else {
-			// This is a top-level comment - notify post author
-			if post.AuthorID != user.ID {
-				payload = tasks.NotificationPayload{
-					Type:        "comment",
-					TenantID:    tenant.ID,
-					RecipientID: post.AuthorID,
-					PostID:      &postID,
-					PostTitle:   post.Title,
-					CommentID:   &comment.ID,
-					AuthorName:  user.Name,
-				}
-			}
-		}
-
-		// Enqueue the notification task
-		if payload.Type != "" && h.taskClient != nil {
-			task, err := tasks.NewSendNotificationTask(payload)
-			if err == nil {
-				if _, err := h.taskClient.Enqueue(task); err != nil {
-					log.Printf("Failed to enqueue notification task: %v", err)
-				}
-			}
-		}
-	}()
-
 	return c.JSON(http.StatusCreated, comment)
 }
 
@@ -0,0 +1,295 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/nickkcj/orbit-backend/internal/service"
+)
+
+type StartConversationRequest struct {
+	ParticipantIDs []string `json:"participant_ids" validate:"required"`
+	Title          string   `json:"title"`
+}
+
+type SendDirectMessageRequest struct {
+	Content string `json:"content" validate:"required"`
+}
+
+// ListConversations returns the user's conversations with unread counts
+// Endpoint: GET /conversations?limit=20&offset=0
+func (h *Handler) ListConversations(c echo.Context) error {
+	tenant := GetTenantFromContext(c)
+	user := GetUserFromContext(c)
+	if tenant == nil || user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	limit := int32(20)
+	offset := int32(0)
+
+	if l := c.QueryParam("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
+			limit = int32(parsed)
+		}
+	}
+	if o := c.QueryParam("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
+			offset = int32(parsed)
+		}
+	}
+
+	conversations, err := h.services.Conversation.List(c.Request().Context(), tenant.ID, user.ID, limit, offset)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to list conversations"})
+	}
+
+	return c.JSON(http.StatusOK, conversations)
+}
+
+// StartConversation opens a 1:1 or group conversation
+// Endpoint: POST /conversations
+func (h *Handler) StartConversation(c echo.Context) error {
+	tenant := GetTenantFromContext(c)
+	user := GetUserFromContext(c)
+	if tenant == nil || user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	var req StartConversationRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	participantIDs := make([]uuid.UUID, 0, len(req.ParticipantIDs))
+	for _, raw := range req.ParticipantIDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid participant_ids"})
+		}
+		participantIDs = append(participantIDs, id)
+	}
+
+	conversation, err := h.services.Conversation.Start(c.Request().Context(), service.StartConversationInput{
+		TenantID:       tenant.ID,
+		CreatorID:      user.ID,
+		ParticipantIDs: participantIDs,
+		Title:          req.Title,
+	})
+	if err != nil {
+		return conversationError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, conversation)
+}
+
+// GetConversation returns a conversation and its participants
+// Endpoint: GET /conversations/:id
+func (h *Handler) GetConversation(c echo.Context) error {
+	tenant := GetTenantFromContext(c)
+	user := GetUserFromContext(c)
+	if tenant == nil || user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid conversation id"})
+	}
+
+	conversation, err := h.services.Conversation.Get(c.Request().Context(), tenant.ID, user.ID, conversationID)
+	if err != nil {
+		return conversationError(c, err)
+	}
+
+	participants, err := h.services.Conversation.ListParticipants(c.Request().Context(), conversationID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to list participants"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"conversation": conversation,
+		"participants": participants,
+	})
+}
+
+// ListDirectMessages returns conversation messages, newest first
+// Endpoint: GET /conversations/:id/messages?before=<message_id>&limit=50
+func (h *Handler) ListDirectMessages(c echo.Context) error {
+	tenant := GetTenantFromContext(c)
+	user := GetUserFromContext(c)
+	if tenant == nil || user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid conversation id"})
+	}
+
+	var before *uuid.UUID
+	if raw := c.QueryParam("before"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid before cursor"})
+		}
+		before = &id
+	}
+
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	// Fetch one extra row to know whether older messages remain
+	messages, err := h.services.Conversation.ListMessages(c.Request().Context(), tenant.ID, user.ID, conversationID, before, int32(limit+1))
+	if err != nil {
+		if errors.Is(err, service.ErrDirectMessageNotFound) {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid before cursor"})
+		}
+		return conversationError(c, err)
+	}
+
+	hasMore := len(messages) > limit
+	if hasMore {
+		messages = messages[:limit]
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"messages": messages,
+		"has_more": hasMore,
+	})
+}
+
+// SendDirectMessage posts a message to a conversation
+// Endpoint: POST /conversations/:id/messages
+func (h *Handler) SendDirectMessage(c echo.Context) error {
+	tenant := GetTenantFromContext(c)
+	user := GetUserFromContext(c)
+	if tenant == nil || user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid conversation id"})
+	}
+
+	var req SendDirectMessageRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	message, err := h.services.Conversation.Send(c.Request().Context(), tenant.ID, user.ID, conversationID, req.Content)
+	if err != nil {
+		return conversationError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, message)
+}
+
+// MarkConversationRead clears the unread messages of a conversation
+// Endpoint: POST /conversations/:id/read
+func (h *Handler) MarkConversationRead(c echo.Context) error {
+	tenant := GetTenantFromContext(c)
+	user := GetUserFromContext(c)
+	if tenant == nil || user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid conversation id"})
+	}
+
+	if err := h.services.Conversation.MarkRead(c.Request().Context(), tenant.ID, user.ID, conversationID); err != nil {
+		return conversationError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetUnreadMessageCount returns unread direct messages across conversations
+// Endpoint: GET /conversations/unread/count
+func (h *Handler) GetUnreadMessageCount(c echo.Context) error {
+	tenant := GetTenantFromContext(c)
+	user := GetUserFromContext(c)
+	if tenant == nil || user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	count, err := h.services.Conversation.CountUnread(c.Request().Context(), tenant.ID, user.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to count messages"})
+	}
+
+	return c.JSON(http.StatusOK, UnreadCountResponse{Count: count})
+}
+
+// BlockMember stops a member from messaging the current user
+// Endpoint: POST /members/:userId/block
+func (h *Handler) BlockMember(c echo.Context) error {
+	tenant := GetTenantFromContext(c)
+	user := GetUserFromContext(c)
+	if tenant == nil || user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	blockedID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid user_id"})
+	}
+
+	if err := h.services.Conversation.Block(c.Request().Context(), tenant.ID, user.ID, blockedID); err != nil {
+		if errors.Is(err, service.ErrCannotBlockSelf) {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to block member"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// UnblockMember lifts a block set by the current user
+// Endpoint: DELETE /members/:userId/block
+func (h *Handler) UnblockMember(c echo.Context) error {
+	tenant := GetTenantFromContext(c)
+	user := GetUserFromContext(c)
+	if tenant == nil || user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	blockedID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid user_id"})
+	}
+
+	if err := h.services.Conversation.Unblock(c.Request().Context(), tenant.ID, user.ID, blockedID); err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to unblock member"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// conversationError maps conversation service errors onto HTTP responses
+func conversationError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrConversationNotFound):
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrMessagingBlocked),
+		errors.Is(err, service.ErrRecipientUnavailable),
+		errors.Is(err, service.ErrSenderInactive):
+		return c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrNoRecipients),
+		errors.Is(err, service.ErrTooManyParticipants),
+		errors.Is(err, service.ErrChatMessageEmpty),
+		errors.Is(err, service.ErrChatMessageTooLong):
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to process conversation"})
+	}
+}
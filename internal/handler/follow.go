@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/nickkcj/orbit-backend/internal/service"
+)
+
+// FollowCategory subscribes the current user to new posts in a category
+// Endpoint: POST /categories/:id/follow
+func (h *Handler) FollowCategory(c echo.Context) error {
+	tenant := GetTenantFromContext(c)
+	user := GetUserFromContext(c)
+	if tenant == nil || user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	categoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid category_id"})
+	}
+
+	if err := h.services.Follow.FollowCategory(c.Request().Context(), tenant.ID, categoryID, user.ID); err != nil {
+		if errors.Is(err, service.ErrCategoryNotFound) {
+			return c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to follow category"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// UnfollowCategory stops new post notifications for a category
+// Endpoint: DELETE /categories/:id/follow
+func (h *Handler) UnfollowCategory(c echo.Context) error {
+	tenant := GetTenantFromContext(c)
+	user := GetUserFromContext(c)
+	if tenant == nil || user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	categoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid category_id"})
+	}
+
+	if err := h.services.Follow.UnfollowCategory(c.Request().Context(), categoryID, user.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to unfollow category"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListFollowedCategories returns the categories the current user follows
+// Endpoint: GET /follows/categories
+func (h *Handler) ListFollowedCategories(c echo.Context) error {
+	tenant := GetTenantFromContext(c)
+	user := GetUserFromContext(c)
+	if tenant == nil || user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	categories, err := h.services.Follow.ListCategories(c.Request().Context(), tenant.ID, user.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to list followed categories"})
+	}
+
+	return c.JSON(http.StatusOK, categories)
+}
+
+// FollowMember subscribes the current user to new posts by a member
+// Endpoint: POST /members/:userId/follow
+func (h *Handler) FollowMember(c echo.Context) error {
+	tenant := GetTenantFromContext(c)
+	user := GetUserFromContext(c)
+	if tenant == nil || user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	authorID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid user_id"})
+	}
+
+	if err := h.services.Follow.FollowAuthor(c.Request().Context(), tenant.ID, authorID, user.ID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrCannotFollowSelf):
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		case errors.Is(err, service.ErrAuthorNotFound):
+			return c.JSON(http.StatusNotFound, ErrorResponse{Error: "member not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to follow member"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// UnfollowMember stops new post notifications for a member
+// Endpoint: DELETE /members/:userId/follow
+func (h *Handler) UnfollowMember(c echo.Context) error {
+	tenant := GetTenantFromContext(c)
+	user := GetUserFromContext(c)
+	if tenant == nil || user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	authorID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid user_id"})
+	}
+
+	if err := h.services.Follow.UnfollowAuthor(c.Request().Context(), tenant.ID, authorID, user.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to unfollow member"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// FollowedMemberResponse is a member the current user follows
+type FollowedMemberResponse struct {
+	UserID     string    `json:"user_id"`
+	Name       string    `json:"name"`
+	AvatarURL  string    `json:"avatar_url,omitempty"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// ListFollowedMembers returns the members the current user follows
+// Endpoint: GET /follows/members
+func (h *Handler) ListFollowedMembers(c echo.Context) error {
+	tenant := GetTenantFromContext(c)
+	user := GetUserFromContext(c)
+	if tenant == nil || user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	authors, err := h.services.Follow.ListAuthors(c.Request().Context(), tenant.ID, user.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to list followed members"})
+	}
+
+	response := make([]FollowedMemberResponse, len(authors))
+	for i, author := range authors {
+		response[i] = FollowedMemberResponse{
+			UserID:     author.AuthorID.String(),
+			Name:       author.AuthorName,
+			AvatarURL:  author.AuthorAvatar.String,
+			FollowedAt: author.CreatedAt,
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
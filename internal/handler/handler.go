@@ -8,6 +8,7 @@ import (
 type Handler struct {
 	services   *service.Services
 	taskClient *worker.TaskClient
+	inspector  *worker.Inspector // Nil disables the admin task endpoints
 }
 
 func New(services *service.Services, taskClient *worker.TaskClient) *Handler {
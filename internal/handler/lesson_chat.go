@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/nickkcj/orbit-backend/internal/service"
+)
+
+// GetLessonChatHistory returns lesson chat messages, newest first
+// Endpoint: GET /learn/lessons/:id/chat?before=<message_id>&limit=50
+func (h *Handler) GetLessonChatHistory(c echo.Context) error {
+	tenant := GetTenantFromContext(c)
+	user := GetUserFromContext(c)
+
+	if tenant == nil || user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	lessonID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid lesson id"})
+	}
+
+	var before *uuid.UUID
+	if raw := c.QueryParam("before"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid before cursor"})
+		}
+		before = &id
+	}
+
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	// Check access
+	canAccess, err := h.services.LessonChat.CanAccess(c.Request().Context(), tenant.ID, user.ID, lessonID)
+	if err != nil {
+		if errors.Is(err, service.ErrLessonNotFound) {
+			return c.JSON(http.StatusNotFound, ErrorResponse{Error: "lesson not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to check access"})
+	}
+
+	if !canAccess {
+		return c.JSON(http.StatusForbidden, ErrorResponse{Error: "access denied - enrollment required"})
+	}
+
+	// Fetch one extra row to know whether older messages remain
+	messages, err := h.services.LessonChat.ListHistory(c.Request().Context(), tenant.ID, lessonID, before, int32(limit+1))
+	if err != nil {
+		if errors.Is(err, service.ErrChatMessageNotFound) {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid before cursor"})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to list chat messages"})
+	}
+
+	hasMore := len(messages) > limit
+	if hasMore {
+		messages = messages[:limit]
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"messages": messages,
+		"has_more": hasMore,
+	})
+}
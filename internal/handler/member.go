@@ -1,13 +1,14 @@
 package handler
 
 import (
-	"log"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 
-	"github.com/nickkcj/orbit-backend/internal/worker/tasks"
+	"github.com/nickkcj/orbit-backend/internal/service"
 )
 
 type AddMemberRequest struct {
@@ -28,6 +29,14 @@ type UpdateProfileRequest struct {
 	DisplayName string  `json:"display_name"`
 	Bio         string  `json:"bio"`
 	AvatarURL   *string `json:"avatar_url"`
+	Handle      *string `json:"handle"`
+}
+
+type MentionSuggestion struct {
+	UserID    string `json:"user_id"`
+	Handle    string `json:"handle"`
+	Name      string `json:"name"`
+	AvatarURL string `json:"avatar_url,omitempty"`
 }
 
 func (h *Handler) AddMember(c echo.Context) error {
@@ -46,37 +55,18 @@ func (h *Handler) AddMember(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid user_id"})
 	}
 
-	var member interface{}
-
+	var roleID *uuid.UUID
 	if req.RoleID != "" {
-		roleID, err := uuid.Parse(req.RoleID)
+		id, err := uuid.Parse(req.RoleID)
 		if err != nil {
 			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid role_id"})
 		}
-		member, err = h.services.Member.Add(c.Request().Context(), tenant.ID, userID, roleID, req.DisplayName)
-		if err != nil {
-			return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
-		}
-	} else {
-		member, err = h.services.Member.AddWithDefaultRole(c.Request().Context(), tenant.ID, userID, req.DisplayName)
-		if err != nil {
-			return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
-		}
+		roleID = &id
 	}
 
-	// Enqueue welcome notification task
-	if h.taskClient != nil {
-		task, err := tasks.NewSendNotificationTask(tasks.NotificationPayload{
-			Type:          "welcome",
-			TenantID:      tenant.ID,
-			RecipientID:   userID,
-			CommunityName: tenant.Name,
-		})
-		if err == nil {
-			if _, err := h.taskClient.Enqueue(task); err != nil {
-				log.Printf("Failed to enqueue welcome notification: %v", err)
-			}
-		}
+	member, err := h.services.Member.AddWithWelcome(c.Request().Context(), tenant.ID, userID, roleID, req.DisplayName)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 	}
 
 	return c.JSON(http.StatusCreated, member)
@@ -155,9 +145,44 @@ func (h *Handler) UpdateMemberRole(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 	}
 
+	h.services.Permission.InvalidateUserPermissions(c.Request().Context(), tenant.ID, userID)
+
 	return c.JSON(http.StatusOK, member)
 }
 
+// UpdateMemberStatus suspends, bans or reactivates a member. Live sessions of
+// a member who is no longer active are closed.
+func (h *Handler) UpdateMemberStatus(c echo.Context) error {
+	tenant := GetTenantFromContext(c)
+	if tenant == nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "tenant context required"})
+	}
+
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid user_id"})
+	}
+
+	var req UpdateMemberStatusRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	switch req.Status {
+	case "active", "suspended", "banned":
+	default:
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "status must be active, suspended or banned"})
+	}
+
+	if err := h.services.Member.UpdateStatus(c.Request().Context(), tenant.ID, userID, req.Status); err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
+
+	h.services.Permission.InvalidateUserPermissions(c.Request().Context(), tenant.ID, userID)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
 func (h *Handler) RemoveMember(c echo.Context) error {
 	tenant := GetTenantFromContext(c)
 	if tenant == nil {
@@ -233,6 +258,20 @@ func (h *Handler) UpdateMyProfile(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
 	}
 
+	// Update the @mention handle first so an invalid or taken one changes nothing
+	if req.Handle != nil {
+		if _, err := h.services.Member.UpdateHandle(c.Request().Context(), tenant.ID, user.ID, *req.Handle); err != nil {
+			switch {
+			case errors.Is(err, service.ErrInvalidHandle):
+				return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			case errors.Is(err, service.ErrHandleTaken):
+				return c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+			default:
+				return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to update handle"})
+			}
+		}
+	}
+
 	// Update tenant member profile (display_name, bio)
 	_, err := h.services.Member.UpdateProfile(c.Request().Context(), tenant.ID, user.ID, req.DisplayName, req.Bio)
 	if err != nil {
@@ -256,6 +295,43 @@ func (h *Handler) UpdateMyProfile(c echo.Context) error {
 	return c.JSON(http.StatusOK, profile)
 }
 
+// MentionAutocomplete suggests members to @mention while typing
+// Endpoint: GET /members/autocomplete?q=jo&limit=8
+func (h *Handler) MentionAutocomplete(c echo.Context) error {
+	tenant := GetTenantFromContext(c)
+	if tenant == nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "tenant context required"})
+	}
+
+	limit := int32(8)
+	if l := c.QueryParam("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 20 {
+			limit = int32(parsed)
+		}
+	}
+
+	members, err := h.services.Member.SearchForMention(c.Request().Context(), tenant.ID, c.QueryParam("q"), limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to search members"})
+	}
+
+	suggestions := make([]MentionSuggestion, len(members))
+	for i, member := range members {
+		name := member.UserName
+		if member.DisplayName.Valid && member.DisplayName.String != "" {
+			name = member.DisplayName.String
+		}
+		suggestions[i] = MentionSuggestion{
+			UserID:    member.UserID.String(),
+			Handle:    member.Handle,
+			Name:      name,
+			AvatarURL: member.UserAvatar.String,
+		}
+	}
+
+	return c.JSON(http.StatusOK, suggestions)
+}
+
 // GetMemberPosts returns posts by a specific member
 func (h *Handler) GetMemberPosts(c echo.Context) error {
 	tenant := GetTenantFromContext(c)
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/nickkcj/orbit-backend/internal/service"
+)
+
+type UpdateNotificationPreferencesRequest struct {
+	Preferences []service.NotificationPreference `json:"preferences"`
+}
+
+// unsubscribePage confirms before unsubscribing, so link scanners that open
+// the email link with GET do not turn anything off
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html lang="pt-BR">
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"><title>Cancelar inscrição</title></head>
+<body style="font-family:sans-serif;max-width:480px;margin:48px auto;padding:0 16px;color:#111827;">
+{{if .Done}}<p>Pronto! Você não vai mais receber estes emails.</p>
+{{else}}<p>Deseja parar de receber estes emails?</p>
+<form method="post"><input type="hidden" name="token" value="{{.Token}}"><button type="submit">Cancelar inscrição</button></form>
+{{end}}</body>
+</html>`))
+
+// GetNotificationPreferences returns the current user's channels per notification type
+// Endpoint: GET /notifications/preferences
+func (h *Handler) GetNotificationPreferences(c echo.Context) error {
+	tenant := GetTenantFromContext(c)
+	if tenant == nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "tenant context required"})
+	}
+
+	user := GetUserFromContext(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "authentication required"})
+	}
+
+	prefs, err := h.services.Notification.Preferences(c.Request().Context(), tenant.ID, user.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to load notification preferences"})
+	}
+
+	return c.JSON(http.StatusOK, prefs)
+}
+
+// UpdateNotificationPreferences changes the channels of the given types
+// Endpoint: PUT /notifications/preferences
+func (h *Handler) UpdateNotificationPreferences(c echo.Context) error {
+	tenant := GetTenantFromContext(c)
+	if tenant == nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "tenant context required"})
+	}
+
+	user := GetUserFromContext(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "authentication required"})
+	}
+
+	var req UpdateNotificationPreferencesRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	prefs, err := h.services.Notification.UpdatePreferences(c.Request().Context(), tenant.ID, user.ID, req.Preferences)
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownNotificationType) {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to update notification preferences"})
+	}
+
+	return c.JSON(http.StatusOK, prefs)
+}
+
+// GetDigestSettings returns when the current user gets the activity digest
+// Endpoint: GET /notifications/digest
+func (h *Handler) GetDigestSettings(c echo.Context) error {
+	tenant := GetTenantFromContext(c)
+	if tenant == nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "tenant context required"})
+	}
+
+	user := GetUserFromContext(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "authentication required"})
+	}
+
+	settings, err := h.services.Digest.Settings(c.Request().Context(), tenant.ID, user.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to load digest settings"})
+	}
+
+	return c.JSON(http.StatusOK, settings)
+}
+
+// UpdateDigestSettings sets the digest frequency, hour, weekday and timezone
+// Endpoint: PUT /notifications/digest
+func (h *Handler) UpdateDigestSettings(c echo.Context) error {
+	tenant := GetTenantFromContext(c)
+	if tenant == nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "tenant context required"})
+	}
+
+	user := GetUserFromContext(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "authentication required"})
+	}
+
+	var req service.DigestSettings
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	settings, err := h.services.Digest.UpdateSettings(c.Request().Context(), tenant.ID, user.ID, req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidDigestSettings) {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to update digest settings"})
+	}
+
+	return c.JSON(http.StatusOK, settings)
+}
+
+// UnsubscribePage asks to confirm an unsubscribe link opened from an email
+// Endpoint: GET /notifications/unsubscribe?token=
+func (h *Handler) UnsubscribePage(c echo.Context) error {
+	token := c.QueryParam("token")
+	if token == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "token is required"})
+	}
+
+	var page strings.Builder
+	if err := unsubscribePage.Execute(&page, map[string]interface{}{"Token": token}); err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to render page"})
+	}
+	return c.HTML(http.StatusOK, page.String())
+}
+
+// Unsubscribe turns off the email channel named by the token. Mail clients
+// post here directly through List-Unsubscribe-Post (RFC 8058).
+// Endpoint: POST /notifications/unsubscribe?token=
+func (h *Handler) Unsubscribe(c echo.Context) error {
+	token := c.QueryParam("token")
+	if token == "" {
+		token = c.FormValue("token")
+	}
+
+	if err := h.services.Notification.Unsubscribe(c.Request().Context(), token); err != nil {
+		if errors.Is(err, service.ErrInvalidUnsubscribeToken) {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to unsubscribe"})
+	}
+
+	// The confirmation form expects a page back; mail clients ignore the body
+	var page strings.Builder
+	if err := unsubscribePage.Execute(&page, map[string]interface{}{"Done": true}); err != nil {
+		return c.NoContent(http.StatusOK)
+	}
+	return c.HTML(http.StatusOK, page.String())
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/nickkcj/orbit-backend/internal/service"
+)
+
+// PushSubscriptionRequest is the browser's PushSubscription.toJSON()
+type PushSubscriptionRequest struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+type PushSubscriptionResponse struct {
+	ID         string     `json:"id"`
+	Endpoint   string     `json:"endpoint"`
+	UserAgent  string     `json:"user_agent,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// GetVAPIDPublicKey returns the applicationServerKey for pushManager.subscribe
+// Endpoint: GET /push/vapid-public-key
+func (h *Handler) GetVAPIDPublicKey(c echo.Context) error {
+	if h.services.Push == nil {
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "push notifications not configured"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"public_key": h.services.Push.PublicKey()})
+}
+
+// SubscribePush registers the current device for push notifications
+// Endpoint: POST /push/subscriptions
+func (h *Handler) SubscribePush(c echo.Context) error {
+	if h.services.Push == nil {
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "push notifications not configured"})
+	}
+
+	user := GetUserFromContext(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "authentication required"})
+	}
+
+	var req PushSubscriptionRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	sub, err := h.services.Push.Subscribe(c.Request().Context(), service.SubscribePushInput{
+		UserID:    user.ID,
+		Endpoint:  req.Endpoint,
+		P256dh:    req.Keys.P256dh,
+		Auth:      req.Keys.Auth,
+		UserAgent: c.Request().UserAgent(),
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPushSubscription) {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to save push subscription"})
+	}
+
+	return c.JSON(http.StatusCreated, PushSubscriptionResponse{
+		ID:        sub.ID.String(),
+		Endpoint:  sub.Endpoint,
+		UserAgent: sub.UserAgent.String,
+		CreatedAt: sub.CreatedAt,
+	})
+}
+
+// ListPushSubscriptions returns the current user's subscribed devices
+// Endpoint: GET /push/subscriptions
+func (h *Handler) ListPushSubscriptions(c echo.Context) error {
+	if h.services.Push == nil {
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "push notifications not configured"})
+	}
+
+	user := GetUserFromContext(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "authentication required"})
+	}
+
+	subs, err := h.services.Push.List(c.Request().Context(), user.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to list push subscriptions"})
+	}
+
+	response := make([]PushSubscriptionResponse, len(subs))
+	for i, sub := range subs {
+		response[i] = PushSubscriptionResponse{
+			ID:        sub.ID.String(),
+			Endpoint:  sub.Endpoint,
+			UserAgent: sub.UserAgent.String,
+			CreatedAt: sub.CreatedAt,
+		}
+		if sub.LastUsedAt.Valid {
+			response[i].LastUsedAt = &sub.LastUsedAt.Time
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// UnsubscribePush removes a device, identified by its endpoint
+// Endpoint: DELETE /push/subscriptions
+func (h *Handler) UnsubscribePush(c echo.Context) error {
+	if h.services.Push == nil {
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "push notifications not configured"})
+	}
+
+	user := GetUserFromContext(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "authentication required"})
+	}
+
+	var req PushSubscriptionRequest
+	if err := c.Bind(&req); err != nil || req.Endpoint == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "endpoint is required"})
+	}
+
+	if err := h.services.Push.Unsubscribe(c.Request().Context(), user.ID, req.Endpoint); err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to remove push subscription"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -12,6 +12,7 @@ func (h *Handler) RegisterRoutes(
 	authMiddleware *middleware.AuthMiddleware,
 	tenantMiddleware *middleware.TenantMiddleware,
 	permissionMiddleware *middleware.PermissionMiddleware,
+	adminMiddleware *middleware.AdminMiddleware,
 	wsHandler *websocket.Handler,
 ) {
 	// Health
@@ -39,9 +40,18 @@ func (h *Handler) RegisterRoutes(
 	v1.POST("/auth/login", h.Login)
 	v1.GET("/auth/google", h.GoogleAuth)
 	v1.GET("/auth/google/callback", h.GoogleCallback)
+	v1.POST("/auth/google/exchange", h.ExchangeLoginCode)
+	v1.POST("/auth/refresh", h.RefreshToken)
+	v1.POST("/auth/verify-email", h.VerifyEmail)
 
 	// Auth (protected)
 	v1.GET("/auth/me", h.Me, authMiddleware.RequireAuth)
+	v1.POST("/auth/logout", h.Logout, authMiddleware.RequireAuth)
+	v1.POST("/auth/logout-all", h.LogoutAll, authMiddleware.RequireAuth)
+	v1.POST("/auth/password", h.ChangePassword, authMiddleware.RequireAuth)
+	v1.POST("/auth/verification-email", h.SendVerificationEmail, authMiddleware.RequireAuth)
+	v1.GET("/auth/sessions", h.ListSessions, authMiddleware.RequireAuth)
+	v1.DELETE("/auth/sessions/:id", h.RevokeSession, authMiddleware.RequireAuth)
 
 	// Tenant management (for main domain operations)
 	v1.GET("/tenants", h.ListTenants)
@@ -53,6 +63,28 @@ func (h *Handler) RegisterRoutes(
 	v1.GET("/users", h.GetUserByEmail, authMiddleware.RequireAuth)
 	v1.GET("/users/:userId/tenants", h.ListUserTenants, authMiddleware.RequireAuth)
 
+	// Web Push devices (per user, across tenants)
+	v1.GET("/push/vapid-public-key", h.GetVAPIDPublicKey)
+	v1.GET("/push/subscriptions", h.ListPushSubscriptions, authMiddleware.RequireAuth)
+	v1.POST("/push/subscriptions", h.SubscribePush, authMiddleware.RequireAuth)
+	v1.DELETE("/push/subscriptions", h.UnsubscribePush, authMiddleware.RequireAuth)
+
+	// One-click email unsubscribe (public, the token names the membership)
+	v1.GET("/notifications/unsubscribe", h.UnsubscribePage)
+	v1.POST("/notifications/unsubscribe", h.Unsubscribe)
+
+	// Platform admin: background task queues and dead letters
+	if h.inspector != nil {
+		admin := v1.Group("/admin", authMiddleware.RequireAuth, adminMiddleware.RequirePlatformAdmin)
+		admin.GET("/queues", h.ListQueues)
+		admin.GET("/tasks", h.ListTasks)
+		admin.GET("/queues/:queue/tasks/:id", h.GetTask)
+		admin.POST("/queues/:queue/archived/:id/retry", h.RetryArchivedTask)
+		admin.DELETE("/queues/:queue/archived/:id", h.DeleteArchivedTask)
+		admin.POST("/queues/:queue/retry-archived", h.RetryAllArchivedTasks)
+		admin.DELETE("/queues/:queue/archived", h.DeleteAllArchivedTasks)
+	}
+
 	// ============================================
 	// TENANT-SCOPED ROUTES (subdomain required)
 	// ============================================
@@ -67,11 +99,13 @@ func (h *Handler) RegisterRoutes(
 	tenantProtected.GET("/categories/:id", h.GetCategory)
 	tenantProtected.PUT("/categories/:id", h.UpdateCategory, permissionMiddleware.RequirePermission("categories.manage"))
 	tenantProtected.DELETE("/categories/:id", h.DeleteCategory, permissionMiddleware.RequirePermission("categories.manage"))
+	tenantProtected.POST("/categories/:id/follow", h.FollowCategory, permissionMiddleware.RequirePermission("posts.view"))
+	tenantProtected.DELETE("/categories/:id/follow", h.UnfollowCategory)
 
 	// Posts (tenant-scoped)
 	tenantScoped.GET("/posts", h.ListPosts)
 	tenantScoped.GET("/posts/:id", h.GetPost)
-	tenantProtected.POST("/posts", h.CreatePost, permissionMiddleware.RequirePermission("posts.create"))
+	tenantProtected.POST("/posts", h.CreatePost, tenantMiddleware.RequireVerifiedEmail, permissionMiddleware.RequirePermission("posts.create"))
 	tenantProtected.PUT("/posts/:id", h.UpdatePost, permissionMiddleware.RequireAnyPermission("posts.edit", "posts.edit_own"))
 	tenantProtected.POST("/posts/:id/publish", h.PublishPost, permissionMiddleware.RequireAnyPermission("posts.edit", "posts.edit_own"))
 	tenantProtected.DELETE("/posts/:id", h.DeletePost, permissionMiddleware.RequireAnyPermission("posts.delete", "posts.delete_own"))
@@ -80,16 +114,24 @@ func (h *Handler) RegisterRoutes(
 	tenantScoped.GET("/posts/:postId/comments", h.ListComments)
 	tenantScoped.GET("/comments/:id", h.GetComment)
 	tenantScoped.GET("/comments/:id/replies", h.ListReplies)
-	tenantProtected.POST("/comments", h.CreateComment, permissionMiddleware.RequirePermission("comments.create"))
+	tenantProtected.POST("/comments", h.CreateComment, tenantMiddleware.RequireVerifiedEmail, permissionMiddleware.RequirePermission("comments.create"))
 	tenantProtected.PUT("/comments/:id", h.UpdateComment, permissionMiddleware.RequirePermission("comments.edit_own"))
 	tenantProtected.DELETE("/comments/:id", h.DeleteComment, permissionMiddleware.RequireAnyPermission("comments.delete", "comments.delete_own"))
 
 	// Members (tenant-scoped)
 	tenantScoped.GET("/members", h.ListMembers)
 	tenantProtected.POST("/members", h.AddMember, permissionMiddleware.RequirePermission("members.invite"))
+	tenantProtected.GET("/members/autocomplete", h.MentionAutocomplete, permissionMiddleware.RequirePermission("members.view"))
 	tenantProtected.GET("/members/:userId", h.GetMember)
 	tenantProtected.PUT("/members/:userId/role", h.UpdateMemberRole, permissionMiddleware.RequirePermission("members.manage"))
+	tenantProtected.PUT("/members/:userId/status", h.UpdateMemberStatus, permissionMiddleware.RequirePermission("moderation.ban"))
 	tenantProtected.DELETE("/members/:userId", h.RemoveMember, permissionMiddleware.RequirePermission("members.remove"))
+	tenantProtected.POST("/members/:userId/follow", h.FollowMember, permissionMiddleware.RequirePermission("posts.view"))
+	tenantProtected.DELETE("/members/:userId/follow", h.UnfollowMember)
+
+	// Follows (new post notifications)
+	tenantProtected.GET("/follows/categories", h.ListFollowedCategories)
+	tenantProtected.GET("/follows/members", h.ListFollowedMembers)
 
 	// Profile (tenant-scoped)
 	tenantScoped.GET("/profile/:userId", h.GetMemberProfile)
@@ -109,9 +151,28 @@ func (h *Handler) RegisterRoutes(
 	tenantProtected.GET("/notifications", h.ListNotifications)
 	tenantProtected.GET("/notifications/unread/count", h.GetUnreadCount)
 	tenantProtected.POST("/notifications/read-all", h.MarkAllNotificationsRead)
+	tenantProtected.GET("/notifications/preferences", h.GetNotificationPreferences)
+	tenantProtected.PUT("/notifications/preferences", h.UpdateNotificationPreferences)
+	tenantProtected.GET("/notifications/digest", h.GetDigestSettings)
+	tenantProtected.PUT("/notifications/digest", h.UpdateDigestSettings)
 	tenantProtected.POST("/notifications/:id/read", h.MarkNotificationRead)
 	tenantProtected.DELETE("/notifications/:id", h.DeleteNotification)
 
+	// Presence (tenant-scoped, protected)
+	if wsHandler != nil {
+		tenantProtected.GET("/presence", wsHandler.GetPresence, permissionMiddleware.RequirePermission("members.view"))
+	}
+
+	// Realtime events over SSE (fallback when /ws is blocked)
+	if wsHandler != nil {
+		tenantProtected.GET("/events/stream", wsHandler.HandleEventStream)
+	}
+
+	// WebSocket connection tickets (keep the JWT out of the /ws URL)
+	if wsHandler != nil {
+		tenantProtected.POST("/ws/ticket", wsHandler.IssueTicket)
+	}
+
 	// Analytics (tenant-scoped, protected - owner/admin only)
 	tenantProtected.GET("/analytics/dashboard", h.GetDashboard, permissionMiddleware.RequireOwnerOrAdmin())
 	tenantProtected.GET("/analytics/stats", h.GetAnalyticsStats, permissionMiddleware.RequireOwnerOrAdmin())
@@ -181,7 +242,7 @@ func (h *Handler) RegisterRoutes(
 	// ============================================
 
 	// Enrollments - User's own enrollments
-	tenantProtected.POST("/enrollments", h.EnrollInCourse, permissionMiddleware.RequirePermission("enrollments.enroll"))
+	tenantProtected.POST("/enrollments", h.EnrollInCourse, tenantMiddleware.RequireVerifiedEmail, permissionMiddleware.RequirePermission("enrollments.enroll"))
 	tenantProtected.GET("/enrollments", h.GetMyEnrollments, permissionMiddleware.RequirePermission("enrollments.view"))
 	tenantProtected.GET("/enrollments/continue", h.GetContinueLearning, permissionMiddleware.RequirePermission("enrollments.view"))
 
@@ -202,4 +263,23 @@ func (h *Handler) RegisterRoutes(
 	tenantProtected.POST("/learn/lessons/:id/complete", h.MarkLessonComplete, permissionMiddleware.RequirePermission("enrollments.view"))
 	tenantProtected.DELETE("/learn/lessons/:id/complete", h.UnmarkLessonComplete, permissionMiddleware.RequirePermission("enrollments.view"))
 	tenantProtected.PUT("/learn/lessons/:id/video-progress", h.UpdateLessonVideoProgress, permissionMiddleware.RequirePermission("enrollments.view"))
+
+	// Lesson chat history (send/edit/delete go over the WebSocket)
+	tenantProtected.GET("/learn/lessons/:id/chat", h.GetLessonChatHistory, permissionMiddleware.RequirePermission("enrollments.view"))
+
+	// ============================================
+	// DIRECT MESSAGES (tenant-scoped)
+	// ============================================
+
+	tenantProtected.GET("/conversations", h.ListConversations)
+	tenantProtected.POST("/conversations", h.StartConversation, tenantMiddleware.RequireVerifiedEmail, permissionMiddleware.RequirePermission("messages.send"))
+	tenantProtected.GET("/conversations/unread/count", h.GetUnreadMessageCount)
+	tenantProtected.GET("/conversations/:id", h.GetConversation)
+	tenantProtected.GET("/conversations/:id/messages", h.ListDirectMessages)
+	tenantProtected.POST("/conversations/:id/messages", h.SendDirectMessage, tenantMiddleware.RequireVerifiedEmail, permissionMiddleware.RequirePermission("messages.send"))
+	tenantProtected.POST("/conversations/:id/read", h.MarkConversationRead)
+
+	// Blocking (stops direct messages between the two members)
+	tenantProtected.POST("/members/:userId/block", h.BlockMember)
+	tenantProtected.DELETE("/members/:userId/block", h.UnblockMember)
 }
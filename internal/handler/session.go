@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/nickkcj/orbit-backend/internal/middleware"
+	"github.com/nickkcj/orbit-backend/internal/service"
+)
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// RefreshToken trades a refresh token for a new access and refresh token pair
+// Endpoint: POST /auth/refresh
+func (h *Handler) RefreshToken(c echo.Context) error {
+	var req RefreshRequest
+	if err := c.Bind(&req); err != nil || req.RefreshToken == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "refresh_token is required"})
+	}
+
+	result, err := h.services.Auth.Refresh(c.Request().Context(), req.RefreshToken, sessionMeta(c, ""))
+	if err != nil {
+		if errors.Is(err, service.ErrRefreshTokenReused) {
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "refresh token already used; session revoked"})
+		}
+		if errors.Is(err, service.ErrInvalidRefreshToken) {
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to refresh session"})
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// Logout revokes the session of the current token
+// Endpoint: POST /auth/logout
+func (h *Handler) Logout(c echo.Context) error {
+	user := GetUserFromContext(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "authentication required"})
+	}
+
+	err := h.services.Auth.RevokeSession(c.Request().Context(), user.ID, currentSessionID(c), service.SessionRevokedLogout)
+	if err != nil && !errors.Is(err, service.ErrSessionNotFound) {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to log out"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// LogoutAll revokes every session of the user, the current one included
+// Endpoint: POST /auth/logout-all
+func (h *Handler) LogoutAll(c echo.Context) error {
+	user := GetUserFromContext(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "authentication required"})
+	}
+
+	revoked, err := h.services.Auth.RevokeOtherSessions(c.Request().Context(), user.ID, uuid.Nil, service.SessionRevokedLogoutAll)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to log out"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]int64{"revoked": revoked})
+}
+
+// ListSessions returns the user's active sessions, flagging the current one
+// Endpoint: GET /auth/sessions
+func (h *Handler) ListSessions(c echo.Context) error {
+	user := GetUserFromContext(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "authentication required"})
+	}
+
+	sessions, err := h.services.Auth.ListSessions(c.Request().Context(), user.ID, currentSessionID(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to list sessions"})
+	}
+
+	return c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession signs one of the user's devices out
+// Endpoint: DELETE /auth/sessions/:id
+func (h *Handler) RevokeSession(c echo.Context) error {
+	user := GetUserFromContext(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "authentication required"})
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid session id"})
+	}
+
+	if err := h.services.Auth.RevokeSession(c.Request().Context(), user.ID, id, service.SessionRevokedByUser); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			return c.JSON(http.StatusNotFound, ErrorResponse{Error: "session not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to revoke session"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ChangePassword sets a new password and signs out every other session
+// Endpoint: POST /auth/password
+func (h *Handler) ChangePassword(c echo.Context) error {
+	user := GetUserFromContext(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "authentication required"})
+	}
+
+	var req ChangePasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+	if len(req.NewPassword) < 8 {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "password must be at least 8 characters"})
+	}
+
+	err := h.services.Auth.ChangePassword(c.Request().Context(), user.ID, currentSessionID(c), req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "current password is incorrect"})
+		}
+		if errors.Is(err, service.ErrRecentLoginRequired) {
+			return c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to change password"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// sessionMeta describes the client making the request
+func sessionMeta(c echo.Context, device string) service.SessionMeta {
+	return service.SessionMeta{
+		Device:    device,
+		IP:        c.RealIP(),
+		UserAgent: c.Request().UserAgent(),
+	}
+}
+
+// currentSessionID is the session of the token RequireAuth validated
+func currentSessionID(c echo.Context) uuid.UUID {
+	if claims := middleware.GetClaimsFromContext(c); claims != nil {
+		return claims.SessionID
+	}
+	return uuid.Nil
+}
@@ -14,7 +14,8 @@ type CreateTenantRequest struct {
 }
 
 type UpdateTenantSettingsRequest struct {
-	Theme *service.ThemeSettings `json:"theme"`
+	Theme                *service.ThemeSettings `json:"theme"`
+	RequireVerifiedEmail *bool                  `json:"requireVerifiedEmail"`
 }
 
 type UpdateTenantLogoRequest struct {
@@ -121,8 +122,13 @@ func (h *Handler) UpdateTenantSettings(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
 	}
 
-	settings := service.TenantSettings{
-		Theme: req.Theme,
+	// Fields left out of the request keep their current value
+	settings := service.ParseTenantSettings(*tenant)
+	if req.Theme != nil {
+		settings.Theme = req.Theme
+	}
+	if req.RequireVerifiedEmail != nil {
+		settings.RequireVerifiedEmail = *req.RequireVerifiedEmail
 	}
 
 	updatedTenant, err := h.services.Tenant.UpdateSettings(ctx, tenant.ID, settings)
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"io"
 	"net/http"
 
@@ -8,6 +9,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/nickkcj/orbit-backend/internal/middleware"
 	"github.com/nickkcj/orbit-backend/internal/service"
+	"github.com/nickkcj/orbit-backend/internal/worker/tasks"
 )
 
 // InitiateVideoUpload handles POST /videos - creates upload URL
@@ -204,13 +206,12 @@ func (h *Handler) HandleStreamWebhook(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid payload"})
 	}
 
-	// Process webhook
-	if err := h.services.Video.ProcessWebhook(c.Request().Context(), payload); err != nil {
-		// Log error but return success to avoid retries for non-existent videos
-		c.Logger().Errorf("Failed to process stream webhook: %v", err)
+	eventType := payload.Status.State
+	if eventType == "" {
+		eventType = "unknown"
 	}
 
-	return c.NoContent(http.StatusOK)
+	return h.enqueueWebhook(c, tasks.ProviderCloudflareStream, eventType, body)
 }
 
 // ConfirmVideoUpload handles POST /videos/:id/confirm - confirms upload completion
@@ -235,6 +236,10 @@ func (h *Handler) ConfirmVideoUpload(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
 	}
 
+	if req.StreamUID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "stream_uid is required"})
+	}
+
 	// Verify video belongs to user and tenant
 	video, err := h.services.Video.GetByID(c.Request().Context(), videoID)
 	if err != nil {
@@ -247,9 +252,22 @@ func (h *Handler) ConfirmVideoUpload(c echo.Context) error {
 
 	// Update video with stream UID
 	if err := h.services.Video.UpdateExternalID(c.Request().Context(), videoID, req.StreamUID); err != nil {
+		if errors.Is(err, service.ErrStreamUIDMismatch) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to confirm upload"})
 	}
 
+	// Poll the provider in case the ready webhook never arrives; the
+	// reconcile sweep covers a failed enqueue
+	task, err := tasks.NewProcessVideoTask(tasks.VideoPayload{VideoID: videoID, TenantID: tenant.ID})
+	if err == nil {
+		_, err = h.taskClient.Enqueue(task)
+	}
+	if err != nil {
+		c.Logger().Errorf("failed to enqueue video %s processing: %v", videoID, err)
+	}
+
 	return c.JSON(http.StatusOK, map[string]string{"status": "processing"})
 }
 
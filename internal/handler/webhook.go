@@ -5,10 +5,14 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 
+	"github.com/hibiken/asynq"
 	"github.com/labstack/echo/v4"
+
+	"github.com/nickkcj/orbit-backend/internal/worker/tasks"
 )
 
 // R2 webhook payload for video ready notification
@@ -63,46 +67,50 @@ func (h *Handler) HandleR2Webhook(c echo.Context) error {
 		})
 	}
 
-	// Log the webhook event
-	_, err = h.services.Webhook.LogEvent(c.Request().Context(), "r2", payload.Action, body)
+	return h.enqueueWebhook(c, tasks.ProviderR2, payload.Action, body)
+}
+
+// enqueueWebhook logs a verified webhook and hands it to the worker. The
+// provider gets a 5xx when the event cannot be recorded or queued, so it
+// delivers it again instead of the event being lost.
+func (h *Handler) enqueueWebhook(c echo.Context, provider, eventType string, body []byte) error {
+	event, err := h.services.Webhook.LogEvent(c.Request().Context(), provider, eventType, body)
 	if err != nil {
-		// Log error but don't fail the webhook
-		c.Logger().Errorf("failed to log webhook event: %v", err)
+		c.Logger().Errorf("failed to log %s webhook event: %v", provider, err)
+		return c.JSON(http.StatusInternalServerError, WebhookResponse{
+			Success: false,
+			Message: "failed to record event",
+		})
 	}
 
-	// Process based on action
-	switch payload.Action {
-	case "PutObject":
-		// Video uploaded - could trigger processing
-		return h.handleVideoUploaded(c, payload)
-	case "DeleteObject":
-		// Video deleted
-		return h.handleVideoDeleted(c, payload)
-	default:
-		// Unknown action, acknowledge but don't process
+	if event.Status == "processed" {
 		return c.JSON(http.StatusOK, WebhookResponse{
 			Success: true,
-			Message: "event acknowledged",
+			Message: "event already processed",
 		})
 	}
-}
-
-func (h *Handler) handleVideoUploaded(c echo.Context, payload R2WebhookPayload) error {
-	// Extract video info from key path
-	// Expected format: tenants/{tenant_id}/videos/{video_uuid}/{filename}
-	// For now, just acknowledge - video is ready since R2 doesn't transcode
 
-	return c.JSON(http.StatusOK, WebhookResponse{
-		Success: true,
-		Message: "video upload processed",
+	task, err := tasks.NewProcessWebhookTask(tasks.WebhookPayload{
+		Provider:   provider,
+		EventType:  eventType,
+		EventID:    event.ID,
+		RawPayload: body,
 	})
-}
+	if err == nil {
+		_, err = h.taskClient.Enqueue(task)
+	}
+	// A conflict means this event is already queued or being retried
+	if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		c.Logger().Errorf("failed to enqueue %s webhook %s: %v", provider, event.ID, err)
+		return c.JSON(http.StatusInternalServerError, WebhookResponse{
+			Success: false,
+			Message: "failed to queue event",
+		})
+	}
 
-func (h *Handler) handleVideoDeleted(c echo.Context, payload R2WebhookPayload) error {
-	// Handle video deletion if needed
 	return c.JSON(http.StatusOK, WebhookResponse{
 		Success: true,
-		Message: "video deletion processed",
+		Message: "event acknowledged",
 	})
 }
 
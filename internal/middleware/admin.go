@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AdminMiddleware restricts platform-wide endpoints to configured operators
+type AdminMiddleware struct {
+	emails map[string]struct{}
+}
+
+// NewAdminMiddleware creates an admin middleware; with no emails every request is denied.
+// Emails match exactly, as users.email is unique case-sensitively.
+func NewAdminMiddleware(emails []string) *AdminMiddleware {
+	allowed := make(map[string]struct{}, len(emails))
+	for _, email := range emails {
+		allowed[strings.TrimSpace(email)] = struct{}{}
+	}
+	return &AdminMiddleware{emails: allowed}
+}
+
+// RequirePlatformAdmin must run after RequireAuth. The account must have
+// verified its email, so nobody can claim an allowlisted address by
+// registering it first.
+func (m *AdminMiddleware) RequirePlatformAdmin(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := GetUserFromContext(c)
+		if user == nil {
+			return c.JSON(http.StatusUnauthorized, map[string]string{
+				"error": "authentication required",
+			})
+		}
+
+		if _, ok := m.emails[user.Email]; !ok || !user.EmailVerifiedAt.Valid {
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error": "platform admin only",
+			})
+		}
+
+		return next(c)
+	}
+}
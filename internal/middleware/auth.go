@@ -11,7 +11,8 @@ import (
 )
 
 const (
-	UserContextKey = "user"
+	UserContextKey   = "user"
+	ClaimsContextKey = "claims"
 )
 
 type AuthMiddleware struct {
@@ -22,7 +23,7 @@ func NewAuthMiddleware(authService *service.AuthService) *AuthMiddleware {
 	return &AuthMiddleware{authService: authService}
 }
 
-// RequireAuth middleware requires a valid JWT token
+// RequireAuth middleware requires a valid JWT token from an active session
 func (m *AuthMiddleware) RequireAuth(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		token := extractToken(c)
@@ -32,7 +33,7 @@ func (m *AuthMiddleware) RequireAuth(next echo.HandlerFunc) echo.HandlerFunc {
 			})
 		}
 
-		claims, err := m.authService.ValidateToken(token)
+		claims, err := m.authService.VerifyAccessToken(c.Request().Context(), token)
 		if err != nil {
 			return c.JSON(http.StatusUnauthorized, map[string]string{
 				"error": "invalid or expired token",
@@ -56,6 +57,7 @@ func (m *AuthMiddleware) RequireAuth(next echo.HandlerFunc) echo.HandlerFunc {
 
 		// Store user in context
 		c.Set(UserContextKey, &user)
+		c.Set(ClaimsContextKey, claims)
 
 		return next(c)
 	}
@@ -69,7 +71,7 @@ func (m *AuthMiddleware) OptionalAuth(next echo.HandlerFunc) echo.HandlerFunc {
 			return next(c)
 		}
 
-		claims, err := m.authService.ValidateToken(token)
+		claims, err := m.authService.VerifyAccessToken(c.Request().Context(), token)
 		if err != nil {
 			return next(c)
 		}
@@ -111,3 +113,12 @@ func GetUserFromContext(c echo.Context) *database.User {
 	}
 	return user
 }
+
+// GetClaimsFromContext returns the validated token claims set by RequireAuth
+func GetClaimsFromContext(c echo.Context) *service.JWTClaims {
+	claims, ok := c.Get(ClaimsContextKey).(*service.JWTClaims)
+	if !ok {
+		return nil
+	}
+	return claims
+}
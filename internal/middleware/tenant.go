@@ -93,6 +93,27 @@ func (m *TenantMiddleware) OptionalTenant(next echo.HandlerFunc) echo.HandlerFun
 	}
 }
 
+// RequireVerifiedEmail stops users whose email is not verified when the
+// tenant requires verified emails. Runs after RequireTenant and RequireAuth.
+func (m *TenantMiddleware) RequireVerifiedEmail(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tenant := GetTenantFromContext(c)
+		user := GetUserFromContext(c)
+		if tenant == nil || user == nil || user.EmailVerifiedAt.Valid {
+			return next(c)
+		}
+
+		if service.ParseTenantSettings(*tenant).RequireVerifiedEmail {
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error": "confirme seu email para continuar",
+				"code":  "EMAIL_NOT_VERIFIED",
+			})
+		}
+
+		return next(c)
+	}
+}
+
 // extractSubdomain parses the Host header and extracts subdomain
 // Examples:
 //   - joao.orbit.app.br -> "joao"
@@ -0,0 +1,25 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/nickkcj/orbit-backend/internal/database"
+)
+
+// Add stores a background task in the outbox through q. Passing the queries
+// of an open transaction makes the task commit or roll back together with the
+// domain change; the Relay enqueues it once committed.
+func Add(ctx context.Context, q *database.Queries, taskType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s payload: %w", taskType, err)
+	}
+
+	_, err = q.CreateOutboxMessage(ctx, database.CreateOutboxMessageParams{
+		TaskType: taskType,
+		Payload:  data,
+	})
+	return err
+}
@@ -0,0 +1,135 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/hibiken/asynq"
+
+	"github.com/nickkcj/orbit-backend/internal/database"
+	"github.com/nickkcj/orbit-backend/internal/worker/tasks"
+)
+
+const (
+	maxRelayBackoff = 5 * time.Minute
+
+	// maxRelayAttempts is how often a message may fail to enqueue before the
+	// relay gives up on it; with the backoff that is about 80 minutes
+	maxRelayAttempts = 25
+)
+
+// Enqueuer hands tasks to the queue; satisfied by worker.TaskClient
+type Enqueuer interface {
+	Enqueue(task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error)
+}
+
+// Relay moves committed outbox messages into asynq. Rows are claimed with
+// FOR UPDATE SKIP LOCKED so several relays can run side by side, and each
+// task gets the message ID as its asynq task ID, so a message enqueued right
+// before a crash is not enqueued twice.
+type Relay struct {
+	db        *database.Queries
+	enqueuer  Enqueuer
+	interval  time.Duration
+	batchSize int32
+}
+
+func NewRelay(db *database.Queries, enqueuer Enqueuer, interval time.Duration, batchSize int) *Relay {
+	return &Relay{
+		db:        db,
+		enqueuer:  enqueuer,
+		interval:  interval,
+		batchSize: int32(batchSize),
+	}
+}
+
+// Run polls the outbox until ctx is cancelled
+func (r *Relay) Run(ctx context.Context) {
+	log.Printf("[OUTBOX] Relay started (interval=%s, batch=%d)", r.interval, r.batchSize)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[OUTBOX] Relay stopped")
+			return
+		case <-ticker.C:
+			r.drain(ctx)
+		}
+	}
+}
+
+// drain relays full batches until the outbox has no ready messages left
+func (r *Relay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		claimed, err := r.relayBatch(ctx)
+		if err != nil {
+			log.Printf("[OUTBOX] Relay batch failed: %v", err)
+			return
+		}
+		if claimed < int(r.batchSize) {
+			return
+		}
+	}
+}
+
+func (r *Relay) relayBatch(ctx context.Context) (int, error) {
+	claimed := 0
+	err := r.db.InTx(ctx, func(q *database.Queries) error {
+		messages, err := q.ClaimOutboxMessages(ctx, r.batchSize)
+		if err != nil {
+			return err
+		}
+		claimed = len(messages)
+
+		for _, msg := range messages {
+			task := asynq.NewTask(msg.TaskType, msg.Payload, tasks.Options(msg.TaskType)...)
+			_, err := r.enqueuer.Enqueue(task, asynq.TaskID("outbox:"+msg.ID.String()))
+			if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+				log.Printf("[OUTBOX] Failed to enqueue %s %s (attempt %d): %v", msg.TaskType, msg.ID, msg.Attempts+1, err)
+				if err := retryLater(ctx, q, msg, err); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := q.DeleteOutboxMessage(ctx, msg.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return claimed, err
+}
+
+// retryLater schedules the next attempt of a message that failed to enqueue,
+// or gives up on it once it has failed maxRelayAttempts times
+func retryLater(ctx context.Context, q *database.Queries, msg database.OutboxMessage, cause error) error {
+	lastError := sql.NullString{String: cause.Error(), Valid: true}
+	if msg.Attempts+1 >= maxRelayAttempts {
+		log.Printf("[OUTBOX] Giving up on %s %s after %d attempts", msg.TaskType, msg.ID, msg.Attempts+1)
+		return q.FailOutboxMessage(ctx, database.FailOutboxMessageParams{ID: msg.ID, LastError: lastError})
+	}
+	return q.RescheduleOutboxMessage(ctx, database.RescheduleOutboxMessageParams{
+		ID:          msg.ID,
+		LastError:   lastError,
+		AvailableAt: time.Now().Add(relayBackoff(msg.Attempts)),
+	})
+}
+
+// relayBackoff doubles the wait after each failed attempt, up to maxRelayBackoff
+func relayBackoff(attempts int32) time.Duration {
+	if attempts > 8 {
+		return maxRelayBackoff
+	}
+	backoff := time.Second << attempts
+	if backoff > maxRelayBackoff {
+		return maxRelayBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,194 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+
+	"github.com/nickkcj/orbit-backend/internal/database"
+)
+
+func TestRelayBackoff(t *testing.T) {
+	cases := []struct {
+		attempts int32
+		want     time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{8, 256 * time.Second},
+		{9, maxRelayBackoff},
+		{maxRelayAttempts, maxRelayBackoff},
+	}
+	for _, tc := range cases {
+		if got := relayBackoff(tc.attempts); got != tc.want {
+			t.Errorf("relayBackoff(%d) = %s, want %s", tc.attempts, got, tc.want)
+		}
+	}
+}
+
+func TestRelayGivesUpAfterMaxAttempts(t *testing.T) {
+	retrying := database.OutboxMessage{ID: uuid.New(), TaskType: "test:task", Payload: []byte("{}"), Attempts: maxRelayAttempts - 2}
+	lastTry := database.OutboxMessage{ID: uuid.New(), TaskType: "test:task", Payload: []byte("{}"), Attempts: maxRelayAttempts - 1}
+	store := &relayStore{claimable: []database.OutboxMessage{retrying, lastTry}}
+	relay := NewRelay(openRelayDB(t, store), failingEnqueuer{}, time.Second, 10)
+
+	claimed, err := relay.relayBatch(context.Background())
+	if err != nil {
+		t.Fatalf("relay batch: %v", err)
+	}
+	if claimed != 2 {
+		t.Fatalf("expected 2 messages claimed, got %d", claimed)
+	}
+
+	if got := store.calls[retrying.ID]; got != "RescheduleOutboxMessage" {
+		t.Fatalf("message below the limit: %s, want a reschedule", got)
+	}
+	if got := store.calls[lastTry.ID]; got != "FailOutboxMessage" {
+		t.Fatalf("message at the limit: %s, want it failed", got)
+	}
+}
+
+func TestRelayDeletesEnqueuedMessages(t *testing.T) {
+	msg := database.OutboxMessage{ID: uuid.New(), TaskType: "test:task", Payload: []byte("{}")}
+	store := &relayStore{claimable: []database.OutboxMessage{msg}}
+	relay := NewRelay(openRelayDB(t, store), conflictEnqueuer{}, time.Second, 10)
+
+	// A task ID conflict means an earlier run already enqueued it
+	if _, err := relay.relayBatch(context.Background()); err != nil {
+		t.Fatalf("relay batch: %v", err)
+	}
+	if got := store.calls[msg.ID]; got != "DeleteOutboxMessage" {
+		t.Fatalf("enqueued message: %s, want it deleted", got)
+	}
+}
+
+type failingEnqueuer struct{}
+
+func (failingEnqueuer) Enqueue(*asynq.Task, ...asynq.Option) (*asynq.TaskInfo, error) {
+	return nil, errors.New("redis unavailable")
+}
+
+type conflictEnqueuer struct{}
+
+func (conflictEnqueuer) Enqueue(*asynq.Task, ...asynq.Option) (*asynq.TaskInfo, error) {
+	return nil, asynq.ErrTaskIDConflict
+}
+
+// ============================================================================
+// In-memory database
+// ============================================================================
+
+// relayStore hands out claimable messages and records what the relay did to
+// each, reached through a database/sql driver so InTx still gets a *sql.DB
+type relayStore struct {
+	mu        sync.Mutex
+	claimable []database.OutboxMessage
+	calls     map[uuid.UUID]string // message -> last statement run on it
+}
+
+var (
+	relayStores   sync.Map
+	registerRelay sync.Once
+)
+
+func openRelayDB(t *testing.T, store *relayStore) *database.Queries {
+	t.Helper()
+	registerRelay.Do(func() { sql.Register("relaystore", relayDriver{}) })
+	store.calls = make(map[uuid.UUID]string)
+
+	dsn := uuid.NewString()
+	relayStores.Store(dsn, store)
+	db, err := sql.Open("relaystore", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		relayStores.Delete(dsn)
+	})
+	return database.New(db)
+}
+
+type relayDriver struct{}
+
+func (relayDriver) Open(dsn string) (driver.Conn, error) {
+	store, ok := relayStores.Load(dsn)
+	if !ok {
+		return nil, fmt.Errorf("relaystore: unknown store %s", dsn)
+	}
+	return &relayConn{store: store.(*relayStore)}, nil
+}
+
+type relayConn struct {
+	store *relayStore
+}
+
+func (c *relayConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("relaystore: prepared statements not supported")
+}
+
+func (c *relayConn) Close() error              { return nil }
+func (c *relayConn) Begin() (driver.Tx, error) { return relayTx{}, nil }
+
+func (c *relayConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	c.store.calls[uuid.MustParse(args[0].Value.(string))] = queryName(query)
+	return driver.RowsAffected(1), nil
+}
+
+func (c *relayConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if name := queryName(query); name != "ClaimOutboxMessages" {
+		return nil, fmt.Errorf("relaystore: unsupported query %s", name)
+	}
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	rows := &relayRows{}
+	for _, msg := range c.store.claimable {
+		rows.rows = append(rows.rows, []driver.Value{
+			msg.ID.String(), msg.TaskType, []byte(msg.Payload), int64(msg.Attempts), nil, time.Now(), time.Now(), nil,
+		})
+	}
+	return rows, nil
+}
+
+// queryName returns X from the "-- name: X :kind" header sqlc puts on every query
+func queryName(query string) string {
+	fields := strings.Fields(strings.TrimPrefix(query, "-- name: "))
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+type relayTx struct{}
+
+func (relayTx) Commit() error   { return nil }
+func (relayTx) Rollback() error { return nil }
+
+type relayRows struct {
+	rows [][]driver.Value
+}
+
+func (r *relayRows) Columns() []string { return make([]string, 8) }
+func (r *relayRows) Close() error      { return nil }
+
+func (r *relayRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
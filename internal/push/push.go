@@ -0,0 +1,65 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrSubscriptionGone means the push service dropped the subscription
+	// (404/410); it must not be used again
+	ErrSubscriptionGone = errors.New("push subscription is gone")
+	ErrPayloadTooLarge  = errors.New("push payload is too large")
+	ErrInvalidKeys      = errors.New("invalid push subscription keys")
+)
+
+// Subscription is a browser's PushSubscription as returned by toJSON()
+type Subscription struct {
+	Endpoint string
+	P256dh   string // Base64url user agent public key
+	Auth     string // Base64url authentication secret
+}
+
+// Message is a payload for one subscription
+type Message struct {
+	Payload []byte
+	TTL     time.Duration // How long the push service keeps it for an offline device
+	Urgency string        // very-low, low, normal or high; empty is normal
+	Topic   string        // Replaces an undelivered message with the same topic
+}
+
+// Sender delivers push messages. Implementations must be safe for concurrent use.
+type Sender interface {
+	Send(ctx context.Context, sub Subscription, msg Message) error
+}
+
+// StatusError is a push service response other than 201 Created
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("push service returned %d: %s", e.StatusCode, e.Body)
+}
+
+// Is makes 404 and 410 match ErrSubscriptionGone
+func (e *StatusError) Is(target error) bool {
+	return target == ErrSubscriptionGone && (e.StatusCode == http.StatusNotFound || e.StatusCode == http.StatusGone)
+}
+
+// IsPermanent reports whether a delivery error will not go away on retry,
+// such as a gone subscription or a rejected payload
+func IsPermanent(err error) bool {
+	if errors.Is(err, ErrPayloadTooLarge) || errors.Is(err, ErrInvalidKeys) {
+		return true
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 400 && statusErr.StatusCode < 500 && statusErr.StatusCode != http.StatusTooManyRequests
+	}
+	return false
+}
@@ -0,0 +1,204 @@
+package push
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// pushEndpoint is a local push service that records what it receives
+type pushEndpoint struct {
+	server   *httptest.Server
+	status   int
+	requests chan capturedPush
+}
+
+type capturedPush struct {
+	header http.Header
+	body   []byte
+}
+
+func newPushEndpoint(t *testing.T, status int) *pushEndpoint {
+	t.Helper()
+	e := &pushEndpoint{status: status, requests: make(chan capturedPush, 1)}
+	e.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		e.requests <- capturedPush{header: r.Header.Clone(), body: body}
+		w.WriteHeader(e.status)
+	}))
+	t.Cleanup(e.server.Close)
+	return e
+}
+
+// browser holds the keys a user agent keeps for its subscription
+type browser struct {
+	private *ecdh.PrivateKey
+	auth    []byte
+}
+
+func newBrowser(t *testing.T) *browser {
+	t.Helper()
+	private, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	auth := make([]byte, 16)
+	rand.Read(auth)
+	return &browser{private: private, auth: auth}
+}
+
+func (b *browser) subscription(endpoint string) Subscription {
+	return Subscription{
+		Endpoint: endpoint,
+		P256dh:   base64.RawURLEncoding.EncodeToString(b.private.PublicKey().Bytes()),
+		Auth:     base64.RawURLEncoding.EncodeToString(b.auth),
+	}
+}
+
+// decrypt reverses encrypt the way a user agent does
+func (b *browser) decrypt(t *testing.T, body []byte) []byte {
+	t.Helper()
+	salt := body[:16]
+	if rs := binary.BigEndian.Uint32(body[16:20]); rs != recordSize {
+		t.Fatalf("unexpected record size %d", rs)
+	}
+	keyLen := int(body[20])
+	asPublicBytes := body[21 : 21+keyLen]
+	ciphertext := body[21+keyLen:]
+
+	asPublic, err := ecdh.P256().NewPublicKey(asPublicBytes)
+	if err != nil {
+		t.Fatalf("sender key: %v", err)
+	}
+	shared, err := b.private.ECDH(asPublic)
+	if err != nil {
+		t.Fatalf("ecdh: %v", err)
+	}
+
+	keyInfo := append([]byte("WebPush: info\x00"), b.private.PublicKey().Bytes()...)
+	keyInfo = append(keyInfo, asPublicBytes...)
+	ikm, _ := hkdfExpand(b.auth, shared, keyInfo, 32)
+	cek, _ := hkdfExpand(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce, _ := hkdfExpand(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatalf("decrypt: %v", err)
+	}
+	if plaintext[len(plaintext)-1] != 0x02 {
+		t.Fatalf("missing last record delimiter")
+	}
+	return plaintext[:len(plaintext)-1]
+}
+
+func testVAPID(t *testing.T) VAPIDConfig {
+	t.Helper()
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate vapid key: %v", err)
+	}
+	return VAPIDConfig{
+		PublicKey:  base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+		PrivateKey: base64.RawURLEncoding.EncodeToString(key.Bytes()),
+		Subject:    "mailto:ops@orbit.app.br",
+	}
+}
+
+func TestWebPushSenderDelivers(t *testing.T) {
+	endpoint := newPushEndpoint(t, http.StatusCreated)
+	vapid := testVAPID(t)
+	sender, err := NewWebPushSender(vapid)
+	if err != nil {
+		t.Fatalf("new sender: %v", err)
+	}
+	b := newBrowser(t)
+
+	payload := []byte(`{"title":"Novo comentário"}`)
+	err = sender.Send(context.Background(), b.subscription(endpoint.server.URL+"/push/abc"), Message{
+		Payload: payload,
+		TTL:     time.Hour,
+		Topic:   "n123",
+	})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	got := <-endpoint.requests
+	if string(b.decrypt(t, got.body)) != string(payload) {
+		t.Fatalf("payload did not round-trip")
+	}
+	for header, want := range map[string]string{
+		"Content-Encoding": "aes128gcm",
+		"Ttl":              "3600",
+		"Topic":            "n123",
+	} {
+		if got.header.Get(header) != want {
+			t.Fatalf("%s = %q, want %q", header, got.header.Get(header), want)
+		}
+	}
+
+	auth := got.header.Get("Authorization")
+	token, key, ok := strings.Cut(strings.TrimPrefix(auth, "vapid t="), ", k=")
+	if !ok || key != vapid.PublicKey {
+		t.Fatalf("unexpected authorization %q", auth)
+	}
+	signingKey, _ := vapidSigningKey(vapid.PublicKey, vapid.PrivateKey)
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return &signingKey.PublicKey, nil
+	}, jwt.WithValidMethods([]string{"ES256"})); err != nil {
+		t.Fatalf("vapid token: %v", err)
+	}
+	if claims["aud"] != endpoint.server.URL || claims["sub"] != vapid.Subject {
+		t.Fatalf("unexpected claims %v", claims)
+	}
+}
+
+func TestWebPushSenderGoneSubscription(t *testing.T) {
+	endpoint := newPushEndpoint(t, http.StatusGone)
+	sender, err := NewWebPushSender(testVAPID(t))
+	if err != nil {
+		t.Fatalf("new sender: %v", err)
+	}
+
+	err = sender.Send(context.Background(), newBrowser(t).subscription(endpoint.server.URL), Message{Payload: []byte("{}")})
+	if !errors.Is(err, ErrSubscriptionGone) || !IsPermanent(err) {
+		t.Fatalf("expected gone subscription, got %v", err)
+	}
+}
+
+func TestWebPushSenderRejectsOversizedPayload(t *testing.T) {
+	sender, err := NewWebPushSender(testVAPID(t))
+	if err != nil {
+		t.Fatalf("new sender: %v", err)
+	}
+
+	payload := make([]byte, MaxPayloadSize+1)
+	err = sender.Send(context.Background(), newBrowser(t).subscription("https://push.example.com/x"), Message{Payload: payload})
+	if !errors.Is(err, ErrPayloadTooLarge) {
+		t.Fatalf("expected oversized payload to fail, got %v", err)
+	}
+}
+
+func TestNewWebPushSenderRejectsMismatchedKeys(t *testing.T) {
+	vapid := testVAPID(t)
+	vapid.PublicKey = testVAPID(t).PublicKey
+	if _, err := NewWebPushSender(vapid); err == nil {
+		t.Fatal("expected mismatched VAPID keys to fail")
+	}
+}
@@ -0,0 +1,242 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	recordSize = 4096
+	// A single aes128gcm record: 86 bytes of header, the 16 byte tag and the
+	// padding delimiter leave this much for the payload (RFC 8291 section 4)
+	MaxPayloadSize = recordSize - 86 - 16 - 1
+
+	vapidTokenTTL = 12 * time.Hour
+	defaultTTL    = 24 * time.Hour
+)
+
+// VAPIDConfig identifies this server to push services (RFC 8292)
+type VAPIDConfig struct {
+	PublicKey  string // Base64url uncompressed P-256 point, shared with browsers
+	PrivateKey string // Base64url 32 byte P-256 scalar
+	Subject    string // mailto: or https: contact for the push service operator
+}
+
+// WebPushSender encrypts payloads (RFC 8291) and posts them to the
+// subscription endpoint with a VAPID signature
+type WebPushSender struct {
+	client     *http.Client
+	signingKey *ecdsa.PrivateKey
+	publicKey  string
+	subject    string
+}
+
+// NewWebPushSender validates the VAPID key pair
+func NewWebPushSender(cfg VAPIDConfig) (*WebPushSender, error) {
+	signingKey, err := vapidSigningKey(cfg.PublicKey, cfg.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Subject == "" {
+		return nil, fmt.Errorf("VAPID subject is required")
+	}
+
+	return &WebPushSender{
+		client:     &http.Client{Timeout: 15 * time.Second},
+		signingKey: signingKey,
+		publicKey:  strings.TrimRight(cfg.PublicKey, "="),
+		subject:    cfg.Subject,
+	}, nil
+}
+
+// Send encrypts and delivers one message
+func (s *WebPushSender) Send(ctx context.Context, sub Subscription, msg Message) error {
+	body, err := encrypt(sub, msg.Payload)
+	if err != nil {
+		return err
+	}
+
+	endpoint, err := url.Parse(sub.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return fmt.Errorf("invalid push endpoint %q: %w", sub.Endpoint, ErrInvalidKeys)
+	}
+	token, err := s.vapidToken(endpoint.Scheme + "://" + endpoint.Host)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	ttl := msg.TTL
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(int(ttl.Seconds())))
+	req.Header.Set("Authorization", "vapid t="+token+", k="+s.publicKey)
+	if msg.Urgency != "" {
+		req.Header.Set("Urgency", msg.Urgency)
+	}
+	if msg.Topic != "" {
+		req.Header.Set("Topic", msg.Topic)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return &StatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(detail))}
+}
+
+// vapidToken signs the JWT that authorizes a push to the endpoint's origin
+func (s *WebPushSender) vapidToken(audience string) (string, error) {
+	claims := jwt.MapClaims{
+		"aud": audience,
+		"exp": time.Now().Add(vapidTokenTTL).Unix(),
+		"sub": s.subject,
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(s.signingKey)
+}
+
+// encrypt builds a single-record aes128gcm body for the subscription
+func encrypt(sub Subscription, payload []byte) ([]byte, error) {
+	if len(payload) > MaxPayloadSize {
+		return nil, ErrPayloadTooLarge
+	}
+
+	uaPublicBytes, err := decodeBase64(sub.P256dh)
+	if err != nil {
+		return nil, ErrInvalidKeys
+	}
+	authSecret, err := decodeBase64(sub.Auth)
+	if err != nil || len(authSecret) != 16 {
+		return nil, ErrInvalidKeys
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicBytes)
+	if err != nil {
+		return nil, ErrInvalidKeys
+	}
+
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	sharedSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, ErrInvalidKeys
+	}
+	asPublicBytes := asPrivate.PublicKey().Bytes()
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	keyInfo := append([]byte("WebPush: info\x00"), uaPublicBytes...)
+	keyInfo = append(keyInfo, asPublicBytes...)
+	ikm, err := hkdfExpand(authSecret, sharedSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdfExpand(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdfExpand(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// 0x02 marks the last (and only) record
+	plaintext := append(append([]byte{}, payload...), 0x02)
+
+	header := make([]byte, 0, 21+len(asPublicBytes))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(asPublicBytes)))
+	header = append(header, asPublicBytes...)
+
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+func hkdfExpand(salt, secret, info []byte, length int) ([]byte, error) {
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, info), out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// vapidSigningKey rebuilds the ECDSA key from the base64url key pair and
+// checks that both halves belong together
+func vapidSigningKey(publicKey, privateKey string) (*ecdsa.PrivateKey, error) {
+	privateBytes, err := decodeBase64(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	private, err := ecdh.P256().NewPrivateKey(privateBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+
+	publicBytes, err := decodeBase64(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID public key: %w", err)
+	}
+	if !bytes.Equal(private.PublicKey().Bytes(), publicBytes) {
+		return nil, fmt.Errorf("VAPID public key does not match the private key")
+	}
+
+	return &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(publicBytes[1:33]),
+			Y:     new(big.Int).SetBytes(publicBytes[33:65]),
+		},
+		D: new(big.Int).SetBytes(privateBytes),
+	}, nil
+}
+
+// decodeBase64 accepts the padded and unpadded base64url keys browsers and
+// key generators produce
+func decodeBase64(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
@@ -165,3 +165,10 @@ func (s *AnalyticsService) GetRecentMembers(ctx context.Context, tenantID uuid.U
 
 	return result, nil
 }
+
+// RollupDaily recomputes the daily stats of every active tenant from the
+// given number of days ago up to today
+func (s *AnalyticsService) RollupDaily(ctx context.Context, days int) (int64, error) {
+	since := time.Now().UTC().AddDate(0, 0, -days)
+	return s.db.RollupTenantDailyStats(ctx, since)
+}
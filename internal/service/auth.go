@@ -18,6 +18,7 @@ import (
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/nickkcj/orbit-backend/internal/cache"
 	"github.com/nickkcj/orbit-backend/internal/database"
 )
 
@@ -28,7 +29,11 @@ var (
 
 type AuthService struct {
 	db                 *database.Queries
+	cache              cache.Cache
 	jwtSecret          []byte
+	accessTokenTTL     time.Duration
+	refreshTokenTTL    time.Duration
+	verifyEmailURL     string
 	googleClientID     string
 	googleClientSecret string
 	googleRedirectURL  string
@@ -42,10 +47,24 @@ type GoogleOAuthConfig struct {
 	FrontendURL  string
 }
 
-func NewAuthService(db *database.Queries, jwtSecret string, googleConfig *GoogleOAuthConfig) *AuthService {
+func NewAuthService(db *database.Queries, jwtSecret string, googleConfig *GoogleOAuthConfig, sessionConfig *SessionConfig, emailConfig *EmailConfig, c cache.Cache) *AuthService {
 	svc := &AuthService{
-		db:        db,
-		jwtSecret: []byte(jwtSecret),
+		db:              db,
+		cache:           c,
+		jwtSecret:       []byte(jwtSecret),
+		accessTokenTTL:  defaultAccessTokenTTL,
+		refreshTokenTTL: defaultRefreshTokenTTL,
+	}
+	if sessionConfig != nil {
+		if sessionConfig.AccessTokenTTL > 0 {
+			svc.accessTokenTTL = sessionConfig.AccessTokenTTL
+		}
+		if sessionConfig.RefreshTokenTTL > 0 {
+			svc.refreshTokenTTL = sessionConfig.RefreshTokenTTL
+		}
+	}
+	if emailConfig != nil {
+		svc.verifyEmailURL = strings.TrimSuffix(emailConfig.FrontendURL, "/") + "/verify-email"
 	}
 	if googleConfig != nil {
 		svc.googleClientID = googleConfig.ClientID
@@ -63,17 +82,20 @@ type RegisterInput struct {
 }
 
 type AuthResponse struct {
-	Token string        `json:"token"`
-	User  database.User `json:"user"`
+	Token        string        `json:"token"`         // Short-lived access token
+	RefreshToken string        `json:"refresh_token"` // Single use; trade it at /auth/refresh
+	ExpiresAt    time.Time     `json:"expires_at"`    // When the access token expires
+	User         database.User `json:"user"`
 }
 
 type JWTClaims struct {
-	UserID uuid.UUID `json:"user_id"`
-	Email  string    `json:"email"`
+	UserID    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email"`
+	SessionID uuid.UUID `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-func (s *AuthService) Register(ctx context.Context, input RegisterInput) (*AuthResponse, error) {
+func (s *AuthService) Register(ctx context.Context, input RegisterInput, meta SessionMeta) (*AuthResponse, error) {
 	// Check if user exists
 	_, err := s.db.GetUserByEmail(ctx, input.Email)
 	if err == nil {
@@ -86,27 +108,25 @@ func (s *AuthService) Register(ctx context.Context, input RegisterInput) (*AuthR
 		return nil, err
 	}
 
-	// Create user
-	user, err := s.db.CreateUser(ctx, database.CreateUserParams{
-		Email:        input.Email,
-		PasswordHash: string(hashedPassword),
-		Name:         input.Name,
-		AvatarUrl:    sql.NullString{},
+	// Create user and queue the verification email
+	var user database.User
+	err = s.db.InTx(ctx, func(q *database.Queries) error {
+		user, err = q.CreateUser(ctx, database.CreateUserParams{
+			Email:        input.Email,
+			PasswordHash: string(hashedPassword),
+			Name:         input.Name,
+			AvatarUrl:    sql.NullString{},
+		})
+		if err != nil {
+			return err
+		}
+		return s.queueVerificationEmail(ctx, q, user)
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Generate token
-	token, err := s.generateToken(user)
-	if err != nil {
-		return nil, err
-	}
-
-	return &AuthResponse{
-		Token: token,
-		User:  user,
-	}, nil
+	return s.startSession(ctx, user, meta)
 }
 
 type LoginInput struct {
@@ -114,7 +134,7 @@ type LoginInput struct {
 	Password string
 }
 
-func (s *AuthService) Login(ctx context.Context, input LoginInput) (*AuthResponse, error) {
+func (s *AuthService) Login(ctx context.Context, input LoginInput, meta SessionMeta) (*AuthResponse, error) {
 	// Get user
 	user, err := s.db.GetUserByEmail(ctx, input.Email)
 	if err != nil {
@@ -131,31 +151,25 @@ func (s *AuthService) Login(ctx context.Context, input LoginInput) (*AuthRespons
 		return nil, ErrInvalidCredentials
 	}
 
-	// Generate token
-	token, err := s.generateToken(user)
-	if err != nil {
-		return nil, err
-	}
-
-	return &AuthResponse{
-		Token: token,
-		User:  user,
-	}, nil
+	return s.startSession(ctx, user, meta)
 }
 
-func (s *AuthService) generateToken(user database.User) (string, error) {
+func (s *AuthService) generateToken(user database.User, sessionID uuid.UUID) (string, time.Time, error) {
+	expiresAt := time.Now().Add(s.accessTokenTTL)
 	claims := JWTClaims{
-		UserID: user.ID,
-		Email:  user.Email,
+		UserID:    user.ID,
+		Email:     user.Email,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * 7 * time.Hour)), // 7 days
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Subject:   user.ID.String(),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.jwtSecret)
+	signed, err := token.SignedString(s.jwtSecret)
+	return signed, expiresAt, err
 }
 
 func (s *AuthService) ValidateToken(tokenString string) (*JWTClaims, error) {
@@ -290,17 +304,17 @@ func (s *AuthService) ExchangeGoogleCode(ctx context.Context, code string) (*Goo
 	return &userInfo, nil
 }
 
-// LoginOrRegisterWithGoogle creates or finds a user from Google OAuth and returns auth response
-func (s *AuthService) LoginOrRegisterWithGoogle(ctx context.Context, googleUser *GoogleUserInfo) (*AuthResponse, error) {
+// LoginOrRegisterWithGoogle creates or finds a user from Google OAuth and
+// returns a one-time code the frontend trades for tokens with ExchangeLoginCode
+func (s *AuthService) LoginOrRegisterWithGoogle(ctx context.Context, googleUser *GoogleUserInfo, meta SessionMeta) (string, error) {
 	// Try to find existing user by email
 	user, err := s.db.GetUserByEmail(ctx, googleUser.Email)
 	if err == nil {
-		// User exists - generate token and return
-		token, err := s.generateToken(user)
-		if err != nil {
-			return nil, err
+		// User exists - open a session and return
+		if err := s.markGoogleEmailVerified(ctx, &user, googleUser); err != nil {
+			return "", err
 		}
-		return &AuthResponse{Token: token, User: user}, nil
+		return s.startSessionWithCode(ctx, user, meta)
 	}
 
 	// User doesn't exist - create new user (no password for OAuth users)
@@ -311,15 +325,13 @@ func (s *AuthService) LoginOrRegisterWithGoogle(ctx context.Context, googleUser
 		AvatarUrl:    sql.NullString{String: googleUser.Picture, Valid: googleUser.Picture != ""},
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+		return "", fmt.Errorf("failed to create user: %w", err)
 	}
-
-	token, err := s.generateToken(user)
-	if err != nil {
-		return nil, err
+	if err := s.markGoogleEmailVerified(ctx, &user, googleUser); err != nil {
+		return "", err
 	}
 
-	return &AuthResponse{Token: token, User: user}, nil
+	return s.startSessionWithCode(ctx, user, meta)
 }
 
 // IsGoogleOAuthConfigured returns true if Google OAuth is configured
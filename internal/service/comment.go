@@ -6,14 +6,18 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/nickkcj/orbit-backend/internal/database"
+	"github.com/nickkcj/orbit-backend/internal/events"
+	"github.com/nickkcj/orbit-backend/internal/outbox"
+	"github.com/nickkcj/orbit-backend/internal/worker/tasks"
 )
 
 type CommentService struct {
-	db *database.Queries
+	db     *database.Queries
+	events *events.Bus
 }
 
-func NewCommentService(db *database.Queries) *CommentService {
-	return &CommentService{db: db}
+func NewCommentService(db *database.Queries, bus *events.Bus) *CommentService {
+	return &CommentService{db: db, events: bus}
 }
 
 type CreateCommentInput struct {
@@ -24,19 +28,105 @@ type CreateCommentInput struct {
 	Content  string
 }
 
+// Create stores a comment and, in the same transaction, queues a notification
+// for the post author (or the parent comment author for replies) and for the
+// members it mentions
 func (s *CommentService) Create(ctx context.Context, input CreateCommentInput) (database.Comment, error) {
 	parentID := uuid.NullUUID{}
 	if input.ParentID != nil {
 		parentID = uuid.NullUUID{UUID: *input.ParentID, Valid: true}
 	}
 
-	return s.db.CreateComment(ctx, database.CreateCommentParams{
-		TenantID: input.TenantID,
-		PostID:   input.PostID,
-		AuthorID: input.AuthorID,
-		ParentID: parentID,
-		Content:  input.Content,
+	authorName := ""
+	if author, err := s.db.GetUserByID(ctx, input.AuthorID); err == nil {
+		authorName = author.Name
+	}
+
+	var comment database.Comment
+	err := s.db.InTx(ctx, func(q *database.Queries) error {
+		var err error
+		comment, err = q.CreateComment(ctx, database.CreateCommentParams{
+			TenantID: input.TenantID,
+			PostID:   input.PostID,
+			AuthorID: input.AuthorID,
+			ParentID: parentID,
+			Content:  input.Content,
+		})
+		if err != nil {
+			return err
+		}
+
+		post, err := q.GetPostByID(ctx, comment.PostID)
+		if err != nil {
+			return err
+		}
+
+		payload, err := commentNotification(ctx, q, post, comment, authorName)
+		if err != nil {
+			return err
+		}
+		var notified []uuid.UUID
+		if payload != nil {
+			if err := outbox.Add(ctx, q, tasks.TypeSendNotification, payload); err != nil {
+				return err
+			}
+			notified = append(notified, payload.RecipientID)
+		}
+
+		return recordMentions(ctx, q, mentionSource{
+			Post:      post,
+			CommentID: &comment.ID,
+			AuthorID:  comment.AuthorID,
+			Content:   comment.Content,
+		}, notified...)
+	})
+	if err != nil {
+		return database.Comment{}, err
+	}
+
+	s.events.Publish(ctx, events.Event{
+		Type:     events.CommentCreated,
+		TenantID: comment.TenantID,
+		Payload: events.CommentPayload{
+			ID:         comment.ID,
+			PostID:     comment.PostID,
+			AuthorID:   comment.AuthorID,
+			AuthorName: authorName,
+			ParentID:   input.ParentID,
+			CreatedAt:  comment.CreatedAt,
+		},
 	})
+
+	return comment, nil
+}
+
+// commentNotification builds the notification for a new comment, or nil when
+// the author would only notify themselves
+func commentNotification(ctx context.Context, q *database.Queries, post database.Post, comment database.Comment, authorName string) (*tasks.NotificationPayload, error) {
+	payload := &tasks.NotificationPayload{
+		Type:        "comment",
+		TenantID:    comment.TenantID,
+		RecipientID: post.AuthorID,
+		PostID:      &comment.PostID,
+		PostTitle:   post.Title,
+		CommentID:   &comment.ID,
+		AuthorID:    &comment.AuthorID,
+		AuthorName:  authorName,
+	}
+
+	if comment.ParentID.Valid {
+		parent, err := q.GetCommentByID(ctx, comment.ParentID.UUID)
+		if err != nil {
+			return nil, err
+		}
+		payload.Type = "reply"
+		payload.RecipientID = parent.AuthorID
+	}
+
+	if payload.RecipientID == comment.AuthorID {
+		return nil, nil
+	}
+	return payload, nil
 }
 
 func (s *CommentService) GetByID(ctx context.Context, id uuid.UUID) (database.Comment, error) {
@@ -55,11 +145,31 @@ func (s *CommentService) ListReplies(ctx context.Context, parentID uuid.UUID) ([
 	return s.db.ListReplies(ctx, uuid.NullUUID{UUID: parentID, Valid: true})
 }
 
+// Update edits a comment and notifies members newly mentioned in it
 func (s *CommentService) Update(ctx context.Context, id uuid.UUID, content string) (database.Comment, error) {
-	return s.db.UpdateComment(ctx, database.UpdateCommentParams{
-		ID:      id,
-		Content: content,
+	var comment database.Comment
+	err := s.db.InTx(ctx, func(q *database.Queries) error {
+		var err error
+		comment, err = q.UpdateComment(ctx, database.UpdateCommentParams{
+			ID:      id,
+			Content: content,
+		})
+		if err != nil {
+			return err
+		}
+
+		post, err := q.GetPostByID(ctx, comment.PostID)
+		if err != nil {
+			return err
+		}
+		return recordMentions(ctx, q, mentionSource{
+			Post:      post,
+			CommentID: &comment.ID,
+			AuthorID:  comment.AuthorID,
+			Content:   comment.Content,
+		})
 	})
+	return comment, err
 }
 
 func (s *CommentService) Hide(ctx context.Context, id uuid.UUID) error {
@@ -67,5 +177,23 @@ func (s *CommentService) Hide(ctx context.Context, id uuid.UUID) error {
 }
 
 func (s *CommentService) Delete(ctx context.Context, id uuid.UUID) error {
-	return s.db.DeleteComment(ctx, id)
+	comment, err := s.db.GetCommentByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.DeleteComment(ctx, id); err != nil {
+		return err
+	}
+
+	s.events.Publish(ctx, events.Event{
+		Type:     events.CommentDeleted,
+		TenantID: comment.TenantID,
+		Payload: events.CommentDeletedPayload{
+			ID:     comment.ID,
+			PostID: comment.PostID,
+		},
+	})
+
+	return nil
 }
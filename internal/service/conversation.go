@@ -0,0 +1,410 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/nickkcj/orbit-backend/internal/database"
+	"github.com/nickkcj/orbit-backend/internal/events"
+)
+
+// maxConversationParticipants bounds the size of a group conversation
+const maxConversationParticipants = 50
+
+var (
+	ErrConversationNotFound  = errors.New("conversation not found")
+	ErrNoRecipients          = errors.New("a conversation needs at least one other member")
+	ErrTooManyParticipants   = errors.New("too many participants")
+	ErrRecipientUnavailable  = errors.New("recipient cannot receive messages")
+	ErrMessagingBlocked      = errors.New("messaging is blocked between these members")
+	ErrSenderInactive        = errors.New("your membership does not allow sending messages")
+	ErrCannotBlockSelf       = errors.New("cannot block yourself")
+	ErrDirectMessageNotFound = errors.New("message not found")
+)
+
+type ConversationService struct {
+	db     *database.Queries
+	events *events.Bus
+}
+
+func NewConversationService(db *database.Queries, bus *events.Bus) *ConversationService {
+	return &ConversationService{db: db, events: bus}
+}
+
+type StartConversationInput struct {
+	TenantID       uuid.UUID
+	CreatorID      uuid.UUID
+	ParticipantIDs []uuid.UUID // Other members; the creator is added automatically
+	Title          string      // Groups only
+}
+
+// Start opens a conversation with the given members. A single recipient
+// without a title reuses the existing 1:1 conversation between the pair.
+func (s *ConversationService) Start(ctx context.Context, input StartConversationInput) (database.Conversation, error) {
+	recipients := uniqueRecipients(input.CreatorID, input.ParticipantIDs)
+	if len(recipients) == 0 {
+		return database.Conversation{}, ErrNoRecipients
+	}
+	if len(recipients)+1 > maxConversationParticipants {
+		return database.Conversation{}, ErrTooManyParticipants
+	}
+
+	if err := s.checkActiveMember(ctx, input.TenantID, input.CreatorID); err != nil {
+		return database.Conversation{}, err
+	}
+	for _, recipientID := range recipients {
+		if err := s.checkCanReach(ctx, input.TenantID, input.CreatorID, recipientID); err != nil {
+			return database.Conversation{}, err
+		}
+	}
+
+	title := strings.TrimSpace(input.Title)
+	isGroup := len(recipients) > 1 || title != ""
+
+	params := database.CreateConversationParams{
+		TenantID:  input.TenantID,
+		IsGroup:   isGroup,
+		Title:     sql.NullString{String: title, Valid: title != ""},
+		CreatedBy: input.CreatorID,
+	}
+	if !isGroup {
+		key := sql.NullString{String: directKey(input.CreatorID, recipients[0]), Valid: true}
+		existing, err := s.db.GetDirectConversation(ctx, database.GetDirectConversationParams{
+			TenantID:  input.TenantID,
+			DirectKey: key,
+		})
+		if err == nil {
+			return existing, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return database.Conversation{}, err
+		}
+		params.DirectKey = key
+	}
+
+	var conversation database.Conversation
+	err := s.db.InTx(ctx, func(q *database.Queries) error {
+		var err error
+		conversation, err = q.CreateConversation(ctx, params)
+		if err != nil {
+			return err
+		}
+
+		for _, userID := range append([]uuid.UUID{input.CreatorID}, recipients...) {
+			if err := q.AddConversationParticipant(ctx, database.AddConversationParticipantParams{
+				ConversationID: conversation.ID,
+				UserID:         userID,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	// A concurrent Start created the same 1:1 conversation first
+	if params.DirectKey.Valid && isUniqueViolation(err) {
+		return s.db.GetDirectConversation(ctx, database.GetDirectConversationParams{
+			TenantID:  input.TenantID,
+			DirectKey: params.DirectKey,
+		})
+	}
+	if err != nil {
+		return database.Conversation{}, err
+	}
+
+	return conversation, nil
+}
+
+// Get returns a conversation the user takes part in
+func (s *ConversationService) Get(ctx context.Context, tenantID, userID, conversationID uuid.UUID) (database.Conversation, error) {
+	conversation, err := s.db.GetConversationByID(ctx, conversationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return conversation, ErrConversationNotFound
+		}
+		return conversation, err
+	}
+	if conversation.TenantID != tenantID {
+		return database.Conversation{}, ErrConversationNotFound
+	}
+
+	isParticipant, err := s.db.IsConversationParticipant(ctx, database.IsConversationParticipantParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+	})
+	if err != nil {
+		return database.Conversation{}, err
+	}
+	if !isParticipant {
+		return database.Conversation{}, ErrConversationNotFound
+	}
+	return conversation, nil
+}
+
+// List returns the user's conversations, most recently active first
+func (s *ConversationService) List(ctx context.Context, tenantID, userID uuid.UUID, limit, offset int32) ([]database.ListConversationsByUserRow, error) {
+	return s.db.ListConversationsByUser(ctx, database.ListConversationsByUserParams{
+		TenantID: tenantID,
+		UserID:   userID,
+		Limit:    limit,
+		Offset:   offset,
+	})
+}
+
+func (s *ConversationService) ListParticipants(ctx context.Context, conversationID uuid.UUID) ([]database.ListConversationParticipantsRow, error) {
+	return s.db.ListConversationParticipants(ctx, conversationID)
+}
+
+// Send stores a message and delivers it to every participant in real time
+func (s *ConversationService) Send(ctx context.Context, tenantID, senderID, conversationID uuid.UUID, content string) (database.ConversationMessage, error) {
+	content, err := normalizeChatContent(content)
+	if err != nil {
+		return database.ConversationMessage{}, err
+	}
+
+	// Every conversation requires a participant who is still an active member
+	conversation, err := s.Get(ctx, tenantID, senderID, conversationID)
+	if err != nil {
+		return database.ConversationMessage{}, err
+	}
+	if err := s.checkActiveMember(ctx, tenantID, senderID); err != nil {
+		return database.ConversationMessage{}, err
+	}
+
+	participants, err := s.db.ListConversationParticipants(ctx, conversationID)
+	if err != nil {
+		return database.ConversationMessage{}, err
+	}
+
+	// A 1:1 conversation closes when the other side blocks, leaves or is
+	// banned. A group stays open when members leave, but a block between the
+	// sender and anyone in it still applies, as it does when starting one.
+	for _, participant := range participants {
+		if participant.UserID == senderID {
+			continue
+		}
+		if !conversation.IsGroup {
+			err = s.checkCanReach(ctx, tenantID, senderID, participant.UserID)
+		} else {
+			err = s.checkNotBlocked(ctx, tenantID, senderID, participant.UserID)
+		}
+		if err != nil {
+			return database.ConversationMessage{}, err
+		}
+	}
+
+	message, err := s.db.CreateConversationMessage(ctx, database.CreateConversationMessageParams{
+		TenantID:       tenantID,
+		ConversationID: conversationID,
+		SenderID:       senderID,
+		Content:        content,
+	})
+	if err != nil {
+		return message, err
+	}
+
+	if err := s.db.TouchConversation(ctx, database.TouchConversationParams{
+		ID:            conversationID,
+		LastMessageAt: sql.NullTime{Time: message.CreatedAt, Valid: true},
+	}); err != nil {
+		return message, err
+	}
+
+	senderName := ""
+	for _, participant := range participants {
+		if participant.UserID == senderID {
+			senderName = participant.Name
+		}
+	}
+
+	payload := events.DirectMessagePayload{
+		ID:             message.ID,
+		ConversationID: conversationID,
+		SenderID:       senderID,
+		SenderName:     senderName,
+		Content:        message.Content,
+		CreatedAt:      message.CreatedAt,
+	}
+	for _, participant := range participants {
+		userID := participant.UserID
+		s.events.Publish(ctx, events.Event{
+			Type:     events.DirectMessageCreated,
+			TenantID: tenantID,
+			UserID:   &userID,
+			Payload:  payload,
+		})
+	}
+
+	return message, nil
+}
+
+// ListMessages returns the most recent messages, newest first. When before is
+// set, only messages older than that message are returned.
+func (s *ConversationService) ListMessages(ctx context.Context, tenantID, userID, conversationID uuid.UUID, before *uuid.UUID, limit int32) ([]database.ListConversationMessagesRow, error) {
+	if _, err := s.Get(ctx, tenantID, userID, conversationID); err != nil {
+		return nil, err
+	}
+
+	if before == nil {
+		return s.db.ListConversationMessages(ctx, database.ListConversationMessagesParams{
+			ConversationID: conversationID,
+			Limit:          limit,
+		})
+	}
+
+	cursor, err := s.db.GetConversationMessageByID(ctx, *before)
+	if err != nil || cursor.ConversationID != conversationID {
+		return nil, ErrDirectMessageNotFound
+	}
+
+	rows, err := s.db.ListConversationMessagesBefore(ctx, database.ListConversationMessagesBeforeParams{
+		ConversationID: conversationID,
+		CreatedAt:      cursor.CreatedAt,
+		Limit:          limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]database.ListConversationMessagesRow, len(rows))
+	for i, row := range rows {
+		messages[i] = database.ListConversationMessagesRow(row)
+	}
+	return messages, nil
+}
+
+// MarkRead marks every message in the conversation as read by the user
+func (s *ConversationService) MarkRead(ctx context.Context, tenantID, userID, conversationID uuid.UUID) error {
+	if _, err := s.Get(ctx, tenantID, userID, conversationID); err != nil {
+		return err
+	}
+
+	if err := s.db.MarkConversationRead(ctx, database.MarkConversationReadParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+	}); err != nil {
+		return err
+	}
+
+	unread, err := s.CountUnread(ctx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+
+	s.events.Publish(ctx, events.Event{
+		Type:     events.ConversationRead,
+		TenantID: tenantID,
+		UserID:   &userID,
+		Payload:  events.ConversationReadPayload{ConversationID: conversationID, UnreadCount: unread},
+	})
+	return nil
+}
+
+// CountUnread returns the user's unread messages across all conversations
+func (s *ConversationService) CountUnread(ctx context.Context, tenantID, userID uuid.UUID) (int64, error) {
+	return s.db.CountUnreadConversationMessages(ctx, database.CountUnreadConversationMessagesParams{
+		TenantID: tenantID,
+		UserID:   userID,
+	})
+}
+
+// Block stops two members from messaging each other directly or starting
+// conversations that include the other
+func (s *ConversationService) Block(ctx context.Context, tenantID, blockerID, blockedID uuid.UUID) error {
+	if blockerID == blockedID {
+		return ErrCannotBlockSelf
+	}
+	return s.db.BlockMember(ctx, database.BlockMemberParams{
+		TenantID:  tenantID,
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+	})
+}
+
+func (s *ConversationService) Unblock(ctx context.Context, tenantID, blockerID, blockedID uuid.UUID) error {
+	return s.db.UnblockMember(ctx, database.UnblockMemberParams{
+		TenantID:  tenantID,
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+	})
+}
+
+// checkActiveMember rejects senders who are not active members of the tenant
+func (s *ConversationService) checkActiveMember(ctx context.Context, tenantID, userID uuid.UUID) error {
+	member, err := s.db.GetMember(ctx, database.GetMemberParams{TenantID: tenantID, UserID: userID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSenderInactive
+		}
+		return err
+	}
+	if member.Status != "active" {
+		return ErrSenderInactive
+	}
+	return nil
+}
+
+// checkCanReach verifies the recipient is an active member and neither side
+// has blocked the other
+func (s *ConversationService) checkCanReach(ctx context.Context, tenantID, senderID, recipientID uuid.UUID) error {
+	member, err := s.db.GetMember(ctx, database.GetMemberParams{TenantID: tenantID, UserID: recipientID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrRecipientUnavailable
+		}
+		return err
+	}
+	if member.Status != "active" {
+		return ErrRecipientUnavailable
+	}
+	return s.checkNotBlocked(ctx, tenantID, senderID, recipientID)
+}
+
+// checkNotBlocked rejects messaging between two members when either has
+// blocked the other
+func (s *ConversationService) checkNotBlocked(ctx context.Context, tenantID, senderID, recipientID uuid.UUID) error {
+	blocked, err := s.db.IsBlockedBetween(ctx, database.IsBlockedBetweenParams{
+		TenantID:  tenantID,
+		BlockerID: senderID,
+		BlockedID: recipientID,
+	})
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrMessagingBlocked
+	}
+	return nil
+}
+
+// uniqueRecipients drops duplicates and the creator from the participant list
+func uniqueRecipients(creatorID uuid.UUID, participantIDs []uuid.UUID) []uuid.UUID {
+	seen := map[uuid.UUID]struct{}{creatorID: {}}
+	recipients := make([]uuid.UUID, 0, len(participantIDs))
+	for _, id := range participantIDs {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		recipients = append(recipients, id)
+	}
+	return recipients
+}
+
+// directKey identifies the 1:1 conversation of a pair regardless of who started it
+func directKey(a, b uuid.UUID) string {
+	ids := []string{a.String(), b.String()}
+	sort.Strings(ids)
+	return ids[0] + ":" + ids[1]
+}
+
+// isUniqueViolation reports whether err is Postgres rejecting a duplicate key
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,343 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nickkcj/orbit-backend/internal/database"
+	"github.com/nickkcj/orbit-backend/internal/events"
+)
+
+func TestStartReusesDirectConversation(t *testing.T) {
+	svc, store, _ := newTestConversationService(t)
+	tenantID := uuid.New()
+	alice := store.addMember(tenantID, "active")
+	bob := store.addMember(tenantID, "active")
+
+	first, err := svc.Start(context.Background(), StartConversationInput{TenantID: tenantID, CreatorID: alice, ParticipantIDs: []uuid.UUID{bob}})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if first.IsGroup {
+		t.Fatalf("conversation between two members should be direct")
+	}
+
+	// Either side starting again lands in the same conversation
+	second, err := svc.Start(context.Background(), StartConversationInput{TenantID: tenantID, CreatorID: bob, ParticipantIDs: []uuid.UUID{alice, bob}})
+	if err != nil {
+		t.Fatalf("start again: %v", err)
+	}
+	if second.ID != first.ID {
+		t.Fatalf("expected conversation %s to be reused, got %s", first.ID, second.ID)
+	}
+
+	if _, err := svc.Start(context.Background(), StartConversationInput{TenantID: tenantID, CreatorID: alice, ParticipantIDs: []uuid.UUID{alice}}); !errors.Is(err, ErrNoRecipients) {
+		t.Fatalf("expected ErrNoRecipients, got %v", err)
+	}
+}
+
+func TestSendDeliversToEveryParticipant(t *testing.T) {
+	svc, store, delivered := newTestConversationService(t)
+	tenantID := uuid.New()
+	alice := store.addMember(tenantID, "active")
+	bob := store.addMember(tenantID, "active")
+	carol := store.addMember(tenantID, "active")
+
+	group, err := svc.Start(context.Background(), StartConversationInput{TenantID: tenantID, CreatorID: alice, ParticipantIDs: []uuid.UUID{bob, carol}})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	message, err := svc.Send(context.Background(), tenantID, alice, group.ID, "  oi  ")
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if message.Content != "oi" {
+		t.Fatalf("expected trimmed content, got %q", message.Content)
+	}
+
+	recipients := delivered()
+	for _, userID := range []uuid.UUID{alice, bob, carol} {
+		if !recipients[userID] {
+			t.Fatalf("participant %s did not receive the message", userID)
+		}
+	}
+
+	// Members outside the conversation cannot post into it
+	dave := store.addMember(tenantID, "active")
+	if _, err := svc.Send(context.Background(), tenantID, dave, group.ID, "oi"); !errors.Is(err, ErrConversationNotFound) {
+		t.Fatalf("expected ErrConversationNotFound for an outsider, got %v", err)
+	}
+}
+
+func TestBlockClosesDirectConversation(t *testing.T) {
+	svc, store, delivered := newTestConversationService(t)
+	tenantID := uuid.New()
+	alice := store.addMember(tenantID, "active")
+	bob := store.addMember(tenantID, "active")
+
+	direct, err := svc.Start(context.Background(), StartConversationInput{TenantID: tenantID, CreatorID: alice, ParticipantIDs: []uuid.UUID{bob}})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	if err := svc.Block(context.Background(), tenantID, bob, bob); !errors.Is(err, ErrCannotBlockSelf) {
+		t.Fatalf("expected ErrCannotBlockSelf, got %v", err)
+	}
+	if err := svc.Block(context.Background(), tenantID, bob, alice); err != nil {
+		t.Fatalf("block: %v", err)
+	}
+
+	// The block applies in both directions
+	if _, err := svc.Send(context.Background(), tenantID, alice, direct.ID, "oi"); !errors.Is(err, ErrMessagingBlocked) {
+		t.Fatalf("expected ErrMessagingBlocked from the blocked side, got %v", err)
+	}
+	if _, err := svc.Send(context.Background(), tenantID, bob, direct.ID, "oi"); !errors.Is(err, ErrMessagingBlocked) {
+		t.Fatalf("expected ErrMessagingBlocked from the blocker, got %v", err)
+	}
+	if _, err := svc.Start(context.Background(), StartConversationInput{TenantID: tenantID, CreatorID: alice, ParticipantIDs: []uuid.UUID{bob}}); !errors.Is(err, ErrMessagingBlocked) {
+		t.Fatalf("expected Start to fail with ErrMessagingBlocked, got %v", err)
+	}
+	if len(delivered()) != 0 {
+		t.Fatalf("blocked message was delivered")
+	}
+
+	if err := svc.Unblock(context.Background(), tenantID, bob, alice); err != nil {
+		t.Fatalf("unblock: %v", err)
+	}
+	if _, err := svc.Send(context.Background(), tenantID, alice, direct.ID, "oi"); err != nil {
+		t.Fatalf("send after unblock: %v", err)
+	}
+}
+
+func TestBlockAppliesToGroupConversation(t *testing.T) {
+	svc, store, delivered := newTestConversationService(t)
+	tenantID := uuid.New()
+	alice := store.addMember(tenantID, "active")
+	bob := store.addMember(tenantID, "active")
+	carol := store.addMember(tenantID, "active")
+
+	group, err := svc.Start(context.Background(), StartConversationInput{TenantID: tenantID, CreatorID: alice, ParticipantIDs: []uuid.UUID{bob, carol}})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	if err := svc.Block(context.Background(), tenantID, carol, alice); err != nil {
+		t.Fatalf("block: %v", err)
+	}
+	if _, err := svc.Send(context.Background(), tenantID, alice, group.ID, "oi"); !errors.Is(err, ErrMessagingBlocked) {
+		t.Fatalf("expected ErrMessagingBlocked, got %v", err)
+	}
+	if len(delivered()) != 0 {
+		t.Fatalf("blocked message was delivered")
+	}
+
+	// A member who left does not close the group for the others
+	store.setStatus(tenantID, carol, "removed")
+	if _, err := svc.Send(context.Background(), tenantID, bob, group.ID, "oi"); err != nil {
+		t.Fatalf("send after a member left: %v", err)
+	}
+}
+
+func TestSendRequiresActiveSender(t *testing.T) {
+	for _, status := range []string{"banned", "suspended", ""} {
+		t.Run(fmt.Sprintf("status %q", status), func(t *testing.T) {
+			svc, store, delivered := newTestConversationService(t)
+			tenantID := uuid.New()
+			alice := store.addMember(tenantID, "active")
+			bob := store.addMember(tenantID, "active")
+			carol := store.addMember(tenantID, "active")
+
+			group, err := svc.Start(context.Background(), StartConversationInput{TenantID: tenantID, CreatorID: alice, ParticipantIDs: []uuid.UUID{bob, carol}})
+			if err != nil {
+				t.Fatalf("start: %v", err)
+			}
+
+			// An empty status stands for a member who was removed from the tenant
+			if status == "" {
+				store.removeMember(tenantID, alice)
+			} else {
+				store.setStatus(tenantID, alice, status)
+			}
+			if _, err := svc.Send(context.Background(), tenantID, alice, group.ID, "oi"); !errors.Is(err, ErrSenderInactive) {
+				t.Fatalf("expected ErrSenderInactive, got %v", err)
+			}
+			if len(delivered()) != 0 {
+				t.Fatalf("message from inactive sender was delivered")
+			}
+		})
+	}
+}
+
+// newTestConversationService returns the service, its store and a function
+// reporting which users received a DirectMessageCreated event
+func newTestConversationService(t *testing.T) (*ConversationService, *conversationStore, func() map[uuid.UUID]bool) {
+	t.Helper()
+	store := &conversationStore{
+		members:       make(map[[2]uuid.UUID]string),
+		blocks:        make(map[[3]uuid.UUID]bool),
+		conversations: make(map[uuid.UUID]database.Conversation),
+		participants:  make(map[uuid.UUID][]uuid.UUID),
+	}
+
+	var mu sync.Mutex
+	recipients := make(map[uuid.UUID]bool)
+	bus := events.NewBus()
+	bus.Subscribe(func(ctx context.Context, event events.Event) {
+		if event.Type != events.DirectMessageCreated {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		recipients[*event.UserID] = true
+	})
+	delivered := func() map[uuid.UUID]bool {
+		mu.Lock()
+		defer mu.Unlock()
+		seen := make(map[uuid.UUID]bool, len(recipients))
+		for userID := range recipients {
+			seen[userID] = true
+		}
+		return seen
+	}
+
+	return NewConversationService(openFakeDB(t, store), bus), store, delivered
+}
+
+// ============================================================================
+// In-memory database
+// ============================================================================
+
+// conversationStore fakes the member, block and conversation queries
+type conversationStore struct {
+	mu            sync.Mutex
+	members       map[[2]uuid.UUID]string // tenant, user -> status
+	blocks        map[[3]uuid.UUID]bool   // tenant, blocker, blocked
+	conversations map[uuid.UUID]database.Conversation
+	participants  map[uuid.UUID][]uuid.UUID
+}
+
+func (s *conversationStore) addMember(tenantID uuid.UUID, status string) uuid.UUID {
+	userID := uuid.New()
+	s.setStatus(tenantID, userID, status)
+	return userID
+}
+
+func (s *conversationStore) setStatus(tenantID, userID uuid.UUID, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.members[[2]uuid.UUID{tenantID, userID}] = status
+}
+
+func (s *conversationStore) removeMember(tenantID, userID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.members, [2]uuid.UUID{tenantID, userID})
+}
+
+func (s *conversationStore) exec(name string, args []driver.Value) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch name {
+	case "AddConversationParticipant":
+		id := uuid.MustParse(args[0].(string))
+		s.participants[id] = append(s.participants[id], uuid.MustParse(args[1].(string)))
+		return 1, nil
+	case "TouchConversation":
+		id := uuid.MustParse(args[0].(string))
+		conversation := s.conversations[id]
+		conversation.LastMessageAt = sql.NullTime{Time: args[1].(time.Time), Valid: true}
+		s.conversations[id] = conversation
+		return 1, nil
+	case "BlockMember":
+		s.blocks[uuidKey3(args)] = true
+		return 1, nil
+	case "UnblockMember":
+		delete(s.blocks, uuidKey3(args))
+		return 1, nil
+	}
+	return 0, fmt.Errorf("conversationstore: unsupported exec %s", name)
+}
+
+func (s *conversationStore) query(name string, args []driver.Value) ([][]driver.Value, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch name {
+	case "GetMember":
+		tenantID, userID := uuid.MustParse(args[0].(string)), uuid.MustParse(args[1].(string))
+		status, ok := s.members[[2]uuid.UUID{tenantID, userID}]
+		if !ok {
+			return nil, nil
+		}
+		now := time.Now()
+		return [][]driver.Value{{uuid.NewString(), tenantID.String(), userID.String(), uuid.NewString(), nil, nil, status, now, now, userID.String()}}, nil
+	case "IsBlockedBetween":
+		key := uuidKey3(args)
+		reverse := [3]uuid.UUID{key[0], key[2], key[1]}
+		return [][]driver.Value{{s.blocks[key] || s.blocks[reverse]}}, nil
+	case "GetDirectConversation":
+		for _, conversation := range s.conversations {
+			if conversation.TenantID.String() == args[0] && conversation.DirectKey.Valid && conversation.DirectKey.String == args[1] {
+				return [][]driver.Value{conversationRow(conversation)}, nil
+			}
+		}
+		return nil, nil
+	case "CreateConversation":
+		now := time.Now()
+		conversation := database.Conversation{
+			ID:        uuid.New(),
+			TenantID:  uuid.MustParse(args[0].(string)),
+			IsGroup:   args[1].(bool),
+			Title:     scanNullString(args[2]),
+			CreatedBy: uuid.MustParse(args[3].(string)),
+			DirectKey: scanNullString(args[4]),
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		s.conversations[conversation.ID] = conversation
+		return [][]driver.Value{conversationRow(conversation)}, nil
+	case "GetConversationByID":
+		if conversation, ok := s.conversations[uuid.MustParse(args[0].(string))]; ok {
+			return [][]driver.Value{conversationRow(conversation)}, nil
+		}
+		return nil, nil
+	case "IsConversationParticipant":
+		for _, userID := range s.participants[uuid.MustParse(args[0].(string))] {
+			if userID.String() == args[1] {
+				return [][]driver.Value{{true}}, nil
+			}
+		}
+		return [][]driver.Value{{false}}, nil
+	case "ListConversationParticipants":
+		var rows [][]driver.Value
+		for _, userID := range s.participants[uuid.MustParse(args[0].(string))] {
+			rows = append(rows, []driver.Value{userID.String(), "Test", nil, time.Now()})
+		}
+		return rows, nil
+	case "CreateConversationMessage":
+		return [][]driver.Value{{uuid.NewString(), args[0], args[1], args[2], args[3], time.Now()}}, nil
+	}
+	return nil, fmt.Errorf("conversationstore: unsupported query %s", name)
+}
+
+func conversationRow(c database.Conversation) []driver.Value {
+	return []driver.Value{
+		c.ID.String(), c.TenantID.String(), c.IsGroup, nullString(c.Title), c.CreatedBy.String(),
+		nullString(c.DirectKey), nullTime(c.LastMessageAt), c.CreatedAt, c.UpdatedAt,
+	}
+}
+
+// uuidKey3 reads the (tenant, blocker, blocked) arguments of the block queries
+func uuidKey3(args []driver.Value) [3]uuid.UUID {
+	return [3]uuid.UUID{uuid.MustParse(args[0].(string)), uuid.MustParse(args[1].(string)), uuid.MustParse(args[2].(string))}
+}
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nickkcj/orbit-backend/internal/database"
+	"github.com/nickkcj/orbit-backend/internal/outbox"
+	"github.com/nickkcj/orbit-backend/internal/worker/tasks"
+)
+
+var ErrInvalidDigestSettings = errors.New("digest needs frequency off, daily or weekly, an hour from 0 to 23, a weekday from 0 to 6 and a valid timezone")
+
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+
+	digestBatchSize     = 500
+	digestMaxUnread     = 5
+	digestMaxPosts      = 5
+	digestMaxCourses    = 3
+	defaultDigestZone   = "America/Sao_Paulo"
+	defaultDigestHour   = 8
+	defaultDigestWeekly = int(time.Monday)
+)
+
+// DigestSettings is when a member gets the activity digest of a community
+type DigestSettings struct {
+	Frequency string `json:"frequency"` // off, daily or weekly
+	Hour      int    `json:"hour"`      // Local hour it goes out
+	Weekday   int    `json:"weekday"`   // 0 = Sunday; weekly only
+	Timezone  string `json:"timezone"`  // IANA name, e.g. America/Sao_Paulo
+}
+
+// DueDigest is a member whose current digest period has not been sent
+type DueDigest struct {
+	TenantID uuid.UUID
+	UserID   uuid.UUID
+	Period   string
+}
+
+// DigestService compiles and schedules the daily/weekly activity emails
+type DigestService struct {
+	db            *database.Queries
+	notifications *NotificationService
+}
+
+func NewDigestService(db *database.Queries, notifications *NotificationService) *DigestService {
+	return &DigestService{db: db, notifications: notifications}
+}
+
+// Settings returns the member's digest schedule; digests are off until the
+// member turns them on
+func (s *DigestService) Settings(ctx context.Context, tenantID, userID uuid.UUID) (DigestSettings, error) {
+	row, err := s.db.GetDigestSettings(ctx, database.GetDigestSettingsParams{TenantID: tenantID, UserID: userID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return DigestSettings{
+			Frequency: DigestOff,
+			Hour:      defaultDigestHour,
+			Weekday:   defaultDigestWeekly,
+			Timezone:  defaultDigestZone,
+		}, nil
+	}
+	if err != nil {
+		return DigestSettings{}, err
+	}
+	return toDigestSettings(row), nil
+}
+
+// UpdateSettings stores the schedule. The period in progress counts as sent,
+// so the first digest goes out at the next scheduled time rather than now.
+func (s *DigestService) UpdateSettings(ctx context.Context, tenantID, userID uuid.UUID, settings DigestSettings) (DigestSettings, error) {
+	if settings.Timezone == "" {
+		settings.Timezone = defaultDigestZone
+	}
+	switch settings.Frequency {
+	case DigestOff, DigestDaily, DigestWeekly:
+	default:
+		return DigestSettings{}, ErrInvalidDigestSettings
+	}
+	if settings.Hour < 0 || settings.Hour > 23 || settings.Weekday < 0 || settings.Weekday > 6 {
+		return DigestSettings{}, ErrInvalidDigestSettings
+	}
+	if _, err := time.LoadLocation(settings.Timezone); err != nil {
+		return DigestSettings{}, ErrInvalidDigestSettings
+	}
+
+	period := digestPeriod(settings, time.Now())
+	row, err := s.db.UpsertDigestSettings(ctx, database.UpsertDigestSettingsParams{
+		TenantID:   tenantID,
+		UserID:     userID,
+		Frequency:  settings.Frequency,
+		Hour:       int32(settings.Hour),
+		Weekday:    int32(settings.Weekday),
+		Timezone:   settings.Timezone,
+		LastPeriod: sql.NullString{String: period, Valid: period != ""},
+	})
+	if err != nil {
+		return DigestSettings{}, err
+	}
+	return toDigestSettings(row), nil
+}
+
+// Due lists the members whose latest scheduled digest was not sent yet. A
+// period missed while the scheduler was down is still picked up later.
+func (s *DigestService) Due(ctx context.Context, now time.Time) ([]DueDigest, error) {
+	var due []DueDigest
+	var afterTenant, afterUser uuid.UUID
+	for {
+		rows, err := s.db.ListDigestSubscribers(ctx, database.ListDigestSubscribersParams{
+			AfterTenantID: afterTenant,
+			AfterUserID:   afterUser,
+			MaxResults:    digestBatchSize,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range rows {
+			period := digestPeriod(toDigestSettings(row), now)
+			if period != "" && period != row.LastPeriod.String {
+				due = append(due, DueDigest{TenantID: row.TenantID, UserID: row.UserID, Period: period})
+			}
+		}
+
+		if len(rows) < digestBatchSize {
+			return due, nil
+		}
+		afterTenant, afterUser = rows[len(rows)-1].TenantID, rows[len(rows)-1].UserID
+	}
+}
+
+// Send compiles the member's digest for the period and queues the email in
+// the transaction that claims the period, so each period is sent once. A
+// digest with nothing to report claims the period without an email.
+func (s *DigestService) Send(ctx context.Context, tenantID, userID uuid.UUID, period string) error {
+	row, err := s.db.GetDigestSettings(ctx, database.GetDigestSettingsParams{TenantID: tenantID, UserID: userID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	settings := toDigestSettings(row)
+	if settings.Frequency == DigestOff || row.LastPeriod.String == period {
+		return nil
+	}
+
+	since := time.Now().Add(-24 * time.Hour)
+	if settings.Frequency == DigestWeekly {
+		since = time.Now().Add(-7 * 24 * time.Hour)
+	}
+	if row.LastSentAt.Valid && row.LastSentAt.Time.After(since) {
+		since = row.LastSentAt.Time
+	}
+
+	payload, err := s.digestEmail(ctx, tenantID, userID, settings.Frequency, since)
+	if err != nil {
+		return err
+	}
+
+	return s.db.InTx(ctx, func(q *database.Queries) error {
+		claimed, err := q.ClaimDigestPeriod(ctx, database.ClaimDigestPeriodParams{
+			TenantID:   tenantID,
+			UserID:     userID,
+			LastPeriod: sql.NullString{String: period, Valid: true},
+		})
+		if err != nil || claimed == 0 || payload == nil {
+			return err
+		}
+		return outbox.Add(ctx, q, tasks.TypeSendEmail, payload)
+	})
+}
+
+// digestEmail builds the email, or nil when the member has nothing unread,
+// no new posts and no course in progress
+func (s *DigestService) digestEmail(ctx context.Context, tenantID, userID uuid.UUID, frequency string, since time.Time) (*tasks.EmailPayload, error) {
+	unread, err := s.db.ListUnreadNotifications(ctx, database.ListUnreadNotificationsParams{TenantID: tenantID, UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+	posts, err := s.db.ListDigestTopPosts(ctx, database.ListDigestTopPostsParams{
+		TenantID:    tenantID,
+		PublishedAt: sql.NullTime{Time: since, Valid: true},
+		AuthorID:    userID,
+		Limit:       digestMaxPosts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	courses, err := s.db.GetContinueLearningCourses(ctx, database.GetContinueLearningCoursesParams{
+		UserID:   userID,
+		TenantID: tenantID,
+		Limit:    digestMaxCourses,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(unread) == 0 && len(posts) == 0 && len(courses) == 0 {
+		return nil, nil
+	}
+
+	user, err := s.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	tenant, err := s.db.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	communityURL := "https://" + tenant.Slug + "." + s.notifications.links.BaseDomain
+
+	notifications := make([]map[string]interface{}, 0, digestMaxUnread)
+	for i, n := range unread {
+		if i == digestMaxUnread {
+			break
+		}
+		notifications = append(notifications, map[string]interface{}{
+			"title":   n.Title,
+			"message": n.Message.String,
+		})
+	}
+
+	topPosts := make([]map[string]interface{}, len(posts))
+	for i, p := range posts {
+		topPosts[i] = map[string]interface{}{
+			"title":    p.Title,
+			"author":   p.AuthorName,
+			"likes":    p.LikeCount,
+			"comments": p.CommentCount,
+			"url":      communityURL + "/posts/" + p.Slug,
+		}
+	}
+
+	continueLearning := make([]map[string]interface{}, len(courses))
+	for i, c := range courses {
+		continueLearning[i] = map[string]interface{}{
+			"title":    c.CourseTitle,
+			"lesson":   c.LessonTitle.String,
+			"progress": c.ProgressPercentage,
+			"url":      communityURL + "/courses/" + c.CourseSlug,
+		}
+	}
+
+	title := "Seu resumo diário"
+	if frequency == DigestWeekly {
+		title = "Seu resumo semanal"
+	}
+	unsubscribeURL := s.notifications.links.APIURL + "/api/v1/notifications/unsubscribe?token=" +
+		url.QueryEscape(s.notifications.UnsubscribeToken(tenantID, userID, NotificationTypeDigest))
+
+	return &tasks.EmailPayload{
+		TenantID: &tenantID,
+		To:       user.Email,
+		ToName:   user.Name,
+		Template: "digest",
+		Data: map[string]interface{}{
+			"title":             title,
+			"name":              user.Name,
+			"unread_count":      len(unread),
+			"notifications":     notifications,
+			"posts":             topPosts,
+			"courses":           continueLearning,
+			"url":               communityURL,
+			"notifications_url": communityURL + "/notifications",
+			"unsubscribe_url":   unsubscribeURL,
+		},
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + unsubscribeURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}, nil
+}
+
+// digestPeriod names the latest scheduled time at or before now, e.g.
+// "daily:2026-10-17" or "weekly:2026-10-12". It is empty for digests that
+// are off.
+func digestPeriod(settings DigestSettings, now time.Time) string {
+	if settings.Frequency != DigestDaily && settings.Frequency != DigestWeekly {
+		return ""
+	}
+	loc, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	local := now.In(loc)
+	slot := time.Date(local.Year(), local.Month(), local.Day(), settings.Hour, 0, 0, 0, loc)
+	if settings.Frequency == DigestDaily {
+		if slot.After(local) {
+			slot = slot.AddDate(0, 0, -1)
+		}
+		return DigestDaily + ":" + slot.Format("2006-01-02")
+	}
+
+	slot = slot.AddDate(0, 0, -((int(local.Weekday()) - settings.Weekday + 7) % 7))
+	if slot.After(local) {
+		slot = slot.AddDate(0, 0, -7)
+	}
+	return DigestWeekly + ":" + slot.Format("2006-01-02")
+}
+
+func toDigestSettings(row database.DigestSetting) DigestSettings {
+	return DigestSettings{
+		Frequency: row.Frequency,
+		Hour:      int(row.Hour),
+		Weekday:   int(row.Weekday),
+		Timezone:  row.Timezone,
+	}
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nickkcj/orbit-backend/internal/database"
+)
+
+func TestDigestPeriod(t *testing.T) {
+	// Friday 2026-10-16 10:30 in São Paulo
+	now := time.Date(2026, 10, 16, 13, 30, 0, 0, time.UTC)
+	cases := []struct {
+		name     string
+		settings DigestSettings
+		want     string
+	}{
+		{"off", DigestSettings{Frequency: DigestOff, Hour: 8, Timezone: "America/Sao_Paulo"}, ""},
+		{"daily, hour passed", DigestSettings{Frequency: DigestDaily, Hour: 8, Timezone: "America/Sao_Paulo"}, "daily:2026-10-16"},
+		{"daily, hour to come", DigestSettings{Frequency: DigestDaily, Hour: 11, Timezone: "America/Sao_Paulo"}, "daily:2026-10-15"},
+		{"weekly, earlier this week", DigestSettings{Frequency: DigestWeekly, Hour: 8, Weekday: int(time.Monday), Timezone: "America/Sao_Paulo"}, "weekly:2026-10-12"},
+		{"weekly, later today", DigestSettings{Frequency: DigestWeekly, Hour: 11, Weekday: int(time.Friday), Timezone: "America/Sao_Paulo"}, "weekly:2026-10-09"},
+		{"unknown timezone falls back to UTC", DigestSettings{Frequency: DigestDaily, Hour: 13, Timezone: "Nowhere/City"}, "daily:2026-10-16"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := digestPeriod(tc.settings, now); got != tc.want {
+				t.Fatalf("digestPeriod = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDueSkipsPeriodsAlreadySent(t *testing.T) {
+	svc, store := newTestDigestService(t)
+	now := time.Now()
+	sent := store.subscribe(DigestDaily)
+	pending := store.subscribe(DigestDaily)
+	store.mu.Lock()
+	store.settings[sent].LastPeriod = sql.NullString{String: digestPeriod(toDigestSettings(*store.settings[sent]), now), Valid: true}
+	store.mu.Unlock()
+
+	due, err := svc.Due(context.Background(), now)
+	if err != nil {
+		t.Fatalf("due: %v", err)
+	}
+	if len(due) != 1 || due[0].UserID != pending.UserID {
+		t.Fatalf("expected only the pending member due, got %+v", due)
+	}
+}
+
+func TestSendQueuesOneEmailPerPeriod(t *testing.T) {
+	svc, store := newTestDigestService(t)
+	member := store.subscribe(DigestDaily)
+	store.unread[member] = 2
+	period := digestPeriod(toDigestSettings(*store.settings[member]), time.Now())
+
+	for i := 0; i < 2; i++ {
+		if err := svc.Send(context.Background(), member.TenantID, member.UserID, period); err != nil {
+			t.Fatalf("send %d: %v", i, err)
+		}
+	}
+	if store.emails != 1 {
+		t.Fatalf("expected 1 digest email, got %d", store.emails)
+	}
+	if got := store.settings[member].LastPeriod.String; got != period {
+		t.Fatalf("period not claimed: %q", got)
+	}
+}
+
+func TestSendSkipsEmptyDigest(t *testing.T) {
+	svc, store := newTestDigestService(t)
+	member := store.subscribe(DigestWeekly)
+	period := digestPeriod(toDigestSettings(*store.settings[member]), time.Now())
+
+	if err := svc.Send(context.Background(), member.TenantID, member.UserID, period); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if store.emails != 0 {
+		t.Fatalf("empty digest was emailed")
+	}
+	// The period is still used up, so the next sweep does not retry it
+	if got := store.settings[member].LastPeriod.String; got != period {
+		t.Fatalf("empty period not claimed: %q", got)
+	}
+}
+
+func newTestDigestService(t *testing.T) (*DigestService, *digestStore) {
+	t.Helper()
+	store := &digestStore{
+		settings: make(map[digestMember]*database.DigestSetting),
+		unread:   make(map[digestMember]int),
+	}
+	db := openFakeDB(t, store)
+	return NewDigestService(db, NewNotificationService(db, nil, notificationLinks("test-secret", nil))), store
+}
+
+// ============================================================================
+// In-memory database
+// ============================================================================
+
+type digestMember struct {
+	TenantID uuid.UUID
+	UserID   uuid.UUID
+}
+
+// digestStore fakes digest settings, unread notifications and the outbox
+type digestStore struct {
+	mu       sync.Mutex
+	settings map[digestMember]*database.DigestSetting
+	unread   map[digestMember]int
+	emails   int // Digest emails queued in the outbox
+}
+
+func (s *digestStore) subscribe(frequency string) digestMember {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	member := digestMember{TenantID: uuid.New(), UserID: uuid.New()}
+	s.settings[member] = &database.DigestSetting{
+		TenantID:  member.TenantID,
+		UserID:    member.UserID,
+		Frequency: frequency,
+		Hour:      0,
+		Weekday:   int32(time.Sunday),
+		Timezone:  "UTC",
+		UpdatedAt: time.Now(),
+	}
+	return member
+}
+
+func (s *digestStore) exec(name string, args []driver.Value) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch name {
+	case "ClaimDigestPeriod":
+		setting, ok := s.settings[memberArgs(args)]
+		if !ok || setting.Frequency == DigestOff || setting.LastPeriod.String == args[2] {
+			return 0, nil
+		}
+		setting.LastPeriod = scanNullString(args[2])
+		setting.LastSentAt = sql.NullTime{Time: time.Now(), Valid: true}
+		return 1, nil
+	}
+	return 0, fmt.Errorf("digeststore: unsupported exec %s", name)
+}
+
+func (s *digestStore) query(name string, args []driver.Value) ([][]driver.Value, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+
+	switch name {
+	case "ListDigestSubscribers":
+		// One page is enough for these tests
+		var rows [][]driver.Value
+		for _, setting := range s.settings {
+			rows = append(rows, digestSettingRow(setting))
+		}
+		return rows, nil
+	case "GetDigestSettings":
+		if setting, ok := s.settings[memberArgs(args)]; ok {
+			return [][]driver.Value{digestSettingRow(setting)}, nil
+		}
+		return nil, nil
+	case "ListUnreadNotifications":
+		member := memberArgs(args)
+		var rows [][]driver.Value
+		for i := 0; i < s.unread[member]; i++ {
+			rows = append(rows, notificationRow(&database.Notification{
+				ID: uuid.New(), TenantID: member.TenantID, UserID: member.UserID, Type: "comment", Title: "Novo comentário", CreatedAt: now,
+			}))
+		}
+		return rows, nil
+	case "ListDigestTopPosts", "GetContinueLearningCourses":
+		return nil, nil
+	case "GetUserByID":
+		return [][]driver.Value{{args[0], "membro@example.com", "", "Membro", nil, nil, "active", now, now}}, nil
+	case "GetTenantByID":
+		return [][]driver.Value{{args[0], "teste", "Teste", nil, nil, nil, "active", "active", nil, nil, nil, now, now}}, nil
+	case "CreateOutboxMessage":
+		s.emails++
+		return [][]driver.Value{{uuid.NewString(), args[0], args[1], int64(0), nil, now, now, nil}}, nil
+	}
+	return nil, fmt.Errorf("digeststore: unsupported query %s", name)
+}
+
+// memberArgs reads the (tenant_id, user_id) arguments most digest queries start with
+func memberArgs(args []driver.Value) digestMember {
+	return digestMember{TenantID: uuid.MustParse(args[0].(string)), UserID: uuid.MustParse(args[1].(string))}
+}
+
+func digestSettingRow(s *database.DigestSetting) []driver.Value {
+	return []driver.Value{
+		s.TenantID.String(), s.UserID.String(), s.Frequency, int64(s.Hour), int64(s.Weekday), s.Timezone,
+		nullString(s.LastPeriod), nullTime(s.LastSentAt), s.UpdatedAt,
+	}
+}
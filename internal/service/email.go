@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/mail"
+
+	"github.com/google/uuid"
+	"github.com/nickkcj/orbit-backend/internal/database"
+	"github.com/nickkcj/orbit-backend/internal/email"
+)
+
+const (
+	defaultPrimaryColor = "#4f46e5"
+	defaultAccentColor  = "#4f46e5"
+)
+
+type EmailConfig struct {
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	From         string // "Name <address>"; the tenant name replaces Name
+	OutboxDir    string // Development sink used when SMTP is not configured
+	BaseDomain   string
+	APIURL       string // Public API base, for one-click unsubscribe links
+	FrontendURL  string // Web app base, for email verification links
+}
+
+type EmailService struct {
+	db         *database.Queries
+	transport  email.Transport
+	templates  *email.Templates
+	from       *mail.Address
+	baseDomain string
+}
+
+func NewEmailService(db *database.Queries, transport email.Transport, from, baseDomain string) (*EmailService, error) {
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email sender %q: %w", from, err)
+	}
+
+	templates, err := email.LoadTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	return &EmailService{
+		db:         db,
+		transport:  transport,
+		templates:  templates,
+		from:       sender,
+		baseDomain: baseDomain,
+	}, nil
+}
+
+// newEmailTransport picks SMTP when a host is configured, then the file sink,
+// and otherwise only logs outgoing mail
+func newEmailTransport(cfg *EmailConfig) email.Transport {
+	switch {
+	case cfg.SMTPHost != "":
+		log.Printf("Email transport: SMTP (%s:%d)", cfg.SMTPHost, cfg.SMTPPort)
+		return email.NewSMTPTransport(email.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+		})
+	case cfg.OutboxDir != "":
+		transport, err := email.NewFileTransport(cfg.OutboxDir)
+		if err == nil {
+			log.Printf("Email transport: files in %s", cfg.OutboxDir)
+			return transport
+		}
+		log.Printf("Warning: %v (emails will only be logged)", err)
+	}
+	return email.LogTransport{}
+}
+
+type SendEmailInput struct {
+	TenantID *uuid.UUID // Nil sends with the platform branding
+	To       string
+	ToName   string
+	Template string
+	Data     map[string]interface{}
+	Headers  map[string]string
+}
+
+// Send renders a template with the tenant's branding and delivers it
+func (s *EmailService) Send(ctx context.Context, input SendEmailInput) error {
+	brand, err := s.branding(ctx, input.TenantID)
+	if err != nil {
+		return err
+	}
+
+	rendered, err := s.templates.Render(input.Template, brand, input.Data)
+	if err != nil {
+		return err
+	}
+
+	from := mail.Address{Name: brand.Name, Address: s.from.Address}
+	return s.transport.Send(ctx, email.Message{
+		From:    from.String(),
+		To:      input.To,
+		ToName:  input.ToName,
+		Subject: rendered.Subject,
+		HTML:    rendered.HTML,
+		Text:    rendered.Text,
+		Headers: input.Headers,
+	})
+}
+
+// branding builds the email look from the tenant name, logo and theme
+func (s *EmailService) branding(ctx context.Context, tenantID *uuid.UUID) (email.Branding, error) {
+	brand := email.Branding{
+		Name:         s.from.Name,
+		URL:          "https://" + s.baseDomain,
+		PrimaryColor: defaultPrimaryColor,
+		AccentColor:  defaultAccentColor,
+	}
+	if brand.Name == "" {
+		brand.Name = "Orbit"
+	}
+	if tenantID == nil {
+		return brand, nil
+	}
+
+	tenant, err := s.db.GetTenantByID(ctx, *tenantID)
+	if err != nil {
+		return brand, fmt.Errorf("failed to load tenant %s: %w", *tenantID, err)
+	}
+
+	brand.Name = tenant.Name
+	brand.URL = "https://" + tenant.Slug + "." + s.baseDomain
+	if tenant.LogoUrl.Valid {
+		brand.LogoURL = tenant.LogoUrl.String
+	}
+
+	if tenant.Settings.Valid {
+		var settings TenantSettings
+		if err := json.Unmarshal(tenant.Settings.RawMessage, &settings); err == nil && settings.Theme != nil {
+			if settings.Theme.PrimaryColor != "" {
+				brand.PrimaryColor = settings.Theme.PrimaryColor
+			}
+			if settings.Theme.AccentColor != "" {
+				brand.AccentColor = settings.Theme.AccentColor
+			}
+		}
+	}
+
+	return brand, nil
+}
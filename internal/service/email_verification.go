@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nickkcj/orbit-backend/internal/database"
+	"github.com/nickkcj/orbit-backend/internal/outbox"
+	"github.com/nickkcj/orbit-backend/internal/worker/tasks"
+)
+
+var (
+	ErrEmailAlreadyVerified     = errors.New("email already verified")
+	ErrVerificationRecentlySent = errors.New("a verification email was sent recently; try again in a minute")
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification link")
+	ErrEmailNotVerified         = errors.New("verify your email to continue")
+)
+
+const (
+	emailVerificationTTL         = 24 * time.Hour
+	emailVerificationResendAfter = time.Minute
+)
+
+// SendVerificationEmail sends the user a new verification link. Older links
+// keep working until they expire.
+func (s *AuthService) SendVerificationEmail(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt.Valid {
+		return ErrEmailAlreadyVerified
+	}
+
+	recent, err := s.db.CountEmailVerificationTokensSince(ctx, database.CountEmailVerificationTokensSinceParams{
+		UserID:    userID,
+		CreatedAt: time.Now().Add(-emailVerificationResendAfter),
+	})
+	if err != nil {
+		return err
+	}
+	if recent > 0 {
+		return ErrVerificationRecentlySent
+	}
+
+	return s.db.InTx(ctx, func(q *database.Queries) error {
+		return s.queueVerificationEmail(ctx, q, user)
+	})
+}
+
+// VerifyEmail spends a verification link. The link only counts for the
+// address it was sent to, so it is void once the user changes email.
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) error {
+	return s.db.InTx(ctx, func(q *database.Queries) error {
+		used, err := q.UseEmailVerificationToken(ctx, hashToken(token))
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidVerificationToken
+		}
+		if err != nil {
+			return err
+		}
+
+		user, err := q.GetUserByID(ctx, used.UserID)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(user.Email, used.Email) {
+			return ErrInvalidVerificationToken
+		}
+		if user.EmailVerifiedAt.Valid {
+			return nil
+		}
+
+		return q.VerifyUserEmail(ctx, user.ID)
+	})
+}
+
+// queueVerificationEmail stores a new link and queues the email in the
+// caller's transaction
+func (s *AuthService) queueVerificationEmail(ctx context.Context, q *database.Queries, user database.User) error {
+	token, err := newOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	if err := q.CreateEmailVerificationToken(ctx, database.CreateEmailVerificationTokenParams{
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+		Email:     user.Email,
+		ExpiresAt: time.Now().Add(emailVerificationTTL),
+	}); err != nil {
+		return err
+	}
+
+	return outbox.Add(ctx, q, tasks.TypeSendEmail, &tasks.EmailPayload{
+		To:       user.Email,
+		ToName:   user.Name,
+		Template: "email_verification",
+		Data: map[string]interface{}{
+			"name":       user.Name,
+			"url":        s.verifyEmailURL + "?token=" + url.QueryEscape(token),
+			"expires_in": "24 horas",
+		},
+	})
+}
+
+// markGoogleEmailVerified trusts Google's verified_email for the account
+// with that address. Until then anyone could have registered the address,
+// so the account's password is cleared and its sessions are revoked: only
+// the Google owner keeps access, and can set a new password.
+func (s *AuthService) markGoogleEmailVerified(ctx context.Context, user *database.User, googleUser *GoogleUserInfo) error {
+	if !googleUser.VerifiedEmail || user.EmailVerifiedAt.Valid || !strings.EqualFold(user.Email, googleUser.Email) {
+		return nil
+	}
+
+	err := s.db.InTx(ctx, func(q *database.Queries) error {
+		if err := q.VerifyUserEmail(ctx, user.ID); err != nil {
+			return err
+		}
+		if user.PasswordHash != "" {
+			if err := q.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{ID: user.ID, PasswordHash: ""}); err != nil {
+				return err
+			}
+		}
+		_, err := q.RevokeUserSessions(ctx, database.RevokeUserSessionsParams{
+			Reason: sql.NullString{String: SessionRevokedEmailVerified, Valid: true},
+			UserID: user.ID,
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	s.forgetSessions(ctx, user.ID, uuid.Nil)
+
+	user.PasswordHash = ""
+	user.EmailVerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	return nil
+}
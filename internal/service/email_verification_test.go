@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGoogleVerificationLocksOutPreRegisteredAccount(t *testing.T) {
+	svc, store := newTestAuthService(t)
+	user := store.addUser("attacker-password-hash")
+	squatter := startTestSession(t, svc, user)
+
+	google := &GoogleUserInfo{Email: user.Email, VerifiedEmail: true}
+	if err := svc.markGoogleEmailVerified(context.Background(), &user, google); err != nil {
+		t.Fatalf("mark verified: %v", err)
+	}
+
+	stored := store.users[user.ID]
+	if !stored.EmailVerifiedAt.Valid {
+		t.Fatalf("email not marked verified")
+	}
+	if stored.PasswordHash != "" || user.PasswordHash != "" {
+		t.Fatalf("password of pre-registered account was kept")
+	}
+	if reason := store.revokedReason(squatter); reason != SessionRevokedEmailVerified {
+		t.Fatalf("expected existing session revoked, got %q", reason)
+	}
+
+	// The Google login that verified the address keeps working
+	owner := startTestSession(t, svc, user)
+	if reason := store.revokedReason(owner); reason != "" {
+		t.Fatalf("new session was revoked: %q", reason)
+	}
+
+	// Already verified accounts are left alone
+	if err := svc.markGoogleEmailVerified(context.Background(), &user, google); err != nil {
+		t.Fatalf("mark verified again: %v", err)
+	}
+	if reason := store.revokedReason(owner); reason != "" {
+		t.Fatalf("session of verified account was revoked: %q", reason)
+	}
+}
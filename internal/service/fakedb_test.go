@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/nickkcj/orbit-backend/internal/database"
+)
+
+// fakeStore answers sqlc queries by name; tests implement it over plain maps
+// and reach it through a database/sql driver so InTx still gets a *sql.DB
+type fakeStore interface {
+	exec(name string, args []driver.Value) (int64, error)
+	query(name string, args []driver.Value) ([][]driver.Value, error)
+}
+
+var (
+	testStores   sync.Map
+	registerOnce sync.Once
+)
+
+// openFakeDB returns Queries backed by store for the lifetime of the test
+func openFakeDB(t *testing.T, store fakeStore) *database.Queries {
+	t.Helper()
+	registerOnce.Do(func() { sql.Register("fakestore", storeDriver{}) })
+
+	dsn := uuid.NewString()
+	testStores.Store(dsn, store)
+
+	db, err := sql.Open("fakestore", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		testStores.Delete(dsn)
+	})
+	return database.New(db)
+}
+
+func nullTime(t sql.NullTime) driver.Value {
+	if !t.Valid {
+		return nil
+	}
+	return t.Time
+}
+
+func nullString(s sql.NullString) driver.Value {
+	if !s.Valid {
+		return nil
+	}
+	return s.String
+}
+
+// scanNullString reads a nullable string argument back into its sql type
+func scanNullString(v driver.Value) sql.NullString {
+	s, ok := v.(string)
+	return sql.NullString{String: s, Valid: ok}
+}
+
+// queryName returns X from the "-- name: X :kind" header sqlc puts on every query
+func queryName(query string) string {
+	fields := strings.Fields(strings.TrimPrefix(query, "-- name: "))
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+func namedValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
+
+type storeDriver struct{}
+
+func (storeDriver) Open(dsn string) (driver.Conn, error) {
+	store, ok := testStores.Load(dsn)
+	if !ok {
+		return nil, fmt.Errorf("fakestore: unknown store %s", dsn)
+	}
+	return &storeConn{store: store.(fakeStore)}, nil
+}
+
+// storeConn runs every statement directly against the store; transactions
+// are no-ops, which is enough for code that only rolls back on error
+type storeConn struct {
+	store fakeStore
+}
+
+func (c *storeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fakestore: prepared statements not supported")
+}
+
+func (c *storeConn) Close() error              { return nil }
+func (c *storeConn) Begin() (driver.Tx, error) { return storeTx{}, nil }
+
+func (c *storeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	affected, err := c.store.exec(queryName(query), namedValues(args))
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(affected), nil
+}
+
+func (c *storeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := c.store.query(queryName(query), namedValues(args))
+	if err != nil {
+		return nil, err
+	}
+	return &storeRows{rows: rows}, nil
+}
+
+type storeTx struct{}
+
+func (storeTx) Commit() error   { return nil }
+func (storeTx) Rollback() error { return nil }
+
+type storeRows struct {
+	rows [][]driver.Value
+}
+
+func (r *storeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	return make([]string, len(r.rows[0]))
+}
+
+func (r *storeRows) Close() error { return nil }
+
+func (r *storeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
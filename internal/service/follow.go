@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/nickkcj/orbit-backend/internal/database"
+)
+
+var (
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrAuthorNotFound   = errors.New("author not found")
+	ErrCannotFollowSelf = errors.New("cannot follow yourself")
+)
+
+// FollowService manages the categories and authors members follow to hear
+// about new posts
+type FollowService struct {
+	db *database.Queries
+}
+
+func NewFollowService(db *database.Queries) *FollowService {
+	return &FollowService{db: db}
+}
+
+func (s *FollowService) FollowCategory(ctx context.Context, tenantID, categoryID, userID uuid.UUID) error {
+	category, err := s.db.GetCategoryByID(ctx, categoryID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCategoryNotFound
+		}
+		return err
+	}
+	if category.TenantID != tenantID {
+		return ErrCategoryNotFound
+	}
+
+	return s.db.FollowCategory(ctx, database.FollowCategoryParams{
+		TenantID:   tenantID,
+		CategoryID: categoryID,
+		UserID:     userID,
+	})
+}
+
+func (s *FollowService) UnfollowCategory(ctx context.Context, categoryID, userID uuid.UUID) error {
+	return s.db.UnfollowCategory(ctx, database.UnfollowCategoryParams{
+		CategoryID: categoryID,
+		UserID:     userID,
+	})
+}
+
+func (s *FollowService) ListCategories(ctx context.Context, tenantID, userID uuid.UUID) ([]database.Category, error) {
+	return s.db.ListFollowedCategories(ctx, database.ListFollowedCategoriesParams{
+		TenantID: tenantID,
+		UserID:   userID,
+	})
+}
+
+func (s *FollowService) FollowAuthor(ctx context.Context, tenantID, authorID, followerID uuid.UUID) error {
+	if authorID == followerID {
+		return ErrCannotFollowSelf
+	}
+
+	_, err := s.db.GetMember(ctx, database.GetMemberParams{TenantID: tenantID, UserID: authorID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAuthorNotFound
+		}
+		return err
+	}
+
+	return s.db.FollowAuthor(ctx, database.FollowAuthorParams{
+		TenantID:   tenantID,
+		AuthorID:   authorID,
+		FollowerID: followerID,
+	})
+}
+
+func (s *FollowService) UnfollowAuthor(ctx context.Context, tenantID, authorID, followerID uuid.UUID) error {
+	return s.db.UnfollowAuthor(ctx, database.UnfollowAuthorParams{
+		TenantID:   tenantID,
+		AuthorID:   authorID,
+		FollowerID: followerID,
+	})
+}
+
+func (s *FollowService) ListAuthors(ctx context.Context, tenantID, userID uuid.UUID) ([]database.ListFollowedAuthorsRow, error) {
+	return s.db.ListFollowedAuthors(ctx, database.ListFollowedAuthorsParams{
+		TenantID:   tenantID,
+		FollowerID: userID,
+	})
+}
+
+// NewPostRecipients returns up to limit followers of the post's category or
+// author with a user ID greater than after; pass uuid.Nil for the first page
+func (s *FollowService) NewPostRecipients(ctx context.Context, post database.Post, after uuid.UUID, limit int) ([]uuid.UUID, error) {
+	return s.db.ListNewPostFollowers(ctx, database.ListNewPostFollowersParams{
+		TenantID:    post.TenantID,
+		AuthorID:    post.AuthorID,
+		AfterUserID: after,
+		CategoryID:  post.CategoryID,
+		MaxResults:  int32(limit),
+	})
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/nickkcj/orbit-backend/internal/database"
+	"github.com/nickkcj/orbit-backend/internal/events"
+)
+
+// maxChatMessageLength bounds a single lesson chat message
+const maxChatMessageLength = 2000
+
+var (
+	ErrChatMessageNotFound = errors.New("chat message not found")
+	ErrChatMessageEmpty    = errors.New("chat message cannot be empty")
+	ErrChatMessageTooLong  = errors.New("chat message is too long")
+	ErrChatNotAuthor       = errors.New("only the author can change this message")
+)
+
+type LessonChatService struct {
+	db         *database.Queries
+	events     *events.Bus
+	enrollment *EnrollmentService
+}
+
+func NewLessonChatService(db *database.Queries, bus *events.Bus, enrollment *EnrollmentService) *LessonChatService {
+	return &LessonChatService{db: db, events: bus, enrollment: enrollment}
+}
+
+type SendChatMessageInput struct {
+	TenantID       uuid.UUID
+	LessonID       uuid.UUID
+	AuthorID       uuid.UUID
+	Content        string
+	VideoTimestamp *int32 // Seconds into the lesson video, if any
+}
+
+// CanAccess checks that the lesson belongs to the tenant and that the user is
+// enrolled in its course or the lesson is a free preview
+func (s *LessonChatService) CanAccess(ctx context.Context, tenantID, userID, lessonID uuid.UUID) (bool, error) {
+	lesson, err := s.db.GetLessonByID(ctx, lessonID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, ErrLessonNotFound
+		}
+		return false, err
+	}
+	if lesson.TenantID != tenantID {
+		return false, ErrLessonNotFound
+	}
+
+	module, err := s.db.GetModuleByID(ctx, lesson.ModuleID)
+	if err != nil {
+		return false, err
+	}
+
+	return s.enrollment.CanAccessLesson(ctx, userID, module.CourseID, lessonID)
+}
+
+// Send stores a message in the lesson chat and broadcasts it to the lesson topic
+func (s *LessonChatService) Send(ctx context.Context, input SendChatMessageInput) (database.LessonChatMessage, error) {
+	content, err := normalizeChatContent(input.Content)
+	if err != nil {
+		return database.LessonChatMessage{}, err
+	}
+
+	if err := s.checkVerifiedEmail(ctx, input.TenantID, input.AuthorID); err != nil {
+		return database.LessonChatMessage{}, err
+	}
+
+	canAccess, err := s.CanAccess(ctx, input.TenantID, input.AuthorID, input.LessonID)
+	if err != nil {
+		return database.LessonChatMessage{}, err
+	}
+	if !canAccess {
+		return database.LessonChatMessage{}, ErrAccessDenied
+	}
+
+	timestamp := sql.NullInt32{}
+	if input.VideoTimestamp != nil && *input.VideoTimestamp >= 0 {
+		timestamp = sql.NullInt32{Int32: *input.VideoTimestamp, Valid: true}
+	}
+
+	message, err := s.db.CreateLessonChatMessage(ctx, database.CreateLessonChatMessageParams{
+		TenantID:              input.TenantID,
+		LessonID:              input.LessonID,
+		AuthorID:              input.AuthorID,
+		Content:               content,
+		VideoTimestampSeconds: timestamp,
+	})
+	if err != nil {
+		return message, err
+	}
+
+	s.publishMessage(ctx, events.LessonChatCreated, message)
+	return message, nil
+}
+
+// Edit changes the content of a message. Only its author may edit it.
+func (s *LessonChatService) Edit(ctx context.Context, tenantID, userID, messageID uuid.UUID, content string) (database.LessonChatMessage, error) {
+	content, err := normalizeChatContent(content)
+	if err != nil {
+		return database.LessonChatMessage{}, err
+	}
+
+	existing, err := s.getVisible(ctx, tenantID, messageID)
+	if err != nil {
+		return existing, err
+	}
+	if existing.AuthorID != userID {
+		return database.LessonChatMessage{}, ErrChatNotAuthor
+	}
+
+	message, err := s.db.UpdateLessonChatMessage(ctx, database.UpdateLessonChatMessageParams{
+		ID:      messageID,
+		Content: content,
+	})
+	if err != nil {
+		return message, err
+	}
+
+	s.publishMessage(ctx, events.LessonChatUpdated, message)
+	return message, nil
+}
+
+// Delete removes a message. Authors may delete their own messages; moderators
+// may delete any message in the tenant.
+func (s *LessonChatService) Delete(ctx context.Context, tenantID, userID, messageID uuid.UUID, canModerate bool) error {
+	existing, err := s.getVisible(ctx, tenantID, messageID)
+	if err != nil {
+		return err
+	}
+	if existing.AuthorID != userID && !canModerate {
+		return ErrChatNotAuthor
+	}
+
+	if err := s.db.DeleteLessonChatMessage(ctx, database.DeleteLessonChatMessageParams{
+		ID:        messageID,
+		DeletedBy: uuid.NullUUID{UUID: userID, Valid: true},
+	}); err != nil {
+		return err
+	}
+
+	s.events.Publish(ctx, events.Event{
+		Type:     events.LessonChatDeleted,
+		TenantID: tenantID,
+		Payload:  events.LessonChatDeletedPayload{ID: messageID, LessonID: existing.LessonID},
+	})
+	return nil
+}
+
+// ListHistory returns the most recent messages, newest first. When before is
+// set, only messages older than that message are returned.
+func (s *LessonChatService) ListHistory(ctx context.Context, tenantID, lessonID uuid.UUID, before *uuid.UUID, limit int32) ([]database.ListLessonChatMessagesRow, error) {
+	if before == nil {
+		return s.db.ListLessonChatMessages(ctx, database.ListLessonChatMessagesParams{
+			LessonID: lessonID,
+			Limit:    limit,
+		})
+	}
+
+	cursor, err := s.db.GetLessonChatMessageByID(ctx, *before)
+	if err != nil || cursor.TenantID != tenantID || cursor.LessonID != lessonID {
+		return nil, ErrChatMessageNotFound
+	}
+
+	rows, err := s.db.ListLessonChatMessagesBefore(ctx, database.ListLessonChatMessagesBeforeParams{
+		LessonID:  lessonID,
+		CreatedAt: cursor.CreatedAt,
+		Limit:     limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]database.ListLessonChatMessagesRow, len(rows))
+	for i, row := range rows {
+		history[i] = database.ListLessonChatMessagesRow(row)
+	}
+	return history, nil
+}
+
+// getVisible loads a message that has not been deleted, scoped to the tenant
+func (s *LessonChatService) getVisible(ctx context.Context, tenantID, messageID uuid.UUID) (database.LessonChatMessage, error) {
+	message, err := s.db.GetLessonChatMessageByID(ctx, messageID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return message, ErrChatMessageNotFound
+		}
+		return message, err
+	}
+	if message.TenantID != tenantID || message.Status != "visible" {
+		return database.LessonChatMessage{}, ErrChatMessageNotFound
+	}
+	return message, nil
+}
+
+// publishMessage broadcasts a created or edited message with its author name
+func (s *LessonChatService) publishMessage(ctx context.Context, eventType events.Type, message database.LessonChatMessage) {
+	authorName := ""
+	if author, err := s.db.GetUserByID(ctx, message.AuthorID); err == nil {
+		authorName = author.Name
+	}
+
+	payload := events.LessonChatPayload{
+		ID:         message.ID,
+		LessonID:   message.LessonID,
+		AuthorID:   message.AuthorID,
+		AuthorName: authorName,
+		Content:    message.Content,
+		CreatedAt:  message.CreatedAt,
+	}
+	if message.VideoTimestampSeconds.Valid {
+		payload.VideoTimestamp = &message.VideoTimestampSeconds.Int32
+	}
+	if message.EditedAt.Valid {
+		payload.EditedAt = &message.EditedAt.Time
+	}
+
+	s.events.Publish(ctx, events.Event{
+		Type:     eventType,
+		TenantID: message.TenantID,
+		Payload:  payload,
+	})
+}
+
+// checkVerifiedEmail applies the tenant's RequireVerifiedEmail setting, which
+// the HTTP routes enforce in middleware but WebSocket messages bypass
+func (s *LessonChatService) checkVerifiedEmail(ctx context.Context, tenantID, userID uuid.UUID) error {
+	user, err := s.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt.Valid {
+		return nil
+	}
+
+	tenant, err := s.db.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if ParseTenantSettings(tenant).RequireVerifiedEmail {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+// normalizeChatContent trims and validates message content
+func normalizeChatContent(content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", ErrChatMessageEmpty
+	}
+	if len([]rune(content)) > maxChatMessageLength {
+		return "", ErrChatMessageTooLong
+	}
+	return content, nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestChatSendRequiresVerifiedEmail(t *testing.T) {
+	cases := []struct {
+		name     string
+		settings string
+		verified bool
+		want     error
+	}{
+		{"required and unverified", `{"requireVerifiedEmail":true}`, false, ErrEmailNotVerified},
+		{"required and verified", `{"requireVerifiedEmail":true}`, true, ErrLessonNotFound},
+		{"not required", `{}`, false, ErrLessonNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &chatStore{tenantID: uuid.New(), userID: uuid.New(), settings: tc.settings, verified: tc.verified}
+			svc := NewLessonChatService(openFakeDB(t, store), nil, nil)
+
+			// The store knows no lessons, so passing the email check ends at the lesson lookup
+			_, err := svc.Send(context.Background(), SendChatMessageInput{
+				TenantID: store.tenantID,
+				LessonID: uuid.New(),
+				AuthorID: store.userID,
+				Content:  "oi",
+			})
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+// chatStore fakes a single tenant and user
+type chatStore struct {
+	tenantID uuid.UUID
+	userID   uuid.UUID
+	settings string
+	verified bool
+}
+
+func (s *chatStore) exec(name string, args []driver.Value) (int64, error) {
+	return 0, fmt.Errorf("chatstore: unsupported exec %s", name)
+}
+
+func (s *chatStore) query(name string, args []driver.Value) ([][]driver.Value, error) {
+	now := time.Now()
+	switch name {
+	case "GetUserByID":
+		var verifiedAt driver.Value
+		if s.verified {
+			verifiedAt = now
+		}
+		return [][]driver.Value{{s.userID.String(), "test@example.com", "", "Test", nil, verifiedAt, "active", now, now}}, nil
+	case "GetTenantByID":
+		return [][]driver.Value{{s.tenantID.String(), "test", "Test", nil, nil, []byte(s.settings), "active", "active", nil, nil, nil, now, now}}, nil
+	case "GetLessonByID":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("chatstore: unsupported query %s", name)
+}
@@ -6,6 +6,9 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/nickkcj/orbit-backend/internal/database"
+	"github.com/nickkcj/orbit-backend/internal/events"
+	"github.com/nickkcj/orbit-backend/internal/outbox"
+	"github.com/nickkcj/orbit-backend/internal/worker/tasks"
 )
 
 var (
@@ -14,14 +17,16 @@ var (
 )
 
 type LikeService struct {
-	db *database.Queries
+	db     *database.Queries
+	events *events.Bus
 }
 
-func NewLikeService(db *database.Queries) *LikeService {
-	return &LikeService{db: db}
+func NewLikeService(db *database.Queries, bus *events.Bus) *LikeService {
+	return &LikeService{db: db, events: bus}
 }
 
-// LikePost adds a like to a post
+// LikePost adds a like to a post and, in the same transaction, queues a
+// notification for its author
 func (s *LikeService) LikePost(ctx context.Context, tenantID, userID, postID uuid.UUID) error {
 	// Check if already liked
 	_, err := s.db.GetPostLike(ctx, database.GetPostLikeParams{
@@ -32,12 +37,28 @@ func (s *LikeService) LikePost(ctx context.Context, tenantID, userID, postID uui
 		return ErrAlreadyLiked
 	}
 
-	_, err = s.db.CreatePostLike(ctx, database.CreatePostLikeParams{
-		TenantID: tenantID,
-		UserID:   userID,
-		PostID:   uuid.NullUUID{UUID: postID, Valid: true},
+	err = s.db.InTx(ctx, func(q *database.Queries) error {
+		_, err := q.CreatePostLike(ctx, database.CreatePostLikeParams{
+			TenantID: tenantID,
+			UserID:   userID,
+			PostID:   uuid.NullUUID{UUID: postID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		post, err := q.GetPostByID(ctx, postID)
+		if err != nil {
+			return err
+		}
+		return s.queueLikeNotification(ctx, q, tenantID, userID, post.AuthorID, post, nil)
 	})
-	return err
+	if err != nil {
+		return err
+	}
+
+	s.publishPostLikes(ctx, tenantID, postID)
+	return nil
 }
 
 // UnlikePost removes a like from a post
@@ -51,11 +72,17 @@ func (s *LikeService) UnlikePost(ctx context.Context, tenantID, userID, postID u
 		return ErrNotLiked
 	}
 
-	return s.db.DeletePostLike(ctx, database.DeletePostLikeParams{
+	err = s.db.DeletePostLike(ctx, database.DeletePostLikeParams{
 		TenantID: tenantID,
 		UserID:   userID,
 		PostID:   uuid.NullUUID{UUID: postID, Valid: true},
 	})
+	if err != nil {
+		return err
+	}
+
+	s.publishPostLikes(ctx, tenantID, postID)
+	return nil
 }
 
 // HasUserLikedPost checks if user liked a post
@@ -70,7 +97,8 @@ func (s *LikeService) HasUserLikedPost(ctx context.Context, userID, postID uuid.
 	return true, nil
 }
 
-// LikeComment adds a like to a comment
+// LikeComment adds a like to a comment and, in the same transaction, queues
+// a notification for its author
 func (s *LikeService) LikeComment(ctx context.Context, tenantID, userID, commentID uuid.UUID) error {
 	// Check if already liked
 	_, err := s.db.GetCommentLike(ctx, database.GetCommentLikeParams{
@@ -81,12 +109,32 @@ func (s *LikeService) LikeComment(ctx context.Context, tenantID, userID, comment
 		return ErrAlreadyLiked
 	}
 
-	_, err = s.db.CreateCommentLike(ctx, database.CreateCommentLikeParams{
-		TenantID:  tenantID,
-		UserID:    userID,
-		CommentID: uuid.NullUUID{UUID: commentID, Valid: true},
+	err = s.db.InTx(ctx, func(q *database.Queries) error {
+		_, err := q.CreateCommentLike(ctx, database.CreateCommentLikeParams{
+			TenantID:  tenantID,
+			UserID:    userID,
+			CommentID: uuid.NullUUID{UUID: commentID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		comment, err := q.GetCommentByID(ctx, commentID)
+		if err != nil {
+			return err
+		}
+		post, err := q.GetPostByID(ctx, comment.PostID)
+		if err != nil {
+			return err
+		}
+		return s.queueLikeNotification(ctx, q, tenantID, userID, comment.AuthorID, post, &comment.ID)
 	})
-	return err
+	if err != nil {
+		return err
+	}
+
+	s.publishCommentLikes(ctx, tenantID, commentID)
+	return nil
 }
 
 // UnlikeComment removes a like from a comment
@@ -100,11 +148,17 @@ func (s *LikeService) UnlikeComment(ctx context.Context, tenantID, userID, comme
 		return ErrNotLiked
 	}
 
-	return s.db.DeleteCommentLike(ctx, database.DeleteCommentLikeParams{
+	err = s.db.DeleteCommentLike(ctx, database.DeleteCommentLikeParams{
 		TenantID:  tenantID,
 		UserID:    userID,
 		CommentID: uuid.NullUUID{UUID: commentID, Valid: true},
 	})
+	if err != nil {
+		return err
+	}
+
+	s.publishCommentLikes(ctx, tenantID, commentID)
+	return nil
 }
 
 // HasUserLikedComment checks if user liked a comment
@@ -156,3 +210,65 @@ func (s *LikeService) GetUserLikedCommentIDs(ctx context.Context, tenantID, user
 	}
 	return result, nil
 }
+
+// queueLikeNotification notifies the author of the liked post or comment,
+// unless they liked their own
+func (s *LikeService) queueLikeNotification(ctx context.Context, q *database.Queries, tenantID, likerID, authorID uuid.UUID, post database.Post, commentID *uuid.UUID) error {
+	if likerID == authorID {
+		return nil
+	}
+
+	likerName := ""
+	if liker, err := q.GetUserByID(ctx, likerID); err == nil {
+		likerName = liker.Name
+	}
+
+	return outbox.Add(ctx, q, tasks.TypeSendNotification, tasks.NotificationPayload{
+		Type:        "like",
+		TenantID:    tenantID,
+		RecipientID: authorID,
+		PostID:      &post.ID,
+		PostTitle:   post.Title,
+		CommentID:   commentID,
+		AuthorID:    &likerID,
+		AuthorName:  likerName,
+	})
+}
+
+// publishPostLikes broadcasts the post's current like count
+func (s *LikeService) publishPostLikes(ctx context.Context, tenantID, postID uuid.UUID) {
+	post, err := s.db.GetPostByID(ctx, postID)
+	if err != nil {
+		return
+	}
+
+	s.events.Publish(ctx, events.Event{
+		Type:     events.LikeUpdated,
+		TenantID: tenantID,
+		Payload: events.LikePayload{
+			TargetType: "post",
+			TargetID:   postID,
+			PostID:     postID,
+			LikeCount:  int(post.LikeCount),
+		},
+	})
+}
+
+// publishCommentLikes broadcasts the comment's current like count
+func (s *LikeService) publishCommentLikes(ctx context.Context, tenantID, commentID uuid.UUID) {
+	comment, err := s.db.GetCommentByID(ctx, commentID)
+	if err != nil {
+		return
+	}
+
+	s.events.Publish(ctx, events.Event{
+		Type:     events.LikeUpdated,
+		TenantID: tenantID,
+		Payload: events.LikePayload{
+			TargetType: "comment",
+			TargetID:   commentID,
+			PostID:     comment.PostID,
+			LikeCount:  int(comment.LikeCount),
+		},
+	})
+}
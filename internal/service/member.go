@@ -3,18 +3,33 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
 
 	"github.com/google/uuid"
 
 	"github.com/nickkcj/orbit-backend/internal/database"
+	"github.com/nickkcj/orbit-backend/internal/events"
+	"github.com/nickkcj/orbit-backend/internal/outbox"
+	"github.com/nickkcj/orbit-backend/internal/worker/tasks"
 )
 
+var (
+	ErrInvalidHandle = errors.New("handle must be 2-30 characters of a-z, 0-9 or _")
+	ErrHandleTaken   = errors.New("handle is already taken")
+)
+
+// handlePattern is the shape of a member handle, matched by parseMentions
+var handlePattern = regexp.MustCompile(`^[a-z0-9_]{2,30}$`)
+
 type MemberService struct {
-	db *database.Queries
+	db     *database.Queries
+	events *events.Bus
 }
 
-func NewMemberService(db *database.Queries) *MemberService {
-	return &MemberService{db: db}
+func NewMemberService(db *database.Queries, bus *events.Bus) *MemberService {
+	return &MemberService{db: db, events: bus}
 }
 
 func (s *MemberService) Add(ctx context.Context, tenantID, userID, roleID uuid.UUID, displayName string) (database.TenantMember, error) {
@@ -36,6 +51,45 @@ func (s *MemberService) AddWithDefaultRole(ctx context.Context, tenantID, userID
 	return s.Add(ctx, tenantID, userID, role.ID, displayName)
 }
 
+// AddWithWelcome adds a member with the given role, or the tenant's default
+// role when roleID is nil, and queues their welcome notification in the same
+// transaction
+func (s *MemberService) AddWithWelcome(ctx context.Context, tenantID, userID uuid.UUID, roleID *uuid.UUID, displayName string) (database.TenantMember, error) {
+	var member database.TenantMember
+	err := s.db.InTx(ctx, func(q *database.Queries) error {
+		tenant, err := q.GetTenantByID(ctx, tenantID)
+		if err != nil {
+			return err
+		}
+
+		if roleID == nil {
+			role, err := q.GetDefaultRole(ctx, tenantID)
+			if err != nil {
+				return err
+			}
+			roleID = &role.ID
+		}
+
+		member, err = q.AddMember(ctx, database.AddMemberParams{
+			TenantID:    tenantID,
+			UserID:      userID,
+			RoleID:      *roleID,
+			DisplayName: sql.NullString{String: displayName, Valid: displayName != ""},
+		})
+		if err != nil {
+			return err
+		}
+
+		return outbox.Add(ctx, q, tasks.TypeSendNotification, tasks.NotificationPayload{
+			Type:          "welcome",
+			TenantID:      tenantID,
+			RecipientID:   userID,
+			CommunityName: tenant.Name,
+		})
+	})
+	return member, err
+}
+
 func (s *MemberService) Get(ctx context.Context, tenantID, userID uuid.UUID) (database.TenantMember, error) {
 	return s.db.GetMember(ctx, database.GetMemberParams{
 		TenantID: tenantID,
@@ -59,26 +113,57 @@ func (s *MemberService) ListTenantsByUser(ctx context.Context, userID uuid.UUID)
 }
 
 func (s *MemberService) UpdateRole(ctx context.Context, tenantID, userID, roleID uuid.UUID) (database.TenantMember, error) {
-	return s.db.UpdateMemberRole(ctx, database.UpdateMemberRoleParams{
+	member, err := s.db.UpdateMemberRole(ctx, database.UpdateMemberRoleParams{
 		TenantID: tenantID,
 		UserID:   userID,
 		RoleID:   roleID,
 	})
+	if err != nil {
+		return member, err
+	}
+
+	s.events.Publish(ctx, events.Event{
+		Type:     events.MemberRoleChanged,
+		TenantID: tenantID,
+		UserID:   &userID,
+		Payload:  events.MemberRolePayload{UserID: userID, RoleID: roleID},
+	})
+	return member, nil
 }
 
 func (s *MemberService) UpdateStatus(ctx context.Context, tenantID, userID uuid.UUID, status string) error {
-	return s.db.UpdateMemberStatus(ctx, database.UpdateMemberStatusParams{
+	if err := s.db.UpdateMemberStatus(ctx, database.UpdateMemberStatusParams{
 		TenantID: tenantID,
 		UserID:   userID,
 		Status:   status,
+	}); err != nil {
+		return err
+	}
+
+	s.events.Publish(ctx, events.Event{
+		Type:     events.MemberStatusChanged,
+		TenantID: tenantID,
+		UserID:   &userID,
+		Payload:  events.MemberStatusPayload{UserID: userID, Status: status},
 	})
+	return nil
 }
 
 func (s *MemberService) Remove(ctx context.Context, tenantID, userID uuid.UUID) error {
-	return s.db.RemoveMember(ctx, database.RemoveMemberParams{
+	if err := s.db.RemoveMember(ctx, database.RemoveMemberParams{
 		TenantID: tenantID,
 		UserID:   userID,
+	}); err != nil {
+		return err
+	}
+
+	s.events.Publish(ctx, events.Event{
+		Type:     events.MemberRemoved,
+		TenantID: tenantID,
+		UserID:   &userID,
+		Payload:  events.MemberRemovedPayload{UserID: userID},
 	})
+	return nil
 }
 
 func (s *MemberService) Count(ctx context.Context, tenantID uuid.UUID) (int64, error) {
@@ -109,3 +194,38 @@ func (s *MemberService) UpdateProfile(ctx context.Context, tenantID, userID uuid
 		Bio:         sql.NullString{String: bio, Valid: bio != ""},
 	})
 }
+
+// UpdateHandle changes the handle other members use to @mention the user
+func (s *MemberService) UpdateHandle(ctx context.Context, tenantID, userID uuid.UUID, handle string) (database.TenantMember, error) {
+	handle = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+	if !handlePattern.MatchString(handle) {
+		return database.TenantMember{}, ErrInvalidHandle
+	}
+
+	existing, err := s.db.GetMemberByHandle(ctx, database.GetMemberByHandleParams{TenantID: tenantID, Handle: handle})
+	if err == nil && existing.UserID != userID {
+		return database.TenantMember{}, ErrHandleTaken
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return database.TenantMember{}, err
+	}
+
+	return s.db.UpdateMemberHandle(ctx, database.UpdateMemberHandleParams{
+		TenantID: tenantID,
+		UserID:   userID,
+		Handle:   handle,
+	})
+}
+
+// SearchForMention returns active members whose handle or name starts with
+// the query, for the editor's @mention autocomplete
+func (s *MemberService) SearchForMention(ctx context.Context, tenantID uuid.UUID, query string, limit int32) ([]database.SearchMembersForMentionRow, error) {
+	query = strings.TrimPrefix(strings.TrimSpace(query), "@")
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(query)
+
+	return s.db.SearchMembersForMention(ctx, database.SearchMembersForMentionParams{
+		TenantID: tenantID,
+		Handle:   strings.ToLower(escaped) + "%",
+		Limit:    limit,
+	})
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/nickkcj/orbit-backend/internal/database"
+	"github.com/nickkcj/orbit-backend/internal/outbox"
+	"github.com/nickkcj/orbit-backend/internal/worker/tasks"
+)
+
+// maxMentions bounds how many members one post or comment can notify
+const maxMentions = 20
+
+// mentionPattern matches @handle at the start of the text or after a
+// character that cannot belong to an email address or another handle. A
+// longer word is not a handle, rather than a mention of its first 30 letters.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@.])@([A-Za-z0-9_]{2,30})\b`)
+
+// parseMentions returns the distinct lowercase handles mentioned in content
+func parseMentions(content string) []string {
+	seen := make(map[string]struct{})
+	var handles []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		handle := strings.ToLower(match[1])
+		if _, ok := seen[handle]; ok {
+			continue
+		}
+		seen[handle] = struct{}{}
+		handles = append(handles, handle)
+		if len(handles) == maxMentions {
+			break
+		}
+	}
+	return handles
+}
+
+// mentionSource is a post, or a comment on it, whose content may mention members
+type mentionSource struct {
+	Post      database.Post
+	CommentID *uuid.UUID // Nil for the post body
+	AuthorID  uuid.UUID
+	Content   string
+}
+
+// recordMentions stores the mentions in a published post or comment and
+// queues a notification for each member mentioned there for the first time.
+// Members in skip keep the mention row but are not notified, as they already
+// get a notification for the same content.
+func recordMentions(ctx context.Context, q *database.Queries, src mentionSource, skip ...uuid.UUID) error {
+	if src.Post.Status != "published" {
+		return nil
+	}
+	handles := parseMentions(src.Content)
+	if len(handles) == 0 {
+		return nil
+	}
+
+	members, err := q.ResolveMentionHandles(ctx, database.ResolveMentionHandlesParams{
+		TenantID: src.Post.TenantID,
+		Column2:  handles,
+	})
+	if err != nil {
+		return err
+	}
+
+	authorName := ""
+	for _, member := range members {
+		if member.UserID == src.AuthorID {
+			continue
+		}
+
+		var inserted int64
+		if src.CommentID == nil {
+			inserted, err = q.CreatePostMention(ctx, database.CreatePostMentionParams{
+				TenantID:        src.Post.TenantID,
+				PostID:          src.Post.ID,
+				AuthorID:        src.AuthorID,
+				MentionedUserID: member.UserID,
+			})
+		} else {
+			inserted, err = q.CreateCommentMention(ctx, database.CreateCommentMentionParams{
+				TenantID:        src.Post.TenantID,
+				PostID:          src.Post.ID,
+				CommentID:       uuid.NullUUID{UUID: *src.CommentID, Valid: true},
+				AuthorID:        src.AuthorID,
+				MentionedUserID: member.UserID,
+			})
+		}
+		if err != nil {
+			return err
+		}
+		if inserted == 0 || containsUUID(skip, member.UserID) {
+			continue
+		}
+
+		if authorName == "" {
+			if author, err := q.GetUserByID(ctx, src.AuthorID); err == nil {
+				authorName = author.Name
+			}
+		}
+
+		authorID := src.AuthorID
+		if err := outbox.Add(ctx, q, tasks.TypeSendNotification, tasks.NotificationPayload{
+			Type:        string(NotificationTypeMention),
+			TenantID:    src.Post.TenantID,
+			RecipientID: member.UserID,
+			PostID:      &src.Post.ID,
+			PostTitle:   src.Post.Title,
+			CommentID:   src.CommentID,
+			AuthorID:    &authorID,
+			AuthorName:  authorName,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func containsUUID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
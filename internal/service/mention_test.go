@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nickkcj/orbit-backend/internal/database"
+	"github.com/nickkcj/orbit-backend/internal/worker/tasks"
+)
+
+func TestParseMentions(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"single", "oi @ana", []string{"ana"}},
+		{"start of text", "@ana oi", []string{"ana"}},
+		{"duplicates", "@ana e @ana de novo", []string{"ana"}},
+		{"case folded", "@Ana @ANA @bia", []string{"ana", "bia"}},
+		{"trailing punctuation", "valeu @ana! e @bia, @caio. (@duda)", []string{"ana", "bia", "caio", "duda"}},
+		{"email", "escreva para ana@example.com", nil},
+		{"email with handle-like domain", "contato: x@ana", nil},
+		{"double at", "@@ana", nil},
+		{"after dot", "fim.@ana", nil},
+		{"too short", "@a", nil},
+		{"30 letters", "@" + strings.Repeat("a", 30) + "!", []string{strings.Repeat("a", 30)}},
+		{"longer than 30", "@" + strings.Repeat("a", 31), nil},
+		{"no mentions", "sem menções aqui", nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := parseMentions(tc.content); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("parseMentions(%q) = %q, want %q", tc.content, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseMentionsStopsAtMax(t *testing.T) {
+	var content strings.Builder
+	for i := 0; i < maxMentions+5; i++ {
+		fmt.Fprintf(&content, "@user%d ", i)
+	}
+	if got := parseMentions(content.String()); len(got) != maxMentions {
+		t.Fatalf("expected %d handles, got %d", maxMentions, len(got))
+	}
+}
+
+func TestRecordMentionsNotifiesOtherMembersOnce(t *testing.T) {
+	store := &mentionStore{members: make(map[string]uuid.UUID), mentions: make(map[[2]uuid.UUID]bool)}
+	db := openFakeDB(t, store)
+
+	author := store.addMember("autor")
+	ana := store.addMember("ana")
+	bia := store.addMember("bia")
+	caio := store.addMember("caio")
+
+	src := mentionSource{
+		Post:     database.Post{ID: uuid.New(), TenantID: uuid.New(), Status: "published", Title: "Olá"},
+		AuthorID: author,
+		// Self-mention, a duplicate, a non-member and someone already notified
+		Content: "@ana @Ana @autor @fantasma @bia @caio",
+	}
+	if err := recordMentions(context.Background(), db, src, caio); err != nil {
+		t.Fatalf("record mentions: %v", err)
+	}
+
+	if got := store.notified(); !reflect.DeepEqual(got, []uuid.UUID{ana, bia}) {
+		t.Fatalf("notified %v, want ana and bia", got)
+	}
+	if !store.mentioned(src.Post.ID, caio) {
+		t.Fatalf("skipped member lost the mention row")
+	}
+	if store.mentioned(src.Post.ID, author) {
+		t.Fatalf("author mention was recorded")
+	}
+
+	// Saving the post again notifies nobody twice
+	if err := recordMentions(context.Background(), db, src); err != nil {
+		t.Fatalf("record mentions again: %v", err)
+	}
+	if got := store.notified(); len(got) != 2 {
+		t.Fatalf("expected no new notifications, got %v", got)
+	}
+
+	// Drafts mention nobody
+	draft := src
+	draft.Post.ID, draft.Post.Status = uuid.New(), "draft"
+	if err := recordMentions(context.Background(), db, draft); err != nil {
+		t.Fatalf("record draft mentions: %v", err)
+	}
+	if store.mentioned(draft.Post.ID, ana) {
+		t.Fatalf("draft mention was recorded")
+	}
+}
+
+// ============================================================================
+// In-memory database
+// ============================================================================
+
+// mentionStore fakes the active members of one tenant, mention rows and the
+// outbox notifications they queue
+type mentionStore struct {
+	mu         sync.Mutex
+	members    map[string]uuid.UUID  // handle -> user
+	mentions   map[[2]uuid.UUID]bool // post, mentioned user
+	recipients []uuid.UUID           // queued mention notifications
+}
+
+func (s *mentionStore) addMember(handle string) uuid.UUID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	userID := uuid.New()
+	s.members[handle] = userID
+	return userID
+}
+
+func (s *mentionStore) notified() []uuid.UUID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]uuid.UUID(nil), s.recipients...)
+}
+
+func (s *mentionStore) mentioned(postID, userID uuid.UUID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mentions[[2]uuid.UUID{postID, userID}]
+}
+
+func (s *mentionStore) exec(name string, args []driver.Value) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch name {
+	case "CreatePostMention":
+		key := [2]uuid.UUID{uuid.MustParse(args[1].(string)), uuid.MustParse(args[3].(string))}
+		if s.mentions[key] {
+			return 0, nil
+		}
+		s.mentions[key] = true
+		return 1, nil
+	}
+	return 0, fmt.Errorf("mentionstore: unsupported exec %s", name)
+}
+
+func (s *mentionStore) query(name string, args []driver.Value) ([][]driver.Value, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+
+	switch name {
+	case "ResolveMentionHandles":
+		// The handles arrive as a Postgres array literal: {"ana","bia"}
+		var rows [][]driver.Value
+		for _, handle := range strings.Split(strings.Trim(args[1].(string), "{}"), ",") {
+			handle = strings.Trim(handle, `"`)
+			if userID, ok := s.members[handle]; ok {
+				rows = append(rows, []driver.Value{userID.String(), handle})
+			}
+		}
+		return rows, nil
+	case "GetUserByID":
+		return [][]driver.Value{{args[0], "autor@example.com", "", "Autor", nil, nil, "active", now, now}}, nil
+	case "CreateOutboxMessage":
+		var payload tasks.NotificationPayload
+		if err := json.Unmarshal(args[1].([]byte), &payload); err != nil {
+			return nil, err
+		}
+		s.recipients = append(s.recipients, payload.RecipientID)
+		return [][]driver.Value{{uuid.NewString(), args[0], args[1], int64(0), nil, now, now, nil}}, nil
+	}
+	return nil, fmt.Errorf("mentionstore: unsupported query %s", name)
+}
@@ -4,36 +4,52 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/nickkcj/orbit-backend/internal/database"
+	"github.com/nickkcj/orbit-backend/internal/events"
 	"github.com/sqlc-dev/pqtype"
 )
 
 type NotificationService struct {
-	db *database.Queries
+	db     *database.Queries
+	events *events.Bus
+	links  NotificationLinks
 }
 
-func NewNotificationService(db *database.Queries) *NotificationService {
-	return &NotificationService{db: db}
+// NotificationLinks are what notification emails link to and sign with
+type NotificationLinks struct {
+	UnsubscribeKey []byte // Signs one-click unsubscribe tokens
+	APIURL         string // Public API base of the unsubscribe endpoint
+	BaseDomain     string // Communities live at <slug>.<BaseDomain>
+}
+
+func NewNotificationService(db *database.Queries, bus *events.Bus, links NotificationLinks) *NotificationService {
+	return &NotificationService{db: db, events: bus, links: links}
 }
 
 type NotificationType string
 
 const (
-	NotificationTypeComment  NotificationType = "comment"
-	NotificationTypeReply    NotificationType = "reply"
-	NotificationTypeMention  NotificationType = "mention"
-	NotificationTypeNewPost  NotificationType = "new_post"
-	NotificationTypeWelcome  NotificationType = "welcome"
+	NotificationTypeComment NotificationType = "comment"
+	NotificationTypeReply   NotificationType = "reply"
+	NotificationTypeMention NotificationType = "mention"
+	NotificationTypeNewPost NotificationType = "new_post"
+	NotificationTypeWelcome NotificationType = "welcome"
+	NotificationTypeLike    NotificationType = "like"
+
+	// Not a notification: names the digest email in unsubscribe links
+	NotificationTypeDigest NotificationType = "digest"
 )
 
 type NotificationData struct {
-	PostID      *uuid.UUID `json:"post_id,omitempty"`
-	PostTitle   string     `json:"post_title,omitempty"`
-	CommentID   *uuid.UUID `json:"comment_id,omitempty"`
-	AuthorID    *uuid.UUID `json:"author_id,omitempty"`
-	AuthorName  string     `json:"author_name,omitempty"`
+	PostID     *uuid.UUID          `json:"post_id,omitempty"`
+	PostTitle  string              `json:"post_title,omitempty"`
+	CommentID  *uuid.UUID          `json:"comment_id,omitempty"`
+	AuthorID   *uuid.UUID          `json:"author_id,omitempty"`
+	AuthorName string              `json:"author_name,omitempty"`
+	Actors     []NotificationActor `json:"actors,omitempty"` // Latest first, for grouped notifications
 }
 
 type CreateNotificationInput struct {
@@ -43,21 +59,65 @@ type CreateNotificationInput struct {
 	Title    string
 	Message  string
 	Data     NotificationData
+	Group    *NotificationGroup // Set to merge into an open notification of the group
 }
 
-func (s *NotificationService) Create(ctx context.Context, input CreateNotificationInput) (database.Notification, error) {
-	dataJSON, err := json.Marshal(input.Data)
+// Create stores the notification, or folds it into the recipient's open
+// notification of the same group. changed is false when the group already
+// counted the actor and nothing was written.
+func (s *NotificationService) Create(ctx context.Context, input CreateNotificationInput) (notification database.Notification, changed bool, err error) {
+	if input.Group != nil {
+		return s.createGrouped(ctx, input)
+	}
+
+	notification, err = s.db.CreateNotification(ctx, createNotificationParams(input))
 	if err != nil {
-		dataJSON = []byte("{}")
+		return notification, false, err
 	}
 
-	return s.db.CreateNotification(ctx, database.CreateNotificationParams{
+	s.publish(ctx, events.NotificationCreated, notification)
+	return notification, true, nil
+}
+
+func createNotificationParams(input CreateNotificationInput) database.CreateNotificationParams {
+	params := database.CreateNotificationParams{
 		TenantID: input.TenantID,
 		UserID:   input.UserID,
 		Type:     string(input.Type),
 		Title:    input.Title,
 		Message:  sql.NullString{String: input.Message, Valid: input.Message != ""},
-		Data:     pqtype.NullRawMessage{RawMessage: dataJSON, Valid: true},
+		Data:     notificationData(input.Data),
+	}
+	if input.Group != nil {
+		params.GroupKey = sql.NullString{String: input.Group.Key, Valid: true}
+	}
+	return params
+}
+
+func notificationData(data NotificationData) pqtype.NullRawMessage {
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		dataJSON = []byte("{}")
+	}
+	return pqtype.NullRawMessage{RawMessage: dataJSON, Valid: true}
+}
+
+// publish sends the notification to the recipient's open connections
+func (s *NotificationService) publish(ctx context.Context, eventType events.Type, notification database.Notification) {
+	recipientID := notification.UserID
+	s.events.Publish(ctx, events.Event{
+		Type:     eventType,
+		TenantID: notification.TenantID,
+		UserID:   &recipientID,
+		Payload: events.NotificationPayload{
+			ID:         notification.ID,
+			Type:       notification.Type,
+			Title:      notification.Title,
+			Message:    notification.Message.String,
+			Data:       notification.Data.RawMessage,
+			ActorCount: int(notification.ActorCount),
+			CreatedAt:  notification.CreatedAt,
+		},
 	})
 }
 
@@ -105,10 +165,12 @@ func (s *NotificationService) Delete(ctx context.Context, notificationID, userID
 	})
 }
 
-// Helper methods for creating specific notification types
+// Builders of each notification type; NotificationHandler picks the
+// channels they go out on
 
-func (s *NotificationService) NotifyComment(ctx context.Context, tenantID, postAuthorID uuid.UUID, commenterName, postTitle string, postID, commentID uuid.UUID) error {
-	_, err := s.Create(ctx, CreateNotificationInput{
+// CommentNotification groups the comments on one post while unread
+func CommentNotification(tenantID, postAuthorID, commenterID uuid.UUID, commenterName, postTitle string, postID, commentID uuid.UUID) CreateNotificationInput {
+	return CreateNotificationInput{
 		TenantID: tenantID,
 		UserID:   postAuthorID,
 		Type:     NotificationTypeComment,
@@ -120,12 +182,50 @@ func (s *NotificationService) NotifyComment(ctx context.Context, tenantID, postA
 			CommentID:  &commentID,
 			AuthorName: commenterName,
 		},
-	})
-	return err
+		Group: &NotificationGroup{
+			Key:    "comment:post:" + postID.String(),
+			Actor:  NotificationActor{ID: commenterID, Name: commenterName},
+			Verb:   "comentou",
+			Verbs:  "comentaram",
+			Object: "no seu post \"" + postTitle + "\"",
+		},
+	}
+}
+
+// LikeNotification tells an author their post, or their comment on it when
+// commentID is set, was liked; likes of one target are grouped while unread
+func LikeNotification(tenantID, authorID, likerID uuid.UUID, likerName, postTitle string, postID uuid.UUID, commentID *uuid.UUID) CreateNotificationInput {
+	group := &NotificationGroup{
+		Key:    "like:post:" + postID.String(),
+		Actor:  NotificationActor{ID: likerID, Name: likerName},
+		Verb:   "curtiu",
+		Verbs:  "curtiram",
+		Object: "seu post \"" + postTitle + "\"",
+	}
+	if commentID != nil {
+		group.Key = "like:comment:" + commentID.String()
+		group.Object = "seu comentário em \"" + postTitle + "\""
+	}
+
+	return CreateNotificationInput{
+		TenantID: tenantID,
+		UserID:   authorID,
+		Type:     NotificationTypeLike,
+		Title:    "Nova curtida",
+		Message:  group.Message(1),
+		Data: NotificationData{
+			PostID:     &postID,
+			PostTitle:  postTitle,
+			CommentID:  commentID,
+			AuthorID:   &likerID,
+			AuthorName: likerName,
+		},
+		Group: group,
+	}
 }
 
-func (s *NotificationService) NotifyReply(ctx context.Context, tenantID, commentAuthorID uuid.UUID, replierName, postTitle string, postID, commentID uuid.UUID) error {
-	_, err := s.Create(ctx, CreateNotificationInput{
+func ReplyNotification(tenantID, commentAuthorID uuid.UUID, replierName, postTitle string, postID, commentID uuid.UUID) CreateNotificationInput {
+	return CreateNotificationInput{
 		TenantID: tenantID,
 		UserID:   commentAuthorID,
 		Type:     NotificationTypeReply,
@@ -137,18 +237,61 @@ func (s *NotificationService) NotifyReply(ctx context.Context, tenantID, comment
 			CommentID:  &commentID,
 			AuthorName: replierName,
 		},
-	})
-	return err
+	}
+}
+
+// MentionNotification tells a member they were mentioned in a post, or in a
+// comment on it when commentID is set
+func MentionNotification(tenantID, mentionedUserID uuid.UUID, authorName, postTitle string, postID uuid.UUID, commentID *uuid.UUID) CreateNotificationInput {
+	message := authorName + " mencionou você no post \"" + postTitle + "\""
+	if commentID != nil {
+		message = authorName + " mencionou você em um comentário em \"" + postTitle + "\""
+	}
+
+	return CreateNotificationInput{
+		TenantID: tenantID,
+		UserID:   mentionedUserID,
+		Type:     NotificationTypeMention,
+		Title:    "Nova menção",
+		Message:  message,
+		Data: NotificationData{
+			PostID:     &postID,
+			PostTitle:  postTitle,
+			CommentID:  commentID,
+			AuthorName: authorName,
+		},
+	}
+}
+
+// NewPostNotification tells a follower of the category or author about a new post
+func NewPostNotification(tenantID, followerID uuid.UUID, authorName, postTitle string, postID, authorID uuid.UUID) CreateNotificationInput {
+	return CreateNotificationInput{
+		TenantID: tenantID,
+		UserID:   followerID,
+		Type:     NotificationTypeNewPost,
+		Title:    "Novo post",
+		Message:  authorName + " publicou \"" + postTitle + "\"",
+		Data: NotificationData{
+			PostID:     &postID,
+			PostTitle:  postTitle,
+			AuthorID:   &authorID,
+			AuthorName: authorName,
+		},
+	}
 }
 
-func (s *NotificationService) NotifyWelcome(ctx context.Context, tenantID, userID uuid.UUID, communityName string) error {
-	_, err := s.Create(ctx, CreateNotificationInput{
+func WelcomeNotification(tenantID, userID uuid.UUID, communityName string) CreateNotificationInput {
+	return CreateNotificationInput{
 		TenantID: tenantID,
 		UserID:   userID,
 		Type:     NotificationTypeWelcome,
 		Title:    "Bem-vindo!",
 		Message:  "Você agora faz parte da comunidade " + communityName + "!",
 		Data:     NotificationData{},
-	})
-	return err
+	}
+}
+
+// PurgeRead deletes read notifications created before the cutoff
+func (s *NotificationService) PurgeRead(ctx context.Context, before time.Time) (int64, error) {
+	return s.db.DeleteOldNotifications(ctx, before)
 }
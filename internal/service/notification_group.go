@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nickkcj/orbit-backend/internal/database"
+	"github.com/nickkcj/orbit-backend/internal/events"
+)
+
+const (
+	// notificationGroupWindow is how long an unread grouped notification
+	// keeps absorbing new events before the next one starts a new group
+	notificationGroupWindow = 24 * time.Hour
+
+	// maxGroupActors is how many of the latest actors the data keeps
+	maxGroupActors = 3
+)
+
+// NotificationActor is a member who caused a grouped event
+type NotificationActor struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+// NotificationGroup merges events of one type on one target, such as the
+// likes of a post, into a single unread notification
+type NotificationGroup struct {
+	Key    string // Type and target, e.g. like:post:<id>
+	Actor  NotificationActor
+	Verb   string // Singular and plural verb, e.g. curtiu / curtiram
+	Verbs  string
+	Object string // Rest of the sentence, e.g. seu post "Título"
+}
+
+// Message describes the group for count actors, naming the latest one:
+// "Ana curtiu seu post", "Ana e mais 12 pessoas curtiram seu post"
+func (g *NotificationGroup) Message(count int) string {
+	switch {
+	case count <= 1:
+		return g.Actor.Name + " " + g.Verb + " " + g.Object
+	case count == 2:
+		return g.Actor.Name + " e mais 1 pessoa " + g.Verbs + " " + g.Object
+	default:
+		return g.Actor.Name + " e mais " + strconv.Itoa(count-1) + " pessoas " + g.Verbs + " " + g.Object
+	}
+}
+
+// createGrouped inserts the first notification of a group or updates the
+// open one. The advisory lock keeps two concurrent events from both
+// inserting; the group's actor rows keep one actor from counting twice.
+func (s *NotificationService) createGrouped(ctx context.Context, input CreateNotificationInput) (database.Notification, bool, error) {
+	group := input.Group
+	var notification database.Notification
+	eventType := events.NotificationCreated
+	changed := true
+
+	err := s.db.InTx(ctx, func(q *database.Queries) error {
+		if err := q.LockNotificationGroup(ctx, input.UserID.String()+":"+group.Key); err != nil {
+			return err
+		}
+
+		open, err := q.GetOpenNotificationGroup(ctx, database.GetOpenNotificationGroupParams{
+			UserID:    input.UserID,
+			GroupKey:  sql.NullString{String: group.Key, Valid: true},
+			CreatedAt: time.Now().Add(-notificationGroupWindow),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			input.Data.Actors = []NotificationActor{group.Actor}
+			notification, err = q.CreateNotification(ctx, createNotificationParams(input))
+			if err != nil {
+				return err
+			}
+			_, err = q.AddNotificationGroupActor(ctx, database.AddNotificationGroupActorParams{
+				NotificationID: notification.ID,
+				ActorID:        group.Actor.ID,
+			})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		// The same member again, e.g. like, unlike and like
+		added, err := q.AddNotificationGroupActor(ctx, database.AddNotificationGroupActorParams{
+			NotificationID: open.ID,
+			ActorID:        group.Actor.ID,
+		})
+		if err != nil {
+			return err
+		}
+		if added == 0 {
+			notification, changed = open, false
+			return nil
+		}
+
+		count, err := q.CountNotificationGroupActors(ctx, open.ID)
+		if err != nil {
+			return err
+		}
+
+		var data NotificationData
+		if open.Data.Valid {
+			_ = json.Unmarshal(open.Data.RawMessage, &data)
+		}
+		actors := append([]NotificationActor{group.Actor}, data.Actors...)
+		if len(actors) > maxGroupActors {
+			actors = actors[:maxGroupActors]
+		}
+		input.Data.Actors = actors
+
+		notification, err = q.UpdateNotificationGroup(ctx, database.UpdateNotificationGroupParams{
+			ID:         open.ID,
+			Title:      input.Title,
+			Message:    sql.NullString{String: group.Message(int(count)), Valid: true},
+			Data:       notificationData(input.Data),
+			ActorCount: int32(count),
+		})
+		eventType = events.NotificationUpdated
+		return err
+	})
+	if err != nil {
+		return database.Notification{}, false, err
+	}
+
+	if changed {
+		s.publish(ctx, eventType, notification)
+	}
+	return notification, changed, nil
+}
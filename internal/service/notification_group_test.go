@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nickkcj/orbit-backend/internal/database"
+)
+
+func TestNotificationGroupMessage(t *testing.T) {
+	group := &NotificationGroup{
+		Actor:  NotificationActor{Name: "Ana"},
+		Verb:   "curtiu",
+		Verbs:  "curtiram",
+		Object: "seu post \"Olá\"",
+	}
+	cases := []struct {
+		count int
+		want  string
+	}{
+		{1, "Ana curtiu seu post \"Olá\""},
+		{2, "Ana e mais 1 pessoa curtiram seu post \"Olá\""},
+		{3, "Ana e mais 2 pessoas curtiram seu post \"Olá\""},
+		{13, "Ana e mais 12 pessoas curtiram seu post \"Olá\""},
+	}
+	for _, tc := range cases {
+		if got := group.Message(tc.count); got != tc.want {
+			t.Errorf("Message(%d) = %q, want %q", tc.count, got, tc.want)
+		}
+	}
+}
+
+func TestCreateGroupedCountsEachActorOnce(t *testing.T) {
+	store := &notificationStore{
+		notifications: make(map[uuid.UUID]*database.Notification),
+		actors:        make(map[uuid.UUID]map[uuid.UUID]bool),
+	}
+	svc := NewNotificationService(openFakeDB(t, store), nil, NotificationLinks{})
+	tenantID, authorID, postID := uuid.New(), uuid.New(), uuid.New()
+
+	like := func(name string, likerID uuid.UUID) (database.Notification, bool) {
+		t.Helper()
+		input := LikeNotification(tenantID, authorID, likerID, name, "Olá", postID, nil)
+		notification, changed, err := svc.createGrouped(context.Background(), input)
+		if err != nil {
+			t.Fatalf("like by %s: %v", name, err)
+		}
+		return notification, changed
+	}
+
+	ana := uuid.New()
+	first, changed := like("Ana", ana)
+	if !changed || first.ActorCount != 1 {
+		t.Fatalf("first like: changed %v, count %d", changed, first.ActorCount)
+	}
+
+	// Like, unlike and like again by the same member adds nothing
+	again, changed := like("Ana", ana)
+	if changed || again.ID != first.ID || again.ActorCount != 1 {
+		t.Fatalf("repeat like: changed %v, count %d", changed, again.ActorCount)
+	}
+
+	var latest database.Notification
+	for _, name := range []string{"Bia", "Caio", "Duda"} {
+		latest, changed = like(name, uuid.New())
+		if !changed || latest.ID != first.ID {
+			t.Fatalf("like by %s did not update the group", name)
+		}
+	}
+	if latest.ActorCount != 4 {
+		t.Fatalf("expected 4 actors, got %d", latest.ActorCount)
+	}
+	if want := "Duda e mais 3 pessoas curtiram seu post \"Olá\""; latest.Message.String != want {
+		t.Fatalf("message %q, want %q", latest.Message.String, want)
+	}
+
+	var data NotificationData
+	if err := json.Unmarshal(latest.Data.RawMessage, &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Actors) != maxGroupActors {
+		t.Fatalf("expected %d actors kept, got %d", maxGroupActors, len(data.Actors))
+	}
+	for i, name := range []string{"Duda", "Caio", "Bia"} {
+		if data.Actors[i].Name != name {
+			t.Fatalf("actor %d is %q, want %q", i, data.Actors[i].Name, name)
+		}
+	}
+}
+
+// ============================================================================
+// In-memory database
+// ============================================================================
+
+// notificationStore fakes the grouped notification queries
+type notificationStore struct {
+	mu            sync.Mutex
+	notifications map[uuid.UUID]*database.Notification
+	actors        map[uuid.UUID]map[uuid.UUID]bool // notification -> actors
+}
+
+func (s *notificationStore) exec(name string, args []driver.Value) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch name {
+	case "LockNotificationGroup":
+		return 0, nil
+	case "AddNotificationGroupActor":
+		id, actorID := uuid.MustParse(args[0].(string)), uuid.MustParse(args[1].(string))
+		if s.actors[id] == nil {
+			s.actors[id] = make(map[uuid.UUID]bool)
+		}
+		if s.actors[id][actorID] {
+			return 0, nil
+		}
+		s.actors[id][actorID] = true
+		return 1, nil
+	}
+	return 0, fmt.Errorf("notificationstore: unsupported exec %s", name)
+}
+
+func (s *notificationStore) query(name string, args []driver.Value) ([][]driver.Value, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch name {
+	case "GetOpenNotificationGroup":
+		for _, n := range s.notifications {
+			if n.UserID.String() == args[0] && n.GroupKey.String == args[1] && !n.ReadAt.Valid && n.CreatedAt.After(args[2].(time.Time)) {
+				return [][]driver.Value{notificationRow(n)}, nil
+			}
+		}
+		return nil, nil
+	case "CreateNotification":
+		n := &database.Notification{
+			ID:         uuid.New(),
+			TenantID:   uuid.MustParse(args[0].(string)),
+			UserID:     uuid.MustParse(args[1].(string)),
+			Type:       args[2].(string),
+			Title:      args[3].(string),
+			Message:    scanNullString(args[4]),
+			CreatedAt:  time.Now(),
+			GroupKey:   scanNullString(args[6]),
+			ActorCount: 1,
+		}
+		n.Data.RawMessage, n.Data.Valid = args[5].([]byte), args[5] != nil
+		s.notifications[n.ID] = n
+		return [][]driver.Value{notificationRow(n)}, nil
+	case "CountNotificationGroupActors":
+		return [][]driver.Value{{int64(len(s.actors[uuid.MustParse(args[0].(string))]))}}, nil
+	case "UpdateNotificationGroup":
+		n := s.notifications[uuid.MustParse(args[0].(string))]
+		n.Title = args[1].(string)
+		n.Message = scanNullString(args[2])
+		n.Data.RawMessage, n.Data.Valid = args[3].([]byte), args[3] != nil
+		n.ActorCount = int32(args[4].(int64))
+		n.CreatedAt = time.Now()
+		return [][]driver.Value{notificationRow(n)}, nil
+	}
+	return nil, fmt.Errorf("notificationstore: unsupported query %s", name)
+}
+
+func notificationRow(n *database.Notification) []driver.Value {
+	var data driver.Value
+	if n.Data.Valid {
+		data = []byte(n.Data.RawMessage)
+	}
+	return []driver.Value{
+		n.ID.String(), n.TenantID.String(), n.UserID.String(), n.Type, n.Title, nullString(n.Message),
+		data, nullTime(n.ReadAt), n.CreatedAt, nullString(n.GroupKey), int64(n.ActorCount),
+	}
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nickkcj/orbit-backend/internal/database"
+	"github.com/nickkcj/orbit-backend/internal/worker/tasks"
+)
+
+const (
+	// unsubscribeTokenVersion changes whenever the token format does
+	unsubscribeTokenVersion = "1"
+	unsubscribeTokenTTL     = 90 * 24 * time.Hour
+)
+
+var (
+	ErrUnknownNotificationType = errors.New("unknown notification type")
+	ErrInvalidUnsubscribeToken = errors.New("invalid unsubscribe token")
+)
+
+// NotificationTypes are the types members can configure, in display order
+var NotificationTypes = []NotificationType{
+	NotificationTypeComment,
+	NotificationTypeReply,
+	NotificationTypeMention,
+	NotificationTypeNewPost,
+	NotificationTypeLike,
+	NotificationTypeWelcome,
+}
+
+// NotificationPreference is the channels a member gets one notification type on
+type NotificationPreference struct {
+	Type  NotificationType `json:"type"`
+	InApp bool             `json:"in_app"`
+	Email bool             `json:"email"`
+	Push  bool             `json:"push"`
+}
+
+// Off reports whether the notification goes out on no channel at all
+func (p NotificationPreference) Off() bool {
+	return !p.InApp && !p.Email && !p.Push
+}
+
+// defaultPreferences apply to every type a member never changed: direct
+// interactions reach every channel, the noisier types stay in the app
+var defaultPreferences = map[NotificationType]NotificationPreference{
+	NotificationTypeComment: {Type: NotificationTypeComment, InApp: true, Email: true, Push: true},
+	NotificationTypeReply:   {Type: NotificationTypeReply, InApp: true, Email: true, Push: true},
+	NotificationTypeMention: {Type: NotificationTypeMention, InApp: true, Email: true, Push: true},
+	NotificationTypeNewPost: {Type: NotificationTypeNewPost, InApp: true, Email: false, Push: true},
+	NotificationTypeLike:    {Type: NotificationTypeLike, InApp: true, Email: false, Push: false},
+	NotificationTypeWelcome: {Type: NotificationTypeWelcome, InApp: true, Email: false, Push: false},
+}
+
+// Preference returns the channels a member gets one notification type on
+func (s *NotificationService) Preference(ctx context.Context, tenantID, userID uuid.UUID, notificationType NotificationType) (NotificationPreference, error) {
+	pref, ok := defaultPreferences[notificationType]
+	if !ok {
+		return NotificationPreference{}, ErrUnknownNotificationType
+	}
+
+	stored, err := s.db.GetNotificationPreference(ctx, database.GetNotificationPreferenceParams{
+		TenantID: tenantID,
+		UserID:   userID,
+		Type:     string(notificationType),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return pref, nil
+	}
+	if err != nil {
+		return pref, err
+	}
+	return toNotificationPreference(stored), nil
+}
+
+// Preferences returns the member's channels for every notification type
+func (s *NotificationService) Preferences(ctx context.Context, tenantID, userID uuid.UUID) ([]NotificationPreference, error) {
+	stored, err := s.db.ListNotificationPreferences(ctx, database.ListNotificationPreferencesParams{
+		TenantID: tenantID,
+		UserID:   userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byType := make(map[NotificationType]NotificationPreference, len(stored))
+	for _, row := range stored {
+		byType[NotificationType(row.Type)] = toNotificationPreference(row)
+	}
+
+	prefs := make([]NotificationPreference, len(NotificationTypes))
+	for i, notificationType := range NotificationTypes {
+		pref, ok := byType[notificationType]
+		if !ok {
+			pref = defaultPreferences[notificationType]
+		}
+		prefs[i] = pref
+	}
+	return prefs, nil
+}
+
+// UpdatePreferences stores the given types and returns the full set
+func (s *NotificationService) UpdatePreferences(ctx context.Context, tenantID, userID uuid.UUID, prefs []NotificationPreference) ([]NotificationPreference, error) {
+	for _, pref := range prefs {
+		if _, ok := defaultPreferences[pref.Type]; !ok {
+			return nil, ErrUnknownNotificationType
+		}
+	}
+
+	err := s.db.InTx(ctx, func(q *database.Queries) error {
+		for _, pref := range prefs {
+			if _, err := q.UpsertNotificationPreference(ctx, database.UpsertNotificationPreferenceParams{
+				TenantID: tenantID,
+				UserID:   userID,
+				Type:     string(pref.Type),
+				InApp:    pref.InApp,
+				Email:    pref.Email,
+				Push:     pref.Push,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Preferences(ctx, tenantID, userID)
+}
+
+func toNotificationPreference(row database.NotificationPreference) NotificationPreference {
+	return NotificationPreference{
+		Type:  NotificationType(row.Type),
+		InApp: row.InApp,
+		Email: row.Email,
+		Push:  row.Push,
+	}
+}
+
+// UnsubscribeToken signs the membership and type an email unsubscribe link
+// turns off. Tokens expire after unsubscribeTokenTTL; every email carries a
+// fresh one.
+func (s *NotificationService) UnsubscribeToken(tenantID, userID uuid.UUID, notificationType NotificationType) string {
+	return s.signUnsubscribeToken(tenantID, userID, notificationType, time.Now().Add(unsubscribeTokenTTL))
+}
+
+func (s *NotificationService) signUnsubscribeToken(tenantID, userID uuid.UUID, notificationType NotificationType, expiresAt time.Time) string {
+	claims := strings.Join([]string{
+		unsubscribeTokenVersion,
+		strconv.FormatInt(expiresAt.Unix(), 10),
+		tenantID.String(),
+		userID.String(),
+		string(notificationType),
+	}, ":")
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(claims))
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.unsubscribeSignature(encoded))
+}
+
+// Unsubscribe turns off the email channel named by an unsubscribe token
+func (s *NotificationService) Unsubscribe(ctx context.Context, token string) error {
+	tenantID, userID, notificationType, err := s.parseUnsubscribeToken(token, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if notificationType == NotificationTypeDigest {
+		return s.db.DisableDigest(ctx, database.DisableDigestParams{TenantID: tenantID, UserID: userID})
+	}
+
+	pref, err := s.Preference(ctx, tenantID, userID, notificationType)
+	if err != nil {
+		if errors.Is(err, ErrUnknownNotificationType) {
+			return ErrInvalidUnsubscribeToken
+		}
+		return err
+	}
+	if !pref.Email {
+		return nil
+	}
+
+	// A member who left since has nothing to unsubscribe from
+	if _, err := s.db.GetMember(ctx, database.GetMemberParams{TenantID: tenantID, UserID: userID}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	pref.Email = false
+	_, err = s.UpdatePreferences(ctx, tenantID, userID, []NotificationPreference{pref})
+	return err
+}
+
+// parseUnsubscribeToken checks the signature, version and expiry of a token
+// and returns what it unsubscribes from
+func (s *NotificationService) parseUnsubscribeToken(token string, now time.Time) (tenantID, userID uuid.UUID, notificationType NotificationType, err error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return tenantID, userID, "", ErrInvalidUnsubscribeToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, s.unsubscribeSignature(encoded)) {
+		return tenantID, userID, "", ErrInvalidUnsubscribeToken
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return tenantID, userID, "", ErrInvalidUnsubscribeToken
+	}
+
+	parts := strings.SplitN(string(raw), ":", 5)
+	if len(parts) != 5 || parts[0] != unsubscribeTokenVersion {
+		return tenantID, userID, "", ErrInvalidUnsubscribeToken
+	}
+	expiresAt, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || now.Unix() > expiresAt {
+		return tenantID, userID, "", ErrInvalidUnsubscribeToken
+	}
+	if tenantID, err = uuid.Parse(parts[2]); err != nil {
+		return tenantID, userID, "", ErrInvalidUnsubscribeToken
+	}
+	if userID, err = uuid.Parse(parts[3]); err != nil {
+		return tenantID, userID, "", ErrInvalidUnsubscribeToken
+	}
+	return tenantID, userID, NotificationType(parts[4]), nil
+}
+
+func (s *NotificationService) unsubscribeSignature(encoded string) []byte {
+	mac := hmac.New(sha256.New, s.links.UnsubscribeKey)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
+
+// NotificationEmail builds the email task of a notification, with the
+// recipient's address and one-click unsubscribe headers (RFC 8058)
+func (s *NotificationService) NotificationEmail(ctx context.Context, input CreateNotificationInput) (tasks.EmailPayload, error) {
+	user, err := s.db.GetUserByID(ctx, input.UserID)
+	if err != nil {
+		return tasks.EmailPayload{}, err
+	}
+	tenant, err := s.db.GetTenantByID(ctx, input.TenantID)
+	if err != nil {
+		return tasks.EmailPayload{}, err
+	}
+
+	unsubscribeURL := s.links.APIURL + "/api/v1/notifications/unsubscribe?token=" +
+		url.QueryEscape(s.UnsubscribeToken(input.TenantID, input.UserID, input.Type))
+
+	tenantID := input.TenantID
+	return tasks.EmailPayload{
+		TenantID: &tenantID,
+		To:       user.Email,
+		ToName:   user.Name,
+		Template: "notification",
+		Data: map[string]interface{}{
+			"title":           input.Title,
+			"message":         input.Message,
+			"url":             "https://" + tenant.Slug + "." + s.links.BaseDomain + "/notifications",
+			"unsubscribe_url": unsubscribeURL,
+		},
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + unsubscribeURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}, nil
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestUnsubscribeTokenRoundTrip(t *testing.T) {
+	svc := NewNotificationService(nil, nil, notificationLinks("test-secret", nil))
+	tenantID, userID := uuid.New(), uuid.New()
+
+	token := svc.UnsubscribeToken(tenantID, userID, NotificationTypeNewPost)
+	gotTenant, gotUser, gotType, err := svc.parseUnsubscribeToken(token, time.Now())
+	if err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+	if gotTenant != tenantID || gotUser != userID || gotType != NotificationTypeNewPost {
+		t.Fatalf("token decoded to %s %s %s", gotTenant, gotUser, gotType)
+	}
+
+	// Still valid just before it expires, not after
+	if _, _, _, err := svc.parseUnsubscribeToken(token, time.Now().Add(unsubscribeTokenTTL-time.Minute)); err != nil {
+		t.Fatalf("token rejected before expiry: %v", err)
+	}
+	if _, _, _, err := svc.parseUnsubscribeToken(token, time.Now().Add(unsubscribeTokenTTL+time.Minute)); !errors.Is(err, ErrInvalidUnsubscribeToken) {
+		t.Fatalf("expected expired token rejected, got %v", err)
+	}
+}
+
+func TestUnsubscribeTokenRejectsTampering(t *testing.T) {
+	svc := NewNotificationService(nil, nil, notificationLinks("test-secret", nil))
+	tenantID, userID := uuid.New(), uuid.New()
+	token := svc.UnsubscribeToken(tenantID, userID, NotificationTypeComment)
+	encoded, signature, _ := strings.Cut(token, ".")
+
+	// sign builds a token over arbitrary claims with the given key
+	sign := func(key []byte, claims string) string {
+		payload := base64.RawURLEncoding.EncodeToString([]byte(claims))
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(payload))
+		return payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	}
+	claims, _ := base64.RawURLEncoding.DecodeString(encoded)
+	otherUser := strings.Replace(string(claims), userID.String(), uuid.NewString(), 1)
+
+	cases := map[string]string{
+		"no signature":      encoded,
+		"other user":        base64.RawURLEncoding.EncodeToString([]byte(otherUser)) + "." + signature,
+		"flipped signature": encoded + "." + flipFirstChar(signature),
+		"other secret":      NewNotificationService(nil, nil, notificationLinks("other-secret", nil)).UnsubscribeToken(tenantID, userID, NotificationTypeComment),
+		"raw jwt secret":    sign([]byte("test-secret"), string(claims)),
+		"unknown version":   sign(svc.links.UnsubscribeKey, "0"+strings.TrimPrefix(string(claims), unsubscribeTokenVersion)),
+		"expired":           svc.signUnsubscribeToken(tenantID, userID, NotificationTypeComment, time.Now().Add(-time.Minute)),
+	}
+	for name, tampered := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Rejected before touching the database, which this service lacks
+			if err := svc.Unsubscribe(context.Background(), tampered); !errors.Is(err, ErrInvalidUnsubscribeToken) {
+				t.Fatalf("expected ErrInvalidUnsubscribeToken, got %v", err)
+			}
+		})
+	}
+}
+
+// flipFirstChar changes a base64 string in its first byte; the last character
+// may only carry padding bits
+func flipFirstChar(s string) string {
+	if s[0] == 'A' {
+		return "B" + s[1:]
+	}
+	return "A" + s[1:]
+}
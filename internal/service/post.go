@@ -8,14 +8,18 @@ import (
 	"github.com/gosimple/slug"
 
 	"github.com/nickkcj/orbit-backend/internal/database"
+	"github.com/nickkcj/orbit-backend/internal/events"
+	"github.com/nickkcj/orbit-backend/internal/outbox"
+	"github.com/nickkcj/orbit-backend/internal/worker/tasks"
 )
 
 type PostService struct {
-	db *database.Queries
+	db     *database.Queries
+	events *events.Bus
 }
 
-func NewPostService(db *database.Queries) *PostService {
-	return &PostService{db: db}
+func NewPostService(db *database.Queries, bus *events.Bus) *PostService {
+	return &PostService{db: db, events: bus}
 }
 
 type CreatePostInput struct {
@@ -89,18 +93,78 @@ func (s *PostService) Update(ctx context.Context, id uuid.UUID, input UpdatePost
 		categoryID = uuid.NullUUID{UUID: *input.CategoryID, Valid: true}
 	}
 
-	return s.db.UpdatePost(ctx, database.UpdatePostParams{
-		ID:            id,
-		Title:         input.Title,
-		Content:       sql.NullString{String: input.Content, Valid: input.Content != ""},
-		Excerpt:       sql.NullString{String: input.Excerpt, Valid: input.Excerpt != ""},
-		CoverImageUrl: sql.NullString{String: input.CoverImageURL, Valid: input.CoverImageURL != ""},
-		CategoryID:    categoryID,
+	var post database.Post
+	err := s.db.InTx(ctx, func(q *database.Queries) error {
+		var err error
+		post, err = q.UpdatePost(ctx, database.UpdatePostParams{
+			ID:            id,
+			Title:         input.Title,
+			Content:       sql.NullString{String: input.Content, Valid: input.Content != ""},
+			Excerpt:       sql.NullString{String: input.Excerpt, Valid: input.Excerpt != ""},
+			CoverImageUrl: sql.NullString{String: input.CoverImageURL, Valid: input.CoverImageURL != ""},
+			CategoryID:    categoryID,
+		})
+		if err != nil {
+			return err
+		}
+		return recordMentions(ctx, q, postMentions(post))
 	})
+	if err != nil {
+		return post, err
+	}
+
+	// Drafts are private to the author, only published posts are broadcast
+	if post.Status == "published" {
+		s.publishPostEvent(ctx, events.PostUpdated, post)
+	}
+
+	return post, nil
 }
 
+// Publish makes a post visible to the community, notifies the members it
+// mentions and announces it in realtime
 func (s *PostService) Publish(ctx context.Context, id uuid.UUID) (database.Post, error) {
-	return s.db.PublishPost(ctx, id)
+	var post database.Post
+	err := s.db.InTx(ctx, func(q *database.Queries) error {
+		previous, err := q.GetPostByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		post, err = q.PublishPost(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := recordMentions(ctx, q, postMentions(post)); err != nil {
+			return err
+		}
+
+		// Followers hear about a post once, not when it is published again
+		if previous.PublishedAt.Valid {
+			return nil
+		}
+		authorName := ""
+		if author, err := q.GetUserByID(ctx, post.AuthorID); err == nil {
+			authorName = author.Name
+		}
+		return outbox.Add(ctx, q, tasks.TypeFanOutNewPost, tasks.NewPostFanOutPayload{
+			TenantID:   post.TenantID,
+			PostID:     post.ID,
+			AuthorName: authorName,
+		})
+	})
+	if err != nil {
+		return post, err
+	}
+
+	s.publishPostEvent(ctx, events.PostCreated, post)
+
+	return post, nil
+}
+
+// postMentions is the mention source of a post body; drafts are skipped by
+// recordMentions until published
+func postMentions(post database.Post) mentionSource {
+	return mentionSource{Post: post, AuthorID: post.AuthorID, Content: post.Content.String}
 }
 
 func (s *PostService) Archive(ctx context.Context, id uuid.UUID) error {
@@ -108,7 +172,25 @@ func (s *PostService) Archive(ctx context.Context, id uuid.UUID) error {
 }
 
 func (s *PostService) Delete(ctx context.Context, id uuid.UUID) error {
-	return s.db.DeletePost(ctx, id)
+	post, err := s.db.GetPostByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.DeletePost(ctx, id); err != nil {
+		return err
+	}
+
+	// Clients never heard of drafts, so only published posts are retracted
+	if post.Status == "published" {
+		s.events.Publish(ctx, events.Event{
+			Type:     events.PostDeleted,
+			TenantID: post.TenantID,
+			Payload:  events.PostDeletedPayload{ID: post.ID},
+		})
+	}
+
+	return nil
 }
 
 func (s *PostService) IncrementViews(ctx context.Context, id uuid.UUID) error {
@@ -121,3 +203,29 @@ func (s *PostService) ListByAuthor(ctx context.Context, tenantID, authorID uuid.
 		AuthorID: authorID,
 	})
 }
+
+// publishPostEvent raises a post event enriched with the author's name
+func (s *PostService) publishPostEvent(ctx context.Context, eventType events.Type, post database.Post) {
+	authorName := ""
+	if author, err := s.db.GetUserByID(ctx, post.AuthorID); err == nil {
+		authorName = author.Name
+	}
+
+	var categoryID *uuid.UUID
+	if post.CategoryID.Valid {
+		categoryID = &post.CategoryID.UUID
+	}
+
+	s.events.Publish(ctx, events.Event{
+		Type:     eventType,
+		TenantID: post.TenantID,
+		Payload: events.PostPayload{
+			ID:         post.ID,
+			Title:      post.Title,
+			AuthorID:   post.AuthorID,
+			AuthorName: authorName,
+			CategoryID: categoryID,
+			CreatedAt:  post.CreatedAt,
+		},
+	})
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/url"
+
+	"github.com/google/uuid"
+
+	"github.com/nickkcj/orbit-backend/internal/database"
+	"github.com/nickkcj/orbit-backend/internal/push"
+)
+
+var ErrInvalidPushSubscription = errors.New("push subscription needs an https endpoint and p256dh and auth keys")
+
+// PushConfig holds the VAPID key pair and contact sent to push services
+type PushConfig struct {
+	PublicKey  string
+	PrivateKey string
+	Subject    string
+}
+
+// PushService keeps the Web Push subscriptions of each user's devices and
+// delivers to them
+type PushService struct {
+	db        *database.Queries
+	sender    push.Sender
+	publicKey string
+}
+
+func NewPushService(db *database.Queries, sender push.Sender, publicKey string) *PushService {
+	return &PushService{db: db, sender: sender, publicKey: publicKey}
+}
+
+// PublicKey is the VAPID applicationServerKey browsers subscribe with
+func (s *PushService) PublicKey() string {
+	return s.publicKey
+}
+
+type SubscribePushInput struct {
+	UserID    uuid.UUID
+	Endpoint  string
+	P256dh    string
+	Auth      string
+	UserAgent string
+}
+
+// Subscribe registers a device, or moves it to this user when it was
+// registered by someone else on the same browser
+func (s *PushService) Subscribe(ctx context.Context, input SubscribePushInput) (database.PushSubscription, error) {
+	endpoint, err := url.Parse(input.Endpoint)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" || input.P256dh == "" || input.Auth == "" {
+		return database.PushSubscription{}, ErrInvalidPushSubscription
+	}
+
+	return s.db.UpsertPushSubscription(ctx, database.UpsertPushSubscriptionParams{
+		UserID:    input.UserID,
+		Endpoint:  input.Endpoint,
+		P256dh:    input.P256dh,
+		Auth:      input.Auth,
+		UserAgent: sql.NullString{String: input.UserAgent, Valid: input.UserAgent != ""},
+	})
+}
+
+func (s *PushService) Unsubscribe(ctx context.Context, userID uuid.UUID, endpoint string) error {
+	return s.db.DeletePushSubscription(ctx, database.DeletePushSubscriptionParams{
+		Endpoint: endpoint,
+		UserID:   userID,
+	})
+}
+
+func (s *PushService) List(ctx context.Context, userID uuid.UUID) ([]database.PushSubscription, error) {
+	return s.db.ListPushSubscriptionsByUser(ctx, userID)
+}
+
+// SendToUser delivers the message to every device of the user. Subscriptions
+// the push service reports gone are deleted; the last transient error is
+// returned so the caller can retry.
+func (s *PushService) SendToUser(ctx context.Context, userID uuid.UUID, msg push.Message) (int, error) {
+	subscriptions, err := s.db.ListPushSubscriptionsByUser(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var lastErr error
+	for _, sub := range subscriptions {
+		err := s.sender.Send(ctx, push.Subscription{
+			Endpoint: sub.Endpoint,
+			P256dh:   sub.P256dh,
+			Auth:     sub.Auth,
+		}, msg)
+		switch {
+		case err == nil:
+			sent++
+			if err := s.db.TouchPushSubscription(ctx, sub.ID); err != nil {
+				log.Printf("Warning: failed to touch push subscription %s: %v", sub.ID, err)
+			}
+		case errors.Is(err, push.ErrSubscriptionGone), errors.Is(err, push.ErrInvalidKeys):
+			if err := s.db.DeletePushSubscriptionByID(ctx, sub.ID); err != nil {
+				log.Printf("Warning: failed to delete push subscription %s: %v", sub.ID, err)
+			}
+		case push.IsPermanent(err):
+			log.Printf("Warning: push to subscription %s rejected: %v", sub.ID, err)
+		default:
+			lastErr = err
+		}
+	}
+	return sent, lastErr
+}
@@ -1,10 +1,17 @@
 package service
 
 import (
+	"crypto/sha256"
+	"io"
 	"log"
+	"strings"
+
+	"golang.org/x/crypto/hkdf"
 
 	"github.com/nickkcj/orbit-backend/internal/cache"
 	"github.com/nickkcj/orbit-backend/internal/database"
+	"github.com/nickkcj/orbit-backend/internal/events"
+	"github.com/nickkcj/orbit-backend/internal/push"
 )
 
 type Services struct {
@@ -26,6 +33,12 @@ type Services struct {
 	Video        *VideoService
 	Course       *CourseService
 	Enrollment   *EnrollmentService
+	LessonChat   *LessonChatService
+	Conversation *ConversationService
+	Email        *EmailService
+	Follow       *FollowService
+	Push         *PushService
+	Digest       *DigestService
 }
 
 type StorageConfig struct {
@@ -35,24 +48,28 @@ type StorageConfig struct {
 	BucketName      string
 }
 
-func New(db *database.Queries, jwtSecret string, storageConfig *StorageConfig, streamConfig *StreamConfig, googleConfig *GoogleOAuthConfig, c cache.Cache) *Services {
+func New(db *database.Queries, jwtSecret string, storageConfig *StorageConfig, streamConfig *StreamConfig, googleConfig *GoogleOAuthConfig, sessionConfig *SessionConfig, emailConfig *EmailConfig, pushConfig *PushConfig, c cache.Cache, bus *events.Bus) *Services {
 	services := &Services{
-		Auth:         NewAuthService(db, jwtSecret, googleConfig),
-		Tenant:       NewTenantService(db),
-		User:         NewUserService(db),
-		Post:         NewPostService(db),
-		Comment:      NewCommentService(db),
+		Auth:         NewAuthService(db, jwtSecret, googleConfig, sessionConfig, emailConfig, c),
+		Tenant:       NewTenantService(db, bus),
+		User:         NewUserService(db, bus),
+		Post:         NewPostService(db, bus),
+		Comment:      NewCommentService(db, bus),
 		Category:     NewCategoryService(db),
-		Member:       NewMemberService(db),
+		Member:       NewMemberService(db, bus),
 		Role:         NewRoleService(db),
 		Webhook:      NewWebhookService(db),
-		Notification: NewNotificationService(db),
+		Notification: NewNotificationService(db, bus, notificationLinks(jwtSecret, emailConfig)),
 		Analytics:    NewAnalyticsService(db),
-		Like:         NewLikeService(db),
+		Like:         NewLikeService(db, bus),
 		Permission:   NewPermissionService(db, c),
 		Course:       NewCourseService(db),
 		Enrollment:   NewEnrollmentService(db),
+		Conversation: NewConversationService(db, bus),
+		Follow:       NewFollowService(db),
 	}
+	services.LessonChat = NewLessonChatService(db, bus, services.Enrollment)
+	services.Digest = NewDigestService(db, services.Notification)
 
 	// Initialize storage service if config provided
 	if storageConfig != nil && storageConfig.AccountID != "" {
@@ -70,6 +87,30 @@ func New(db *database.Queries, jwtSecret string, storageConfig *StorageConfig, s
 		}
 	}
 
+	if emailConfig != nil {
+		emailService, err := NewEmailService(db, newEmailTransport(emailConfig), emailConfig.From, emailConfig.BaseDomain)
+		if err != nil {
+			log.Printf("Warning: Failed to initialize email service: %v", err)
+		} else {
+			services.Email = emailService
+		}
+	}
+
+	// Initialize Web Push if VAPID keys are configured
+	if pushConfig != nil && pushConfig.PublicKey != "" && pushConfig.PrivateKey != "" {
+		sender, err := push.NewWebPushSender(push.VAPIDConfig{
+			PublicKey:  pushConfig.PublicKey,
+			PrivateKey: pushConfig.PrivateKey,
+			Subject:    pushConfig.Subject,
+		})
+		if err != nil {
+			log.Printf("Warning: Failed to initialize push service: %v", err)
+		} else {
+			services.Push = NewPushService(db, sender, pushConfig.PublicKey)
+			log.Println("Push service initialized (Web Push)")
+		}
+	}
+
 	// Initialize stream and video services if config provided
 	if streamConfig != nil && streamConfig.AccountID != "" && streamConfig.APIToken != "" {
 		stream, err := NewStreamService(streamConfig)
@@ -87,3 +128,24 @@ func New(db *database.Queries, jwtSecret string, storageConfig *StorageConfig, s
 
 	return services
 }
+
+// notificationLinks points unsubscribe links at the public API and signs them
+// with a key derived from the JWT secret, so a token of one kind can never
+// pass for the other
+func notificationLinks(secret string, emailConfig *EmailConfig) NotificationLinks {
+	links := NotificationLinks{UnsubscribeKey: deriveKey(secret, "orbit unsubscribe v1")}
+	if emailConfig != nil {
+		links.APIURL = strings.TrimSuffix(emailConfig.APIURL, "/")
+		links.BaseDomain = emailConfig.BaseDomain
+	}
+	return links
+}
+
+// deriveKey returns a 32-byte key for one purpose from a shared secret (HKDF)
+func deriveKey(secret, purpose string) []byte {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, []byte(secret), nil, []byte(purpose)), key); err != nil {
+		panic(err) // Only fails when asking for more than 255 hashes of output
+	}
+	return key
+}
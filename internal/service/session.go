@@ -0,0 +1,470 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/nickkcj/orbit-backend/internal/cache"
+	"github.com/nickkcj/orbit-backend/internal/database"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token already used")
+	ErrInvalidSession      = errors.New("session revoked or expired")
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrInvalidLoginCode    = errors.New("invalid or expired login code")
+	ErrRecentLoginRequired = errors.New("sign in again to set a password")
+)
+
+// Why a session was revoked
+const (
+	SessionRevokedLogout         = "logout"
+	SessionRevokedLogoutAll      = "logout_all"
+	SessionRevokedByUser         = "revoked"
+	SessionRevokedPasswordChange = "password_change"
+	SessionRevokedReuseDetected  = "reuse_detected"
+	SessionRevokedEmailVerified  = "email_verified"
+
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+	maxSessionDeviceLength = 255
+	loginCodeTTL           = time.Minute
+
+	// A first password can only be set this soon after signing in, so a
+	// stolen access token cannot add a password to a Google-only account
+	passwordSetupWindow = 10 * time.Minute
+)
+
+// SessionConfig sets how long access tokens and idle sessions last
+type SessionConfig struct {
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration // A session unused this long expires
+}
+
+// SessionMeta describes the client a session is opened or refreshed from
+type SessionMeta struct {
+	Device    string // Name the client gives itself, e.g. "iPhone de Ana"
+	IP        string
+	UserAgent string
+}
+
+// SessionInfo is a session as listed to its owner
+type SessionInfo struct {
+	ID         uuid.UUID `json:"id"`
+	Device     string    `json:"device,omitempty"`
+	IPAddress  string    `json:"ip_address,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// startSession opens a session for the user and returns its first access
+// and refresh tokens
+func (s *AuthService) startSession(ctx context.Context, user database.User, meta SessionMeta) (*AuthResponse, error) {
+	refreshToken, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	var session database.Session
+	err = s.db.InTx(ctx, func(q *database.Queries) error {
+		session, err = s.createSession(ctx, q, user.ID, meta)
+		if err != nil {
+			return err
+		}
+		return q.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+			TokenHash: hashToken(refreshToken),
+			SessionID: session.ID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.authResponse(user, session.ID, refreshToken)
+}
+
+// startSessionWithCode opens a session for the user and returns a one-time
+// code for it instead of tokens, for flows that end in a browser redirect
+func (s *AuthService) startSessionWithCode(ctx context.Context, user database.User, meta SessionMeta) (string, error) {
+	code, err := newOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = s.db.InTx(ctx, func(q *database.Queries) error {
+		session, err := s.createSession(ctx, q, user.ID, meta)
+		if err != nil {
+			return err
+		}
+		return q.CreateLoginCode(ctx, database.CreateLoginCodeParams{
+			CodeHash:  hashToken(code),
+			SessionID: session.ID,
+			ExpiresAt: time.Now().Add(loginCodeTTL),
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// ExchangeLoginCode spends a one-time code from startSessionWithCode and
+// returns the first access and refresh tokens of its session
+func (s *AuthService) ExchangeLoginCode(ctx context.Context, code string) (*AuthResponse, error) {
+	sessionID, err := s.db.UseLoginCode(ctx, hashToken(code))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidLoginCode
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.db.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !sessionActive(session) {
+		return nil, ErrInvalidLoginCode
+	}
+
+	user, err := s.db.GetUserByID(ctx, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status != "active" {
+		return nil, ErrInvalidLoginCode
+	}
+
+	refreshToken, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+		TokenHash: hashToken(refreshToken),
+		SessionID: session.ID,
+	}); err != nil {
+		return nil, err
+	}
+
+	return s.authResponse(user, session.ID, refreshToken)
+}
+
+// Refresh trades a refresh token for a new access and refresh token pair.
+// Each refresh token works once: presenting one that was already rotated
+// means it leaked, so the whole session is revoked.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string, meta SessionMeta) (*AuthResponse, error) {
+	hash := hashToken(refreshToken)
+	stored, err := s.db.GetRefreshToken(ctx, hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.db.GetSession(ctx, stored.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !sessionActive(session) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if stored.RotatedAt.Valid {
+		s.revokeReusedSession(ctx, session)
+		return nil, ErrRefreshTokenReused
+	}
+
+	user, err := s.db.GetUserByID(ctx, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status != "active" {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	next, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.InTx(ctx, func(q *database.Queries) error {
+		rotated, err := q.RotateRefreshToken(ctx, hash)
+		if err != nil {
+			return err
+		}
+		// Another request rotated it between the read and now
+		if rotated == 0 {
+			return ErrRefreshTokenReused
+		}
+		if err := q.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+			TokenHash: hashToken(next),
+			SessionID: session.ID,
+		}); err != nil {
+			return err
+		}
+		return q.TouchSession(ctx, database.TouchSessionParams{
+			ID:        session.ID,
+			ExpiresAt: time.Now().Add(s.refreshTokenTTL),
+			IpAddress: sql.NullString{String: meta.IP, Valid: meta.IP != ""},
+			UserAgent: sql.NullString{String: meta.UserAgent, Valid: meta.UserAgent != ""},
+		})
+	})
+	if errors.Is(err, ErrRefreshTokenReused) {
+		s.revokeReusedSession(ctx, session)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.authResponse(user, session.ID, next)
+}
+
+// VerifyAccessToken validates the token and checks that its session is still
+// active, so logging out takes effect before the token expires
+func (s *AuthService) VerifyAccessToken(ctx context.Context, tokenString string) (*JWTClaims, error) {
+	claims, err := s.ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.CheckSession(ctx, claims.UserID, claims.SessionID); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// CheckSession returns ErrInvalidSession unless the session is the user's
+// and has not been revoked or expired. Valid sessions are cached briefly so
+// authenticated requests don't each hit the database; revoking a session
+// clears its entry.
+func (s *AuthService) CheckSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	if sessionID == uuid.Nil {
+		return ErrInvalidSession
+	}
+
+	cacheKey := cache.SessionKey(userID, sessionID)
+	if s.cache != nil {
+		if valid, err := s.cache.Exists(ctx, cacheKey); err == nil && valid {
+			return nil
+		}
+	}
+
+	session, err := s.db.GetSession(ctx, sessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrInvalidSession
+	}
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID || !sessionActive(session) {
+		return ErrInvalidSession
+	}
+
+	if s.cache != nil {
+		ttl := min(time.Duration(cache.TTLSession)*time.Second, time.Until(session.ExpiresAt))
+		s.cache.Set(ctx, cacheKey, true, ttl)
+	}
+	return nil
+}
+
+// ListSessions returns the user's active sessions, most recently used first
+func (s *AuthService) ListSessions(ctx context.Context, userID, currentSessionID uuid.UUID) ([]SessionInfo, error) {
+	sessions, err := s.db.ListActiveSessionsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]SessionInfo, len(sessions))
+	for i, session := range sessions {
+		result[i] = SessionInfo{
+			ID:         session.ID,
+			Device:     session.Device.String,
+			IPAddress:  session.IpAddress.String,
+			UserAgent:  session.UserAgent.String,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID == currentSessionID,
+		}
+	}
+	return result, nil
+}
+
+// RevokeSession ends one of the user's sessions
+func (s *AuthService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID, reason string) error {
+	revoked, err := s.db.RevokeSession(ctx, database.RevokeSessionParams{
+		ID:            sessionID,
+		UserID:        userID,
+		RevokedReason: sql.NullString{String: reason, Valid: reason != ""},
+	})
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return ErrSessionNotFound
+	}
+	s.forgetSessions(ctx, userID, sessionID)
+	return nil
+}
+
+// RevokeOtherSessions ends every session of the user except keep; uuid.Nil
+// ends them all
+func (s *AuthService) RevokeOtherSessions(ctx context.Context, userID, keep uuid.UUID, reason string) (int64, error) {
+	revoked, err := s.db.RevokeUserSessions(ctx, database.RevokeUserSessionsParams{
+		Reason:        sql.NullString{String: reason, Valid: reason != ""},
+		UserID:        userID,
+		KeepSessionID: uuid.NullUUID{UUID: keep, Valid: keep != uuid.Nil},
+	})
+	if err != nil {
+		return 0, err
+	}
+	s.forgetSessions(ctx, userID, uuid.Nil)
+	return revoked, nil
+}
+
+// ChangePassword sets a new password and signs out every other session.
+// Accounts created through Google have no password and may set one without
+// the current one, but only from a session that signed in moments ago.
+func (s *AuthService) ChangePassword(ctx context.Context, userID, currentSessionID uuid.UUID, currentPassword, newPassword string) error {
+	user, err := s.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.PasswordHash != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(currentPassword)); err != nil {
+			return ErrInvalidCredentials
+		}
+	} else if err := s.checkRecentLogin(ctx, userID, currentSessionID); err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.InTx(ctx, func(q *database.Queries) error {
+		if err := q.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{
+			ID:           userID,
+			PasswordHash: string(hashedPassword),
+		}); err != nil {
+			return err
+		}
+		_, err := q.RevokeUserSessions(ctx, database.RevokeUserSessionsParams{
+			Reason:        sql.NullString{String: SessionRevokedPasswordChange, Valid: true},
+			UserID:        userID,
+			KeepSessionID: uuid.NullUUID{UUID: currentSessionID, Valid: currentSessionID != uuid.Nil},
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	s.forgetSessions(ctx, userID, uuid.Nil)
+	return nil
+}
+
+// checkRecentLogin returns ErrRecentLoginRequired unless the session is the
+// user's and was opened within passwordSetupWindow
+func (s *AuthService) checkRecentLogin(ctx context.Context, userID, sessionID uuid.UUID) error {
+	if sessionID == uuid.Nil {
+		return ErrRecentLoginRequired
+	}
+
+	session, err := s.db.GetSession(ctx, sessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrRecentLoginRequired
+	}
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID || !sessionActive(session) || time.Since(session.CreatedAt) > passwordSetupWindow {
+		return ErrRecentLoginRequired
+	}
+	return nil
+}
+
+func (s *AuthService) createSession(ctx context.Context, q *database.Queries, userID uuid.UUID, meta SessionMeta) (database.Session, error) {
+	device := meta.Device
+	if len(device) > maxSessionDeviceLength {
+		device = device[:maxSessionDeviceLength]
+	}
+
+	return q.CreateSession(ctx, database.CreateSessionParams{
+		UserID:    userID,
+		Device:    sql.NullString{String: device, Valid: device != ""},
+		IpAddress: sql.NullString{String: meta.IP, Valid: meta.IP != ""},
+		UserAgent: sql.NullString{String: meta.UserAgent, Valid: meta.UserAgent != ""},
+		ExpiresAt: time.Now().Add(s.refreshTokenTTL),
+	})
+}
+
+// forgetSessions clears the cached validity of a revoked session, or of all
+// the user's sessions when sessionID is uuid.Nil
+func (s *AuthService) forgetSessions(ctx context.Context, userID, sessionID uuid.UUID) {
+	if s.cache == nil {
+		return
+	}
+
+	var err error
+	if sessionID == uuid.Nil {
+		err = s.cache.DeletePattern(ctx, cache.UserSessionsPattern(userID))
+	} else {
+		err = s.cache.Delete(ctx, cache.SessionKey(userID, sessionID))
+	}
+	if err != nil {
+		log.Printf("Warning: failed to clear cached sessions of user %s: %v", userID, err)
+	}
+}
+
+func (s *AuthService) revokeReusedSession(ctx context.Context, session database.Session) {
+	log.Printf("Warning: refresh token reused for session %s of user %s; revoking it", session.ID, session.UserID)
+	if err := s.RevokeSession(ctx, session.UserID, session.ID, SessionRevokedReuseDetected); err != nil && !errors.Is(err, ErrSessionNotFound) {
+		log.Printf("Warning: failed to revoke session %s: %v", session.ID, err)
+	}
+}
+
+func (s *AuthService) authResponse(user database.User, sessionID uuid.UUID, refreshToken string) (*AuthResponse, error) {
+	token, expiresAt, err := s.generateToken(user, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return &AuthResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiresAt,
+		User:         user,
+	}, nil
+}
+
+func sessionActive(session database.Session) bool {
+	return !session.RevokedAt.Valid && session.ExpiresAt.After(time.Now())
+}
+
+// newOpaqueToken returns a random URL-safe token; only its hash is stored
+func newOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
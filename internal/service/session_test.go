@@ -0,0 +1,481 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/nickkcj/orbit-backend/internal/cache"
+	"github.com/nickkcj/orbit-backend/internal/database"
+)
+
+func TestRefreshRotatesToken(t *testing.T) {
+	svc, store := newTestAuthService(t)
+	user := store.addUser("")
+
+	first, err := svc.startSession(context.Background(), user, SessionMeta{Device: "laptop"})
+	if err != nil {
+		t.Fatalf("start session: %v", err)
+	}
+
+	second, err := svc.Refresh(context.Background(), first.RefreshToken, SessionMeta{IP: "10.0.0.1"})
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatalf("refresh returned the same refresh token")
+	}
+
+	third, err := svc.Refresh(context.Background(), second.RefreshToken, SessionMeta{})
+	if err != nil {
+		t.Fatalf("refresh with rotated token: %v", err)
+	}
+	if _, err := svc.VerifyAccessToken(context.Background(), third.Token); err != nil {
+		t.Fatalf("access token of refreshed session rejected: %v", err)
+	}
+}
+
+func TestRefreshReuseRevokesSession(t *testing.T) {
+	svc, store := newTestAuthService(t)
+	user := store.addUser("")
+
+	first, err := svc.startSession(context.Background(), user, SessionMeta{})
+	if err != nil {
+		t.Fatalf("start session: %v", err)
+	}
+	second, err := svc.Refresh(context.Background(), first.RefreshToken, SessionMeta{})
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	if _, err := svc.Refresh(context.Background(), first.RefreshToken, SessionMeta{}); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("expected ErrRefreshTokenReused, got %v", err)
+	}
+
+	sessionID := store.onlySession(t, user.ID)
+	if reason := store.revokedReason(sessionID); reason != SessionRevokedReuseDetected {
+		t.Fatalf("expected session revoked for reuse, got %q", reason)
+	}
+	if _, err := svc.Refresh(context.Background(), second.RefreshToken, SessionMeta{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("expected the latest token to die with the session, got %v", err)
+	}
+	if _, err := svc.VerifyAccessToken(context.Background(), second.Token); !errors.Is(err, ErrInvalidSession) {
+		t.Fatalf("expected ErrInvalidSession for access token, got %v", err)
+	}
+}
+
+func TestRevokeOtherSessionsKeepsCurrent(t *testing.T) {
+	svc, store := newTestAuthService(t)
+	user := store.addUser("")
+	other := store.addUser("")
+
+	current := startTestSession(t, svc, user)
+	stale := startTestSession(t, svc, user)
+	otherUser := startTestSession(t, svc, other)
+
+	revoked, err := svc.RevokeOtherSessions(context.Background(), user.ID, current, SessionRevokedLogoutAll)
+	if err != nil {
+		t.Fatalf("revoke other sessions: %v", err)
+	}
+	if revoked != 1 {
+		t.Fatalf("expected 1 session revoked, got %d", revoked)
+	}
+	if reason := store.revokedReason(current); reason != "" {
+		t.Fatalf("current session was revoked: %q", reason)
+	}
+	if reason := store.revokedReason(stale); reason != SessionRevokedLogoutAll {
+		t.Fatalf("expected other session revoked, got %q", reason)
+	}
+	if reason := store.revokedReason(otherUser); reason != "" {
+		t.Fatalf("another user's session was revoked: %q", reason)
+	}
+
+	if _, err := svc.RevokeOtherSessions(context.Background(), user.ID, uuid.Nil, SessionRevokedLogoutAll); err != nil {
+		t.Fatalf("revoke all sessions: %v", err)
+	}
+	if reason := store.revokedReason(current); reason != SessionRevokedLogoutAll {
+		t.Fatalf("expected uuid.Nil to revoke every session, got %q", reason)
+	}
+}
+
+func TestChangePasswordKeepsCurrentSession(t *testing.T) {
+	svc, store := newTestAuthService(t)
+	hash, err := bcrypt.GenerateFromPassword([]byte("old-password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := store.addUser(string(hash))
+
+	current := startTestSession(t, svc, user)
+	other := startTestSession(t, svc, user)
+
+	if err := svc.ChangePassword(context.Background(), user.ID, current, "wrong-password", "new-password"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected ErrInvalidCredentials, got %v", err)
+	}
+	if err := svc.ChangePassword(context.Background(), user.ID, current, "old-password", "new-password"); err != nil {
+		t.Fatalf("change password: %v", err)
+	}
+
+	if reason := store.revokedReason(current); reason != "" {
+		t.Fatalf("current session was revoked: %q", reason)
+	}
+	if reason := store.revokedReason(other); reason != SessionRevokedPasswordChange {
+		t.Fatalf("expected other session revoked, got %q", reason)
+	}
+}
+
+func TestSetFirstPasswordRequiresRecentLogin(t *testing.T) {
+	svc, store := newTestAuthService(t)
+	user := store.addUser("")
+	session := startTestSession(t, svc, user)
+
+	store.mu.Lock()
+	store.sessions[session].CreatedAt = time.Now().Add(-passwordSetupWindow - time.Minute)
+	store.mu.Unlock()
+
+	if err := svc.ChangePassword(context.Background(), user.ID, session, "", "new-password"); !errors.Is(err, ErrRecentLoginRequired) {
+		t.Fatalf("expected ErrRecentLoginRequired for an old session, got %v", err)
+	}
+	if err := svc.ChangePassword(context.Background(), user.ID, uuid.Nil, "", "new-password"); !errors.Is(err, ErrRecentLoginRequired) {
+		t.Fatalf("expected ErrRecentLoginRequired without a session, got %v", err)
+	}
+	if store.users[user.ID].PasswordHash != "" {
+		t.Fatalf("password was set without a recent login")
+	}
+
+	fresh := startTestSession(t, svc, user)
+	if err := svc.ChangePassword(context.Background(), user.ID, fresh, "", "new-password"); err != nil {
+		t.Fatalf("set password after signing in again: %v", err)
+	}
+	if store.users[user.ID].PasswordHash == "" {
+		t.Fatalf("password was not set")
+	}
+}
+
+func TestCheckSessionCachesValidSessions(t *testing.T) {
+	svc, store := newTestAuthService(t)
+	svc.cache = newMemoryCache()
+	user := store.addUser("")
+	current := startTestSession(t, svc, user)
+	other := startTestSession(t, svc, user)
+
+	for i := 0; i < 3; i++ {
+		if err := svc.CheckSession(context.Background(), user.ID, current); err != nil {
+			t.Fatalf("check session: %v", err)
+		}
+	}
+	if store.sessionLookups != 1 {
+		t.Fatalf("expected 1 session lookup, got %d", store.sessionLookups)
+	}
+	if err := svc.CheckSession(context.Background(), uuid.New(), current); !errors.Is(err, ErrInvalidSession) {
+		t.Fatalf("cached session accepted for another user: %v", err)
+	}
+
+	// Revoking clears the cache, one session or all of them
+	if err := svc.RevokeSession(context.Background(), user.ID, current, SessionRevokedLogout); err != nil {
+		t.Fatalf("revoke session: %v", err)
+	}
+	if err := svc.CheckSession(context.Background(), user.ID, current); !errors.Is(err, ErrInvalidSession) {
+		t.Fatalf("expected ErrInvalidSession after revoke, got %v", err)
+	}
+
+	if err := svc.CheckSession(context.Background(), user.ID, other); err != nil {
+		t.Fatalf("check session: %v", err)
+	}
+	if _, err := svc.RevokeOtherSessions(context.Background(), user.ID, uuid.Nil, SessionRevokedLogoutAll); err != nil {
+		t.Fatalf("revoke all sessions: %v", err)
+	}
+	if err := svc.CheckSession(context.Background(), user.ID, other); !errors.Is(err, ErrInvalidSession) {
+		t.Fatalf("expected ErrInvalidSession after revoking all, got %v", err)
+	}
+}
+
+func TestExchangeLoginCodeWorksOnce(t *testing.T) {
+	svc, store := newTestAuthService(t)
+	user := store.addUser("")
+
+	code, err := svc.startSessionWithCode(context.Background(), user, SessionMeta{})
+	if err != nil {
+		t.Fatalf("start session: %v", err)
+	}
+
+	result, err := svc.ExchangeLoginCode(context.Background(), code)
+	if err != nil {
+		t.Fatalf("exchange login code: %v", err)
+	}
+	if _, err := svc.Refresh(context.Background(), result.RefreshToken, SessionMeta{}); err != nil {
+		t.Fatalf("refresh token from login code rejected: %v", err)
+	}
+
+	if _, err := svc.ExchangeLoginCode(context.Background(), code); !errors.Is(err, ErrInvalidLoginCode) {
+		t.Fatalf("expected ErrInvalidLoginCode on reuse, got %v", err)
+	}
+}
+
+func startTestSession(t *testing.T, svc *AuthService, user database.User) uuid.UUID {
+	t.Helper()
+	result, err := svc.startSession(context.Background(), user, SessionMeta{})
+	if err != nil {
+		t.Fatalf("start session: %v", err)
+	}
+	claims, err := svc.ValidateToken(result.Token)
+	if err != nil {
+		t.Fatalf("validate token: %v", err)
+	}
+	return claims.SessionID
+}
+
+// ============================================================================
+// In-memory database
+// ============================================================================
+
+// sessionStore fakes the user and session queries
+type sessionStore struct {
+	mu         sync.Mutex
+	users      map[uuid.UUID]database.User
+	sessions   map[uuid.UUID]*database.Session
+	tokens     map[string]*database.RefreshToken
+	loginCodes map[string]database.LoginCode
+
+	sessionLookups int
+}
+
+func newTestAuthService(t *testing.T) (*AuthService, *sessionStore) {
+	t.Helper()
+	store := &sessionStore{
+		users:      make(map[uuid.UUID]database.User),
+		sessions:   make(map[uuid.UUID]*database.Session),
+		tokens:     make(map[string]*database.RefreshToken),
+		loginCodes: make(map[string]database.LoginCode),
+	}
+	return NewAuthService(openFakeDB(t, store), "test-secret", nil, nil, nil, nil), store
+}
+
+func (s *sessionStore) addUser(passwordHash string) database.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := uuid.New()
+	user := database.User{
+		ID:           id,
+		Email:        id.String() + "@example.com",
+		PasswordHash: passwordHash,
+		Name:         "Test",
+		Status:       "active",
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	s.users[id] = user
+	return user
+}
+
+func (s *sessionStore) onlySession(t *testing.T, userID uuid.UUID) uuid.UUID {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []uuid.UUID
+	for id, session := range s.sessions {
+		if session.UserID == userID {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) != 1 {
+		t.Fatalf("expected 1 session, got %d", len(ids))
+	}
+	return ids[0]
+}
+
+func (s *sessionStore) revokedReason(sessionID uuid.UUID) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessions[sessionID].RevokedReason.String
+}
+
+func (s *sessionStore) exec(name string, args []driver.Value) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+
+	switch name {
+	case "CreateRefreshToken":
+		hash := args[0].(string)
+		s.tokens[hash] = &database.RefreshToken{TokenHash: hash, SessionID: uuid.MustParse(args[1].(string)), CreatedAt: now}
+		return 1, nil
+	case "CreateLoginCode":
+		hash := args[0].(string)
+		s.loginCodes[hash] = database.LoginCode{CodeHash: hash, SessionID: uuid.MustParse(args[1].(string)), ExpiresAt: args[2].(time.Time), CreatedAt: now}
+		return 1, nil
+	case "RotateRefreshToken":
+		token, ok := s.tokens[args[0].(string)]
+		if !ok || token.RotatedAt.Valid {
+			return 0, nil
+		}
+		token.RotatedAt = sql.NullTime{Time: now, Valid: true}
+		return 1, nil
+	case "TouchSession":
+		session := s.sessions[uuid.MustParse(args[0].(string))]
+		session.LastSeenAt = now
+		session.ExpiresAt = args[1].(time.Time)
+		return 1, nil
+	case "RevokeSession":
+		session, ok := s.sessions[uuid.MustParse(args[0].(string))]
+		if !ok || session.UserID.String() != args[1].(string) || session.RevokedAt.Valid {
+			return 0, nil
+		}
+		revoke(session, args[2], now)
+		return 1, nil
+	case "RevokeUserSessions":
+		var revoked int64
+		for _, session := range s.sessions {
+			if session.UserID.String() != args[1].(string) || session.RevokedAt.Valid || session.ID.String() == args[2] {
+				continue
+			}
+			revoke(session, args[0], now)
+			revoked++
+		}
+		return revoked, nil
+	case "VerifyUserEmail":
+		id := uuid.MustParse(args[0].(string))
+		user := s.users[id]
+		user.EmailVerifiedAt = sql.NullTime{Time: now, Valid: true}
+		s.users[id] = user
+		return 1, nil
+	case "UpdateUserPassword":
+		id := uuid.MustParse(args[0].(string))
+		user := s.users[id]
+		user.PasswordHash = args[1].(string)
+		s.users[id] = user
+		return 1, nil
+	}
+	return 0, fmt.Errorf("sessionstore: unsupported exec %s", name)
+}
+
+func (s *sessionStore) query(name string, args []driver.Value) ([][]driver.Value, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch name {
+	case "CreateSession":
+		session := &database.Session{
+			ID:         uuid.New(),
+			UserID:     uuid.MustParse(args[0].(string)),
+			CreatedAt:  time.Now(),
+			LastSeenAt: time.Now(),
+			ExpiresAt:  args[4].(time.Time),
+		}
+		s.sessions[session.ID] = session
+		return [][]driver.Value{sessionRow(session)}, nil
+	case "GetSession":
+		s.sessionLookups++
+		if session, ok := s.sessions[uuid.MustParse(args[0].(string))]; ok {
+			return [][]driver.Value{sessionRow(session)}, nil
+		}
+		return nil, nil
+	case "GetRefreshToken":
+		if token, ok := s.tokens[args[0].(string)]; ok {
+			return [][]driver.Value{{token.TokenHash, token.SessionID.String(), token.CreatedAt, nullTime(token.RotatedAt)}}, nil
+		}
+		return nil, nil
+	case "GetUserByID":
+		if user, ok := s.users[uuid.MustParse(args[0].(string))]; ok {
+			return [][]driver.Value{{user.ID.String(), user.Email, user.PasswordHash, user.Name, nil, nullTime(user.EmailVerifiedAt), user.Status, user.CreatedAt, user.UpdatedAt}}, nil
+		}
+		return nil, nil
+	case "UseLoginCode":
+		code, ok := s.loginCodes[args[0].(string)]
+		if !ok || !code.ExpiresAt.After(time.Now()) {
+			return nil, nil
+		}
+		delete(s.loginCodes, code.CodeHash)
+		return [][]driver.Value{{code.SessionID.String()}}, nil
+	}
+	return nil, fmt.Errorf("sessionstore: unsupported query %s", name)
+}
+
+func revoke(session *database.Session, reason driver.Value, now time.Time) {
+	session.RevokedAt = sql.NullTime{Time: now, Valid: true}
+	if reason != nil {
+		session.RevokedReason = sql.NullString{String: reason.(string), Valid: true}
+	}
+}
+
+func sessionRow(session *database.Session) []driver.Value {
+	var reason driver.Value
+	if session.RevokedReason.Valid {
+		reason = session.RevokedReason.String
+	}
+	return []driver.Value{
+		session.ID.String(), session.UserID.String(), nil, nil, nil,
+		session.CreatedAt, session.LastSeenAt, session.ExpiresAt,
+		nullTime(session.RevokedAt), reason,
+	}
+}
+
+// memoryCache is a cache.Cache over a map; entries never expire
+type memoryCache struct {
+	mu      sync.Mutex
+	entries map[string][]byte
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{entries: make(map[string][]byte)}
+}
+
+func (m *memoryCache) Get(ctx context.Context, key string, dest interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.entries[key]
+	if !ok {
+		return &cache.ErrCacheMiss{Key: key}
+	}
+	return json.Unmarshal(data, dest)
+}
+
+func (m *memoryCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = data
+	return nil
+}
+
+func (m *memoryCache) Delete(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		delete(m.entries, key)
+	}
+	return nil
+}
+
+func (m *memoryCache) DeletePattern(ctx context.Context, pattern string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key := range m.entries {
+		if matched, _ := path.Match(pattern, key); matched {
+			delete(m.entries, key)
+		}
+	}
+	return nil
+}
+
+func (m *memoryCache) Exists(ctx context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.entries[key]
+	return ok, nil
+}
+
+func (m *memoryCache) Close() error { return nil }
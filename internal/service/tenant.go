@@ -7,15 +7,17 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/nickkcj/orbit-backend/internal/database"
+	"github.com/nickkcj/orbit-backend/internal/events"
 	"github.com/sqlc-dev/pqtype"
 )
 
 type TenantService struct {
-	db *database.Queries
+	db     *database.Queries
+	events *events.Bus
 }
 
-func NewTenantService(db *database.Queries) *TenantService {
-	return &TenantService{db: db}
+func NewTenantService(db *database.Queries, bus *events.Bus) *TenantService {
+	return &TenantService{db: db, events: bus}
 }
 
 func (s *TenantService) GetBySlug(ctx context.Context, slug string) (database.Tenant, error) {
@@ -40,7 +42,8 @@ func (s *TenantService) List(ctx context.Context) ([]database.Tenant, error) {
 
 // TenantSettings represents the settings structure stored in JSONB
 type TenantSettings struct {
-	Theme *ThemeSettings `json:"theme,omitempty"`
+	Theme                *ThemeSettings `json:"theme,omitempty"`
+	RequireVerifiedEmail bool           `json:"requireVerifiedEmail,omitempty"` // Before posting, commenting, enrolling or messaging
 }
 
 type ThemeSettings struct {
@@ -49,6 +52,16 @@ type ThemeSettings struct {
 	BannerURL    string `json:"bannerUrl,omitempty"`
 }
 
+// ParseTenantSettings decodes the tenant's settings; missing or malformed
+// settings read as the defaults
+func ParseTenantSettings(tenant database.Tenant) TenantSettings {
+	var settings TenantSettings
+	if tenant.Settings.Valid {
+		_ = json.Unmarshal(tenant.Settings.RawMessage, &settings)
+	}
+	return settings
+}
+
 func (s *TenantService) UpdateSettings(ctx context.Context, tenantID uuid.UUID, settings TenantSettings) (database.Tenant, error) {
 	settingsJSON, err := json.Marshal(settings)
 	if err != nil {
@@ -69,5 +82,32 @@ func (s *TenantService) UpdateLogo(ctx context.Context, tenantID uuid.UUID, logo
 }
 
 func (s *TenantService) Delete(ctx context.Context, tenantID uuid.UUID) error {
-	return s.db.DeleteTenant(ctx, tenantID)
+	if err := s.db.DeleteTenant(ctx, tenantID); err != nil {
+		return err
+	}
+
+	s.publishStatus(ctx, tenantID, "deleted")
+	return nil
+}
+
+// UpdateStatus activates or suspends a tenant. Live connections to a tenant
+// that is no longer active are closed.
+func (s *TenantService) UpdateStatus(ctx context.Context, tenantID uuid.UUID, status string) error {
+	if err := s.db.UpdateTenantStatus(ctx, database.UpdateTenantStatusParams{
+		ID:     tenantID,
+		Status: status,
+	}); err != nil {
+		return err
+	}
+
+	s.publishStatus(ctx, tenantID, status)
+	return nil
+}
+
+func (s *TenantService) publishStatus(ctx context.Context, tenantID uuid.UUID, status string) {
+	s.events.Publish(ctx, events.Event{
+		Type:     events.TenantStatusChanged,
+		TenantID: tenantID,
+		Payload:  events.TenantStatusPayload{Status: status},
+	})
 }
@@ -8,14 +8,16 @@ import (
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/nickkcj/orbit-backend/internal/database"
+	"github.com/nickkcj/orbit-backend/internal/events"
 )
 
 type UserService struct {
-	db *database.Queries
+	db     *database.Queries
+	events *events.Bus
 }
 
-func NewUserService(db *database.Queries) *UserService {
-	return &UserService{db: db}
+func NewUserService(db *database.Queries, bus *events.Bus) *UserService {
+	return &UserService{db: db, events: bus}
 }
 
 func (s *UserService) Create(ctx context.Context, email, password, name string) (database.User, error) {
@@ -56,3 +58,21 @@ func (s *UserService) UpdateAvatar(ctx context.Context, userID uuid.UUID, avatar
 		AvatarUrl: avatar,
 	})
 }
+
+// UpdateStatus activates or suspends a user account across all tenants. Live
+// connections of a user that is no longer active are closed.
+func (s *UserService) UpdateStatus(ctx context.Context, userID uuid.UUID, status string) error {
+	if err := s.db.UpdateUserStatus(ctx, database.UpdateUserStatusParams{
+		ID:     userID,
+		Status: status,
+	}); err != nil {
+		return err
+	}
+
+	s.events.Publish(ctx, events.Event{
+		Type:    events.UserStatusChanged,
+		UserID:  &userID,
+		Payload: events.UserStatusPayload{UserID: userID, Status: status},
+	})
+	return nil
+}
@@ -3,13 +3,24 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/nickkcj/orbit-backend/internal/database"
 )
 
+// reconcileBatchSize bounds how many stuck videos a sweep polls
+const reconcileBatchSize = 100
+
+var (
+	ErrVideoProcessing   = errors.New("video is still processing")
+	ErrVideoNotUploaded  = errors.New("video has no stream upload")
+	ErrStreamUIDMismatch = errors.New("stream_uid does not match the upload")
+)
+
 // VideoService handles video operations
 type VideoService struct {
 	db     *database.Queries
@@ -70,9 +81,10 @@ func (s *VideoService) InitiateUpload(ctx context.Context, req *VideoUploadReque
 	}
 
 	// Update video with external ID
-	_, err = s.db.UpdateVideoStatus(ctx, database.UpdateVideoStatusParams{
-		ID:     video.ID,
-		Status: "uploading",
+	_, err = s.db.SetVideoExternalID(ctx, database.SetVideoExternalIDParams{
+		ID:         video.ID,
+		ExternalID: sql.NullString{String: directUpload.UID, Valid: true},
+		Status:     "uploading",
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to update video status: %w", err)
@@ -201,37 +213,140 @@ func (s *VideoService) ProcessWebhook(ctx context.Context, payload *StreamWebhoo
 
 	if payload.ReadyToStream {
 		// Video is ready
-		_, err = s.db.UpdateVideoAfterProcessing(ctx, database.UpdateVideoAfterProcessingParams{
-			ID:              video.ID,
-			ExternalID:      sql.NullString{String: payload.UID, Valid: true},
-			PlaybackUrl:     sql.NullString{String: payload.Playback.HLS, Valid: true},
-			ThumbnailUrl:    sql.NullString{String: payload.Thumbnail, Valid: true},
-			DurationSeconds: sql.NullInt32{Int32: int32(payload.Duration), Valid: true},
-			Resolution:      sql.NullString{}, // Can be extracted from metadata if needed
-		})
-		if err != nil {
-			return fmt.Errorf("failed to update video: %w", err)
-		}
+		return s.markReady(ctx, video.ID, payload.UID, payload.Playback.HLS, payload.Thumbnail, payload.Duration)
 	} else if payload.Status.State == "error" {
 		// Video processing failed
-		_, err = s.db.UpdateVideoStatus(ctx, database.UpdateVideoStatusParams{
-			ID:           video.ID,
-			Status:       "failed",
-			ErrorMessage: sql.NullString{String: payload.Status.ErrorReasonText, Valid: true},
-		})
-		if err != nil {
-			return fmt.Errorf("failed to update video status: %w", err)
-		}
+		return s.markFailed(ctx, video.ID, payload.Status.ErrorReasonText)
 	}
 
 	return nil
 }
 
-// UpdateExternalID updates the external ID after client-side upload completes
+// UpdateExternalID stores the stream UID after client-side upload completes.
+// A UID recorded when the upload was initiated must match.
 func (s *VideoService) UpdateExternalID(ctx context.Context, videoID uuid.UUID, streamUID string) error {
-	_, err := s.db.UpdateVideoStatus(ctx, database.UpdateVideoStatusParams{
-		ID:     videoID,
-		Status: "processing",
+	video, err := s.db.GetVideoByID(ctx, videoID)
+	if err != nil {
+		return err
+	}
+	if video.ExternalID.Valid && video.ExternalID.String != streamUID {
+		return ErrStreamUIDMismatch
+	}
+	// Nothing to do if a webhook or poll got there first
+	if video.Status == "ready" || video.Status == "failed" {
+		return nil
+	}
+
+	_, err = s.db.SetVideoExternalID(ctx, database.SetVideoExternalIDParams{
+		ID:         videoID,
+		ExternalID: sql.NullString{String: streamUID, Valid: true},
+		Status:     "processing",
 	})
 	return err
 }
+
+// Reconcile polls the stream provider and brings the video row up to date.
+// It returns ErrVideoProcessing while the provider is still working on it.
+func (s *VideoService) Reconcile(ctx context.Context, videoID uuid.UUID) error {
+	video, err := s.db.GetVideoByID(ctx, videoID)
+	if err != nil {
+		return err
+	}
+	if video.Status == "ready" || video.Status == "failed" {
+		return nil
+	}
+	if !video.ExternalID.Valid || video.ExternalID.String == "" {
+		return ErrVideoNotUploaded
+	}
+	if s.stream == nil {
+		return fmt.Errorf("stream service not configured")
+	}
+
+	streamVideo, err := s.stream.GetVideo(ctx, video.ExternalID.String)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case streamVideo == nil:
+		return s.markFailed(ctx, video.ID, "video not found on stream provider")
+	case streamVideo.ReadyToStream:
+		return s.markReady(ctx, video.ID, streamVideo.UID, streamVideo.Playback.HLS, streamVideo.Thumbnail, streamVideo.Duration)
+	case streamVideo.Status.State == "error":
+		return s.markFailed(ctx, video.ID, streamVideo.Status.ErrorReasonText)
+	case streamVideo.Status.State == "queued" || streamVideo.Status.State == "inprogress":
+		// The upload finished even if the client never confirmed it
+		if video.Status != "processing" {
+			if _, err := s.db.UpdateVideoStatus(ctx, database.UpdateVideoStatusParams{
+				ID:     video.ID,
+				Status: "processing",
+			}); err != nil {
+				return fmt.Errorf("failed to update video status: %w", err)
+			}
+		}
+	}
+
+	return ErrVideoProcessing
+}
+
+// ReconcileStuck polls the provider for videos that have been uploading or
+// processing since before the cutoff, in case their webhook was missed. Each
+// attempt is recorded so videos that stay stuck go to the back of the line.
+func (s *VideoService) ReconcileStuck(ctx context.Context, before time.Time) (int, error) {
+	videoIDs, err := s.db.ListStuckVideos(ctx, database.ListStuckVideosParams{
+		UpdatedAt: before,
+		Limit:     reconcileBatchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	settled := 0
+	for _, videoID := range videoIDs {
+		if err := s.db.MarkVideoReconciled(ctx, videoID); err != nil {
+			log.Printf("Warning: failed to record reconcile of video %s: %v", videoID, err)
+		}
+
+		err := s.Reconcile(ctx, videoID)
+		switch {
+		case err == nil:
+			settled++
+		case errors.Is(err, ErrVideoProcessing):
+		default:
+			log.Printf("Warning: failed to reconcile video %s: %v", videoID, err)
+		}
+	}
+	return settled, nil
+}
+
+func (s *VideoService) markReady(ctx context.Context, videoID uuid.UUID, streamUID, playbackURL, thumbnailURL string, duration float64) error {
+	_, err := s.db.UpdateVideoAfterProcessing(ctx, database.UpdateVideoAfterProcessingParams{
+		ID:              videoID,
+		ExternalID:      sql.NullString{String: streamUID, Valid: true},
+		PlaybackUrl:     sql.NullString{String: playbackURL, Valid: true},
+		ThumbnailUrl:    sql.NullString{String: thumbnailURL, Valid: true},
+		DurationSeconds: sql.NullInt32{Int32: int32(duration), Valid: true},
+		Resolution:      sql.NullString{}, // Can be extracted from metadata if needed
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update video: %w", err)
+	}
+	return nil
+}
+
+func (s *VideoService) markFailed(ctx context.Context, videoID uuid.UUID, reason string) error {
+	_, err := s.db.UpdateVideoStatus(ctx, database.UpdateVideoStatusParams{
+		ID:           videoID,
+		Status:       "failed",
+		ErrorMessage: sql.NullString{String: reason, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update video status: %w", err)
+	}
+	return nil
+}
+
+// ExpireStale fails videos whose upload never completed
+func (s *VideoService) ExpireStale(ctx context.Context, before time.Time) (int64, error) {
+	return s.db.ExpireStaleVideos(ctx, before)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/nickkcj/orbit-backend/internal/database"
@@ -90,3 +91,8 @@ func (s *WebhookService) MarkFailed(ctx context.Context, id uuid.UUID, errorMsg
 		ErrorMessage: sql.NullString{String: errorMsg, Valid: errorMsg != ""},
 	})
 }
+
+// Prune deletes webhook events received before the cutoff
+func (s *WebhookService) Prune(ctx context.Context, before time.Time) (int64, error) {
+	return s.db.DeleteWebhookEventsBefore(ctx, before)
+}
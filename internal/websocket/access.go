@@ -0,0 +1,171 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"nhooyr.io/websocket"
+
+	"github.com/nickkcj/orbit-backend/internal/events"
+	"github.com/nickkcj/orbit-backend/internal/service"
+)
+
+// Close codes sent when the server ends a session because access changed
+const (
+	StatusSessionEnded     websocket.StatusCode = 4001
+	StatusMembershipEnded  websocket.StatusCode = 4003
+	StatusTenantSuspended  websocket.StatusCode = 4005
+	StatusAccountSuspended websocket.StatusCode = 4006
+)
+
+// Access change actions
+const (
+	AccessDisconnect  = "disconnect"
+	AccessReauthorize = "reauthorize"
+)
+
+// AccessChange tells the hub to close or re-check the sessions of a user in a
+// tenant, every session of a user (zero TenantID) or a whole tenant (zero UserID)
+type AccessChange struct {
+	Action   string               `json:"action"`
+	TenantID uuid.UUID            `json:"tenant_id,omitempty"`
+	UserID   uuid.UUID            `json:"user_id,omitempty"`
+	Code     websocket.StatusCode `json:"code,omitempty"`
+	Reason   string               `json:"reason,omitempty"`
+}
+
+// accessChange maps membership, role and status events onto the sessions they
+// affect. Reactivations need no action since access is checked on connect.
+func accessChange(event events.Event) (*AccessChange, bool) {
+	switch p := event.Payload.(type) {
+	case events.MemberStatusPayload:
+		if p.Status == "active" {
+			return nil, false
+		}
+		return &AccessChange{Action: AccessDisconnect, TenantID: event.TenantID, UserID: p.UserID, Code: StatusMembershipEnded, Reason: "membership " + p.Status}, true
+	case events.MemberRemovedPayload:
+		return &AccessChange{Action: AccessDisconnect, TenantID: event.TenantID, UserID: p.UserID, Code: StatusMembershipEnded, Reason: "membership removed"}, true
+	case events.MemberRolePayload:
+		return &AccessChange{Action: AccessReauthorize, TenantID: event.TenantID, UserID: p.UserID}, true
+	case events.TenantStatusPayload:
+		if p.Status == "active" {
+			return nil, false
+		}
+		return &AccessChange{Action: AccessDisconnect, TenantID: event.TenantID, Code: StatusTenantSuspended, Reason: "tenant " + p.Status}, true
+	case events.UserStatusPayload:
+		if p.Status == "active" {
+			return nil, false
+		}
+		return &AccessChange{Action: AccessDisconnect, UserID: p.UserID, Code: StatusAccountSuspended, Reason: "account " + p.Status}, true
+	default:
+		return nil, false
+	}
+}
+
+// applyAccess closes or re-checks the local sessions matched by a change
+func (h *Hub) applyAccess(change *AccessChange) {
+	var clients []*Client
+	if change.UserID != uuid.Nil {
+		for _, client := range h.snapshot(h.userChannels, change.UserID) {
+			if change.TenantID == uuid.Nil || client.tenantID == change.TenantID {
+				clients = append(clients, client)
+			}
+		}
+	} else {
+		clients = h.snapshot(h.tenantRooms, change.TenantID)
+	}
+
+	for _, client := range clients {
+		switch change.Action {
+		case AccessDisconnect:
+			log.Printf("[WS] Closing session user=%s tenant=%s: %s", client.userID, client.tenantID, change.Reason)
+			client.disconnect(change.Code, change.Reason)
+		case AccessReauthorize:
+			go client.reauthorize()
+		}
+	}
+}
+
+// tryApplyAccess queues an access change without blocking the event publisher
+func (h *Hub) tryApplyAccess(change *AccessChange) {
+	select {
+	case h.access <- change:
+	default:
+		log.Printf("[WS] Access buffer full, dropping %s for tenant=%s user=%s", change.Action, change.TenantID, change.UserID)
+	}
+}
+
+// disconnect ends the session with a close code the client can act on. SSE
+// streams get a final disconnected event instead.
+func (c *Client) disconnect(code websocket.StatusCode, reason string) {
+	c.closeOnce.Do(func() {
+		c.closeCode = code
+		c.closeReason = reason
+
+		if c.conn == nil {
+			c.cancel()
+			return
+		}
+		// Close before cancelling: a cancelled read drops the connection
+		// without sending the close frame
+		go func() {
+			c.conn.Close(code, reason)
+			c.cancel()
+		}()
+	})
+}
+
+// sessionEnded reports whether the login session the client connected with
+// was revoked or expired. A failed lookup keeps the connection open.
+func (c *Client) sessionEnded() bool {
+	if c.sessions == nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, 5*time.Second)
+	defer cancel()
+
+	err := c.sessions.authService.CheckSession(ctx, c.userID, c.sessionID)
+	if errors.Is(err, service.ErrInvalidSession) {
+		return true
+	}
+	if err != nil {
+		log.Printf("[WS] Failed to check session of user=%s: %v", c.userID, err)
+	}
+	return false
+}
+
+// reauthorize re-checks the client's topic subscriptions after a role change
+// and drops the ones it may no longer see
+func (c *Client) reauthorize() {
+	if c.authorizer == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, 5*time.Second)
+	defer cancel()
+
+	c.authorizer.invalidate(ctx, c.tenantID, c.userID)
+
+	for _, name := range c.hub.clientTopics(c) {
+		topic, err := ParseTopic(name)
+		if err != nil {
+			continue
+		}
+		allowed, err := c.authorizer.CanSubscribe(ctx, c.tenantID, c.userID, topic)
+		if err != nil {
+			log.Printf("[WS] Failed to reauthorize topic %s for user=%s: %v", name, c.userID, err)
+			continue
+		}
+		if allowed {
+			continue
+		}
+
+		c.hub.unsubscribe(c, topic)
+		msg, _ := NewMessage(MessageTypeUnsubscribed, SubscriptionPayload{Topic: name})
+		c.Send(msg)
+	}
+}
@@ -0,0 +1,104 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nickkcj/orbit-backend/internal/events"
+)
+
+func expectDisconnected(t *testing.T, client *Client, code int) {
+	t.Helper()
+	select {
+	case <-client.ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("client was not disconnected")
+	}
+	if int(client.closeCode) != code {
+		t.Fatalf("expected close code %d, got %d", code, client.closeCode)
+	}
+}
+
+func expectConnected(t *testing.T, client *Client) {
+	t.Helper()
+	select {
+	case <-client.ctx.Done():
+		t.Fatalf("client was disconnected with %d", client.closeCode)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestMemberBanClosesSessionsOnAllNodes(t *testing.T) {
+	nodeA, nodeB := startHubs(t, NewMemoryBackplane())
+	tenantID, otherTenantID := uuid.New(), uuid.New()
+	userID := uuid.New()
+
+	local := newTestClient(nodeA, tenantID, userID)
+	remote := newTestClient(nodeB, tenantID, userID)
+	elsewhere := newTestClient(nodeB, otherTenantID, userID)
+	nodeA.Register(local)
+	nodeB.Register(remote)
+	nodeB.Register(elsewhere)
+
+	nodeA.HandleEvent(context.Background(), events.Event{
+		Type:     events.MemberStatusChanged,
+		TenantID: tenantID,
+		UserID:   &userID,
+		Payload:  events.MemberStatusPayload{UserID: userID, Status: "banned"},
+	})
+
+	expectDisconnected(t, local, int(StatusMembershipEnded))
+	expectDisconnected(t, remote, int(StatusMembershipEnded))
+	expectConnected(t, elsewhere)
+}
+
+func TestTenantSuspensionClosesTenantRoom(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hub := NewHub()
+	go hub.Run(ctx)
+
+	tenantID := uuid.New()
+	first := newTestClient(hub, tenantID, uuid.New())
+	second := newTestClient(hub, tenantID, uuid.New())
+	other := newTestClient(hub, uuid.New(), uuid.New())
+	hub.Register(first)
+	hub.Register(second)
+	hub.Register(other)
+
+	hub.HandleEvent(ctx, events.Event{
+		Type:     events.TenantStatusChanged,
+		TenantID: tenantID,
+		Payload:  events.TenantStatusPayload{Status: "suspended"},
+	})
+
+	expectDisconnected(t, first, int(StatusTenantSuspended))
+	expectDisconnected(t, second, int(StatusTenantSuspended))
+	expectConnected(t, other)
+}
+
+func TestReactivationKeepsSessions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hub := NewHub()
+	go hub.Run(ctx)
+
+	tenantID, userID := uuid.New(), uuid.New()
+	client := newTestClient(hub, tenantID, userID)
+	hub.Register(client)
+
+	hub.HandleEvent(ctx, events.Event{
+		Type:     events.MemberStatusChanged,
+		TenantID: tenantID,
+		UserID:   &userID,
+		Payload:  events.MemberStatusPayload{UserID: userID, Status: "active"},
+	})
+
+	expectConnected(t, client)
+	expectNoMessage(t, client)
+}
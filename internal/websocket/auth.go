@@ -2,9 +2,11 @@ package websocket
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 
 	"github.com/google/uuid"
+	"github.com/nickkcj/orbit-backend/internal/database"
 	"github.com/nickkcj/orbit-backend/internal/service"
 )
 
@@ -15,31 +17,50 @@ var (
 	ErrInvalidTenant  = errors.New("invalid tenant")
 	ErrInactiveTenant = errors.New("tenant is not active")
 	ErrInactiveUser   = errors.New("user account is not active")
+	ErrInactiveMember = errors.New("membership is not active")
+	ErrNotMember      = errors.New("not a member of this tenant")
 )
 
 // AuthResult contains the authenticated user and tenant info
 type AuthResult struct {
-	UserID   uuid.UUID
-	TenantID uuid.UUID
+	UserID     uuid.UUID
+	TenantID   uuid.UUID
+	TenantSlug string
+	SessionID  uuid.UUID // Login session, re-checked while connected
+}
+
+// MemberLookup finds a user's membership in a tenant, returning sql.ErrNoRows
+// when there is none
+type MemberLookup interface {
+	Get(ctx context.Context, tenantID, userID uuid.UUID) (database.TenantMember, error)
 }
 
 // Authenticator handles WebSocket authentication
 type Authenticator struct {
 	authService   *service.AuthService
 	tenantService *service.TenantService
+	members       MemberLookup
+	tickets       TicketStore
 }
 
 // NewAuthenticator creates a new WebSocket authenticator
-func NewAuthenticator(authService *service.AuthService, tenantService *service.TenantService) *Authenticator {
+func NewAuthenticator(authService *service.AuthService, tenantService *service.TenantService, memberService *service.MemberService) *Authenticator {
 	return &Authenticator{
 		authService:   authService,
 		tenantService: tenantService,
+		members:       memberService,
+		tickets:       NewMemoryTicketStore(),
 	}
 }
 
-// Authenticate validates token and tenant for WebSocket connection
-// Token can come from: query param (?token=xxx) or cookie
-// Tenant can come from: query param (?tenant=slug) or X-Tenant-Slug header
+// UseTicketStore replaces the in-memory ticket store, typically with a shared
+// one when running several instances
+func (a *Authenticator) UseTicketStore(tickets TicketStore) {
+	a.tickets = tickets
+}
+
+// Authenticate validates the auth cookie and tenant for a same-site WebSocket
+// connection. Tenant can come from: query param (?tenant=slug) or X-Tenant-Slug header
 func (a *Authenticator) Authenticate(ctx context.Context, token, tenantSlug string) (*AuthResult, error) {
 	if token == "" {
 		return nil, ErrMissingToken
@@ -49,31 +70,96 @@ func (a *Authenticator) Authenticate(ctx context.Context, token, tenantSlug stri
 	}
 
 	// Validate JWT token
-	claims, err := a.authService.ValidateToken(token)
+	claims, err := a.authService.VerifyAccessToken(ctx, token)
 	if err != nil {
 		return nil, ErrInvalidToken
 	}
 
-	// Verify user is active
-	user, err := a.authService.GetUserByID(ctx, claims.UserID)
+	// Validate tenant
+	tenant, err := a.tenantService.GetBySlug(ctx, tenantSlug)
 	if err != nil {
-		return nil, ErrInvalidToken
+		return nil, ErrInvalidTenant
 	}
-	if user.Status != "active" {
-		return nil, ErrInactiveUser
+
+	return a.verify(ctx, claims.UserID, tenant, claims.SessionID)
+}
+
+// IssueTicket creates a single-use ticket for opening a WebSocket as the user
+// in the tenant. The login session is carried over so the connection still
+// ends when that session is revoked.
+func (a *Authenticator) IssueTicket(ctx context.Context, userID, tenantID, sessionID uuid.UUID) (string, error) {
+	id, err := newTicketID()
+	if err != nil {
+		return "", err
 	}
 
-	// Validate tenant
-	tenant, err := a.tenantService.GetBySlug(ctx, tenantSlug)
+	ticket := Ticket{UserID: userID, TenantID: tenantID, SessionID: sessionID}
+	if err := a.tickets.Save(ctx, id, ticket, ticketTTL); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// AuthenticateTicket redeems a ticket and re-checks that the user may still
+// connect to the tenant
+func (a *Authenticator) AuthenticateTicket(ctx context.Context, id string) (*AuthResult, error) {
+	if id == "" {
+		return nil, ErrMissingToken
+	}
+
+	ticket, err := a.tickets.Take(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant, err := a.tenantService.GetByID(ctx, ticket.TenantID)
 	if err != nil {
 		return nil, ErrInvalidTenant
 	}
-	if tenant.Status != "active" {
-		return nil, ErrInactiveTenant
+	if err := a.authService.CheckSession(ctx, ticket.UserID, ticket.SessionID); err != nil {
+		return nil, ErrInvalidToken
+	}
+	return a.verify(ctx, ticket.UserID, tenant, ticket.SessionID)
+}
+
+// verify checks that the user, the tenant and the user's membership are active
+func (a *Authenticator) verify(ctx context.Context, userID uuid.UUID, tenant database.Tenant, sessionID uuid.UUID) (*AuthResult, error) {
+	user, err := a.authService.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if err := a.authorize(ctx, user, tenant); err != nil {
+		return nil, err
 	}
 
 	return &AuthResult{
-		UserID:   user.ID,
-		TenantID: tenant.ID,
+		UserID:     user.ID,
+		TenantID:   tenant.ID,
+		TenantSlug: tenant.Slug,
+		SessionID:  sessionID,
 	}, nil
 }
+
+// authorize checks that the user and the tenant are active and that the user
+// is an active member. Tenant events are for members only, so users who were
+// never members or whose membership was removed are kept out too.
+func (a *Authenticator) authorize(ctx context.Context, user database.User, tenant database.Tenant) error {
+	if user.Status != "active" {
+		return ErrInactiveUser
+	}
+	if tenant.Status != "active" {
+		return ErrInactiveTenant
+	}
+
+	member, err := a.members.Get(ctx, tenant.ID, user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotMember
+	}
+	if err != nil {
+		return err
+	}
+	if member.Status != "active" {
+		return ErrInactiveMember
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package websocket
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/nickkcj/orbit-backend/internal/database"
+	"github.com/nickkcj/orbit-backend/internal/events"
+)
+
+// fakeMembers is a MemberLookup over an in-memory membership list
+type fakeMembers struct {
+	mu      sync.Mutex
+	members map[[2]uuid.UUID]database.TenantMember
+}
+
+func newFakeMembers() *fakeMembers {
+	return &fakeMembers{members: make(map[[2]uuid.UUID]database.TenantMember)}
+}
+
+func (f *fakeMembers) set(tenantID, userID uuid.UUID, status string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.members[[2]uuid.UUID{tenantID, userID}] = database.TenantMember{TenantID: tenantID, UserID: userID, Status: status}
+}
+
+func (f *fakeMembers) remove(tenantID, userID uuid.UUID) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.members, [2]uuid.UUID{tenantID, userID})
+}
+
+func (f *fakeMembers) Get(ctx context.Context, tenantID, userID uuid.UUID) (database.TenantMember, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	member, ok := f.members[[2]uuid.UUID{tenantID, userID}]
+	if !ok {
+		return database.TenantMember{}, sql.ErrNoRows
+	}
+	return member, nil
+}
+
+func TestAuthorizeRequiresActiveMembership(t *testing.T) {
+	members := newFakeMembers()
+	auth := &Authenticator{members: members}
+	tenant := database.Tenant{ID: uuid.New(), Status: "active"}
+	user := database.User{ID: uuid.New(), Status: "active"}
+
+	if err := auth.authorize(context.Background(), user, tenant); !errors.Is(err, ErrNotMember) {
+		t.Fatalf("expected ErrNotMember for a non-member, got %v", err)
+	}
+
+	members.set(tenant.ID, user.ID, "banned")
+	if err := auth.authorize(context.Background(), user, tenant); !errors.Is(err, ErrInactiveMember) {
+		t.Fatalf("expected ErrInactiveMember for a banned member, got %v", err)
+	}
+
+	members.set(tenant.ID, user.ID, "active")
+	if err := auth.authorize(context.Background(), user, tenant); err != nil {
+		t.Fatalf("active member rejected: %v", err)
+	}
+}
+
+func TestReconnectAfterMemberRemovedFails(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hub := NewHub()
+	go hub.Run(ctx)
+
+	members := newFakeMembers()
+	auth := &Authenticator{members: members}
+	tenant := database.Tenant{ID: uuid.New(), Status: "active"}
+	user := database.User{ID: uuid.New(), Status: "active"}
+	members.set(tenant.ID, user.ID, "active")
+
+	if err := auth.authorize(ctx, user, tenant); err != nil {
+		t.Fatalf("member rejected: %v", err)
+	}
+	client := newTestClient(hub, tenant.ID, user.ID)
+	hub.Register(client)
+
+	members.remove(tenant.ID, user.ID)
+	hub.HandleEvent(ctx, events.Event{
+		Type:     events.MemberRemoved,
+		TenantID: tenant.ID,
+		UserID:   &user.ID,
+		Payload:  events.MemberRemovedPayload{UserID: user.ID},
+	})
+	expectDisconnected(t, client, int(StatusMembershipEnded))
+
+	if err := auth.authorize(ctx, user, tenant); !errors.Is(err, ErrNotMember) {
+		t.Fatalf("expected reconnect to fail with ErrNotMember, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package websocket
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Envelope kinds relayed over the backplane
+const (
+	EnvelopeBroadcast = "broadcast"
+	EnvelopeDirect    = "direct"
+	EnvelopeTopic     = "topic"
+	EnvelopeAccess    = "access"
+)
+
+// Envelope wraps a hub message so it can be relayed between API instances
+type Envelope struct {
+	ID       uuid.UUID `json:"id"`
+	NodeID   string    `json:"node_id"`
+	Kind     string    `json:"kind"`
+	TenantID uuid.UUID `json:"tenant_id"`
+	UserID   uuid.UUID `json:"user_id,omitempty"`
+	Topic    string    `json:"topic,omitempty"`
+	Message  *Message  `json:"message,omitempty"`
+
+	// Set on access envelopes, which carry no message
+	Access *AccessChange `json:"access,omitempty"`
+}
+
+// Backplane relays hub messages between API instances so that clients
+// connected to one node receive events raised on another
+type Backplane interface {
+	// Publish sends an envelope to every node (including the sender)
+	Publish(ctx context.Context, env *Envelope) error
+
+	// Subscribe delivers envelopes from all nodes until ctx is cancelled
+	Subscribe(ctx context.Context, handler func(*Envelope)) error
+
+	// Close releases backplane resources
+	Close() error
+}
+
+// MemoryBackplane is an in-process Backplane. Hubs sharing one instance behave
+// like separate nodes, which makes it useful for tests and single-node setups.
+type MemoryBackplane struct {
+	handlers map[int]func(*Envelope)
+	nextID   int
+	mu       sync.RWMutex
+}
+
+// NewMemoryBackplane creates a new in-memory backplane
+func NewMemoryBackplane() *MemoryBackplane {
+	return &MemoryBackplane{
+		handlers: make(map[int]func(*Envelope)),
+	}
+}
+
+// Publish delivers the envelope to every subscriber synchronously
+func (b *MemoryBackplane) Publish(ctx context.Context, env *Envelope) error {
+	b.mu.RLock()
+	handlers := make([]func(*Envelope), 0, len(b.handlers))
+	for _, handler := range b.handlers {
+		handlers = append(handlers, handler)
+	}
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(env)
+	}
+	return nil
+}
+
+// Subscribe registers the handler until ctx is cancelled
+func (b *MemoryBackplane) Subscribe(ctx context.Context, handler func(*Envelope)) error {
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handler
+	b.mu.Unlock()
+
+	<-ctx.Done()
+
+	b.mu.Lock()
+	delete(b.handlers, id)
+	b.mu.Unlock()
+	return nil
+}
+
+// Close is a no-op for the in-memory backplane
+func (b *MemoryBackplane) Close() error {
+	return nil
+}
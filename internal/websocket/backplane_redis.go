@@ -0,0 +1,73 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis channel naming: one channel per tenant
+const backplaneChannelPrefix = "ws:tenant:"
+
+// RedisBackplane relays hub messages through Redis pub/sub
+type RedisBackplane struct {
+	client *redis.Client
+}
+
+// NewRedisBackplane creates a backplane on top of an existing Redis client
+// (typically the one owned by cache.RedisCache)
+func NewRedisBackplane(client *redis.Client) *RedisBackplane {
+	return &RedisBackplane{client: client}
+}
+
+// tenantChannel returns the pub/sub channel for a tenant
+func tenantChannel(tenantID uuid.UUID) string {
+	return fmt.Sprintf("%s%s", backplaneChannelPrefix, tenantID)
+}
+
+// Publish sends the envelope on the tenant's channel
+func (b *RedisBackplane) Publish(ctx context.Context, env *Envelope) error {
+	data, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, tenantChannel(env.TenantID), data).Err()
+}
+
+// Subscribe listens on all tenant channels until ctx is cancelled
+func (b *RedisBackplane) Subscribe(ctx context.Context, handler func(*Envelope)) error {
+	pubsub := b.client.PSubscribe(ctx, backplaneChannelPrefix+"*")
+	defer pubsub.Close()
+
+	// Wait for subscription confirmation so early publishes aren't missed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return err
+	}
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			var env Envelope
+			if err := json.Unmarshal([]byte(msg.Payload), &env); err != nil {
+				log.Printf("[WS] Failed to decode backplane message: %v", err)
+				continue
+			}
+			handler(&env)
+		}
+	}
+}
+
+// Close is a no-op: the Redis client is owned by the cache
+func (b *RedisBackplane) Close() error {
+	return nil
+}
@@ -0,0 +1,118 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// newTestClient builds a client without a network connection
+func newTestClient(hub *Hub, tenantID, userID uuid.UUID) *Client {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Client{
+		hub:      hub,
+		tenantID: tenantID,
+		userID:   userID,
+		send:     make(chan *Message, 16),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// isPresence reports whether msg is a presence update, which registration
+// triggers on its own and tests usually want to skip
+func isPresence(msg *Message) bool {
+	return msg.Type == MessageTypePresenceJoin || msg.Type == MessageTypePresenceLeave
+}
+
+func expectMessage(t *testing.T, client *Client, msgType MessageType) *Message {
+	t.Helper()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case msg := <-client.send:
+			if isPresence(msg) && msgType != msg.Type {
+				continue
+			}
+			if msg.Type != msgType {
+				t.Fatalf("expected %s, got %s", msgType, msg.Type)
+			}
+			return msg
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s", msgType)
+			return nil
+		}
+	}
+}
+
+func expectNoMessage(t *testing.T, client *Client) {
+	t.Helper()
+	timeout := time.After(50 * time.Millisecond)
+	for {
+		select {
+		case msg := <-client.send:
+			if isPresence(msg) {
+				continue
+			}
+			t.Fatalf("unexpected message %s", msg.Type)
+		case <-timeout:
+			return
+		}
+	}
+}
+
+func startHubs(t *testing.T, backplane Backplane) (*Hub, *Hub) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	nodeA, nodeB := NewHub(), NewHub()
+	nodeA.UseBackplane(backplane)
+	nodeB.UseBackplane(backplane)
+	go nodeA.Run(ctx)
+	go nodeB.Run(ctx)
+
+	// Give both subscribers time to attach
+	time.Sleep(20 * time.Millisecond)
+	return nodeA, nodeB
+}
+
+func TestBackplaneRelaysBroadcastAcrossNodes(t *testing.T) {
+	nodeA, nodeB := startHubs(t, NewMemoryBackplane())
+	tenantID := uuid.New()
+
+	local := newTestClient(nodeA, tenantID, uuid.New())
+	remote := newTestClient(nodeB, tenantID, uuid.New())
+	otherTenant := newTestClient(nodeB, uuid.New(), uuid.New())
+	nodeA.Register(local)
+	nodeB.Register(remote)
+	nodeB.Register(otherTenant)
+
+	msg, _ := NewMessage(MessageTypePostCreated, PostCreatedPayload{ID: uuid.New()})
+	nodeA.BroadcastToTenant(tenantID, msg)
+
+	expectMessage(t, local, MessageTypePostCreated)
+	expectMessage(t, remote, MessageTypePostCreated)
+	expectNoMessage(t, otherTenant)
+
+	// Locally originated messages must not be delivered twice
+	expectNoMessage(t, local)
+}
+
+func TestBackplaneRelaysDirectMessageAcrossNodes(t *testing.T) {
+	nodeA, nodeB := startHubs(t, NewMemoryBackplane())
+	tenantID, userID := uuid.New(), uuid.New()
+
+	remote := newTestClient(nodeB, tenantID, userID)
+	bystander := newTestClient(nodeB, tenantID, uuid.New())
+	nodeB.Register(remote)
+	nodeB.Register(bystander)
+
+	msg, _ := NewMessage(MessageTypeNotificationNew, NotificationPayload{ID: uuid.New()})
+	nodeA.SendToUser(tenantID, userID, msg)
+
+	expectMessage(t, remote, MessageTypeNotificationNew)
+	expectNoMessage(t, bystander)
+}
@@ -0,0 +1,109 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/nickkcj/orbit-backend/internal/service"
+)
+
+var errInvalidChatPayload = errors.New("invalid chat payload")
+
+// LessonChat handles chat:* messages from clients. Results are not sent back
+// directly: the service publishes them to the lesson topic, which the sender
+// receives like everyone else once subscribed.
+type LessonChat struct {
+	chatService       *service.LessonChatService
+	permissionService *service.PermissionService
+}
+
+// NewLessonChat creates a new lesson chat message handler
+func NewLessonChat(chatService *service.LessonChatService, permissionService *service.PermissionService) *LessonChat {
+	return &LessonChat{
+		chatService:       chatService,
+		permissionService: permissionService,
+	}
+}
+
+// handle dispatches a chat message sent by the client
+func (lc *LessonChat) handle(c *Client, msg *Message) {
+	var err error
+	switch msg.Type {
+	case MessageTypeChatSend:
+		err = lc.send(c, msg)
+	case MessageTypeChatEdit:
+		err = lc.edit(c, msg)
+	case MessageTypeChatDelete:
+		err = lc.delete(c, msg)
+	}
+	if err != nil {
+		c.sendError(chatErrorMessage(err))
+	}
+}
+
+func (lc *LessonChat) send(c *Client, msg *Message) error {
+	var payload ChatSendPayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		return errInvalidChatPayload
+	}
+
+	allowed, err := lc.permissionService.HasPermission(c.ctx, c.tenantID, c.userID, "enrollments.view")
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return service.ErrAccessDenied
+	}
+
+	_, err = lc.chatService.Send(c.ctx, service.SendChatMessageInput{
+		TenantID:       c.tenantID,
+		LessonID:       payload.LessonID,
+		AuthorID:       c.userID,
+		Content:        payload.Content,
+		VideoTimestamp: payload.VideoTimestamp,
+	})
+	return err
+}
+
+func (lc *LessonChat) edit(c *Client, msg *Message) error {
+	var payload ChatEditPayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		return errInvalidChatPayload
+	}
+
+	_, err := lc.chatService.Edit(c.ctx, c.tenantID, c.userID, payload.MessageID, payload.Content)
+	return err
+}
+
+func (lc *LessonChat) delete(c *Client, msg *Message) error {
+	var payload ChatDeletePayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		return errInvalidChatPayload
+	}
+
+	canModerate, err := lc.permissionService.HasPermission(c.ctx, c.tenantID, c.userID, "lesson_chat.moderate")
+	if err != nil {
+		return err
+	}
+
+	return lc.chatService.Delete(c.ctx, c.tenantID, c.userID, payload.MessageID, canModerate)
+}
+
+// chatErrorMessage exposes validation errors and hides internal ones
+func chatErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, errInvalidChatPayload),
+		errors.Is(err, service.ErrAccessDenied),
+		errors.Is(err, service.ErrLessonNotFound),
+		errors.Is(err, service.ErrChatMessageNotFound),
+		errors.Is(err, service.ErrChatMessageEmpty),
+		errors.Is(err, service.ErrChatMessageTooLong),
+		errors.Is(err, service.ErrChatNotAuthor),
+		errors.Is(err, service.ErrEmailNotVerified):
+		return err.Error()
+	default:
+		log.Printf("[WS] Chat message failed: %v", err)
+		return "failed to process chat message"
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,6 +15,9 @@ const (
 	writeTimeout = 10 * time.Second
 	pingInterval = 30 * time.Second // Railway requires keep-alive
 	pongWait     = 60 * time.Second
+
+	// How often a connection re-checks that its login session is active
+	sessionCheckInterval = 2 * time.Minute
 )
 
 // Client represents a WebSocket connection
@@ -25,19 +29,46 @@ type Client struct {
 	send     chan *Message
 	ctx      context.Context
 	cancel   context.CancelFunc
+
+	// Checks topic subscriptions (nil rejects them all)
+	authorizer *TopicAuthorizer
+
+	// Handles lesson chat messages (nil disables chat)
+	chat *LessonChat
+
+	// Login session the client connected with, re-checked on the ping
+	// ticker (nil sessions skips the check)
+	sessionID uuid.UUID
+	sessions  *Authenticator
+
+	// Set once when the server ends the session
+	closeOnce   sync.Once
+	closeCode   websocket.StatusCode
+	closeReason string
+
+	// Guarded by hub.mu
+	topics map[string]struct{}
+	closed bool
+
+	// While replaying, live messages wait in pending
+	replayMu  sync.Mutex
+	replaying bool
+	pending   []*Message
 }
 
 // NewClient creates a new WebSocket client
-func NewClient(hub *Hub, conn *websocket.Conn, tenantID, userID uuid.UUID) *Client {
+func NewClient(hub *Hub, conn *websocket.Conn, tenantID, userID uuid.UUID, authorizer *TopicAuthorizer, chat *LessonChat) *Client {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Client{
-		hub:      hub,
-		conn:     conn,
-		tenantID: tenantID,
-		userID:   userID,
-		send:     make(chan *Message, 256),
-		ctx:      ctx,
-		cancel:   cancel,
+		hub:        hub,
+		conn:       conn,
+		tenantID:   tenantID,
+		userID:     userID,
+		send:       make(chan *Message, 256),
+		ctx:        ctx,
+		cancel:     cancel,
+		authorizer: authorizer,
+		chat:       chat,
 	}
 }
 
@@ -49,6 +80,7 @@ func (c *Client) WritePump() {
 		c.hub.unregister <- c
 		c.conn.Close(websocket.StatusNormalClosure, "")
 	}()
+	var sessionCheckedAt time.Time
 
 	for {
 		select {
@@ -72,6 +104,14 @@ func (c *Client) WritePump() {
 				return
 			}
 		case <-ticker.C:
+			if time.Since(sessionCheckedAt) >= sessionCheckInterval {
+				sessionCheckedAt = time.Now()
+				if c.sessionEnded() {
+					c.disconnect(StatusSessionEnded, "session ended")
+					continue
+				}
+			}
+
 			// Send ping for Railway keep-alive
 			ctx, cancel := context.WithTimeout(c.ctx, writeTimeout)
 			err := c.conn.Ping(ctx)
@@ -117,14 +157,189 @@ func (c *Client) handleMessage(msg *Message) {
 		// Respond with pong
 		pong, _ := NewMessage(MessageTypePong, nil)
 		c.send <- pong
+	case MessageTypePresenceView:
+		c.handlePresenceView(msg)
+	case MessageTypeSubscribe:
+		c.handleSubscribe(msg)
+	case MessageTypeUnsubscribe:
+		c.handleUnsubscribe(msg)
+	case MessageTypeChatSend, MessageTypeChatEdit, MessageTypeChatDelete:
+		if c.chat == nil {
+			c.sendError("chat is not available")
+			return
+		}
+		c.chat.handle(c, msg)
 	default:
 		// Handle other message types as needed
 		log.Printf("[WS] Received message type: %s", msg.Type)
 	}
 }
 
+// handlePresenceView records the resource the client is currently viewing
+func (c *Client) handlePresenceView(msg *Message) {
+	var payload PresenceViewPayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		c.sendError("invalid presence:view payload")
+		return
+	}
+
+	resource := ""
+	if payload.Resource != "" {
+		parsed, err := ParsePresenceResource(payload.Resource)
+		if err != nil {
+			c.sendError(err.Error())
+			return
+		}
+		resource = parsed
+
+		// Viewers of a resource are only visible to those allowed to see it
+		topic, err := ParseTopic(resource)
+		if err != nil {
+			c.sendError(err.Error())
+			return
+		}
+		if !c.authorizeTopic(topic) {
+			return
+		}
+	}
+
+	c.hub.presence.view(c, resource)
+}
+
+// handleSubscribe permission-checks a topic and adds the client to it
+func (c *Client) handleSubscribe(msg *Message) {
+	var payload SubscriptionPayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		c.sendError("invalid subscribe payload")
+		return
+	}
+	c.subscribeTo(payload.Topic)
+}
+
+// subscribeTo validates and authorizes a topic, then acknowledges or reports
+// why it was rejected
+func (c *Client) subscribeTo(name string) {
+	topic, err := ParseTopic(name)
+	if err != nil {
+		c.sendError(err.Error())
+		return
+	}
+
+	if !c.authorizeTopic(topic) {
+		return
+	}
+
+	if err := c.hub.subscribe(c, topic); err != nil {
+		c.sendError(err.Error())
+		return
+	}
+
+	ack, _ := NewMessage(MessageTypeSubscribed, SubscriptionPayload{Topic: topic.String()})
+	c.Send(ack)
+}
+
+// authorizeTopic checks the client may see the topic, reporting to the client
+// why not
+func (c *Client) authorizeTopic(topic Topic) bool {
+	if c.authorizer == nil {
+		c.sendError(ErrTopicForbidden.Error())
+		return false
+	}
+	allowed, err := c.authorizer.CanSubscribe(c.ctx, c.tenantID, c.userID, topic)
+	if err != nil {
+		log.Printf("[WS] Failed to authorize topic %s for user=%s: %v", topic, c.userID, err)
+		c.sendError("failed to check topic access")
+		return false
+	}
+	if !allowed {
+		c.sendError(ErrTopicForbidden.Error())
+		return false
+	}
+	return true
+}
+
+// handleUnsubscribe removes the client from a topic
+func (c *Client) handleUnsubscribe(msg *Message) {
+	var payload SubscriptionPayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		c.sendError("invalid unsubscribe payload")
+		return
+	}
+
+	topic, err := ParseTopic(payload.Topic)
+	if err != nil {
+		c.sendError(err.Error())
+		return
+	}
+
+	c.hub.unsubscribe(c, topic)
+
+	ack, _ := NewMessage(MessageTypeUnsubscribed, SubscriptionPayload{Topic: topic.String()})
+	c.Send(ack)
+}
+
+// sendError reports a rejected message back to the client
+func (c *Client) sendError(reason string) {
+	msg, err := NewMessage(MessageTypeError, ErrorPayload{Error: reason})
+	if err != nil {
+		return
+	}
+	c.Send(msg)
+}
+
+// beginReplay holds live messages until finishReplay. Call before Register.
+func (c *Client) beginReplay() {
+	c.replayMu.Lock()
+	defer c.replayMu.Unlock()
+	c.replaying = true
+}
+
+// finishReplay sends the missed messages (or a resync notice) followed by the
+// live messages that arrived meanwhile, skipping ones already replayed
+func (c *Client) finishReplay(missed []*Message, resync *Message) {
+	c.replayMu.Lock()
+	defer c.replayMu.Unlock()
+
+	if resync != nil {
+		c.push(resync)
+	}
+
+	replayed := make(map[uint64]struct{}, len(missed))
+	for _, msg := range missed {
+		replayed[msg.Seq] = struct{}{}
+		c.push(msg)
+	}
+
+	for _, msg := range c.pending {
+		if _, ok := replayed[msg.Seq]; ok && msg.Seq != 0 {
+			continue
+		}
+		c.push(msg)
+	}
+
+	c.pending = nil
+	c.replaying = false
+}
+
 // Send sends a message to the client
 func (c *Client) Send(msg *Message) {
+	c.replayMu.Lock()
+	if c.replaying {
+		if len(c.pending) < cap(c.send) {
+			c.pending = append(c.pending, msg)
+		} else {
+			log.Printf("[WS] Client replay buffer full, dropping message")
+		}
+		c.replayMu.Unlock()
+		return
+	}
+	c.replayMu.Unlock()
+
+	c.push(msg)
+}
+
+// push queues a message for the write pump without blocking
+func (c *Client) push(msg *Message) {
 	select {
 	case c.send <- msg:
 	default:
@@ -0,0 +1,200 @@
+package websocket
+
+import (
+	"context"
+	"log"
+
+	"github.com/nickkcj/orbit-backend/internal/events"
+)
+
+// HandleEvent translates domain events into WebSocket messages and routes them
+// to the affected user, topic subscribers or, for global events, the whole
+// tenant room. It is meant to be subscribed to the events.Bus and never blocks
+// the publisher.
+func (h *Hub) HandleEvent(ctx context.Context, event events.Event) {
+	if change, ok := accessChange(event); ok {
+		h.tryApplyAccess(change)
+		return
+	}
+
+	msgType, payload, ok := toMessagePayload(event)
+	if !ok {
+		return
+	}
+
+	msg, err := NewMessage(msgType, payload)
+	if err != nil {
+		log.Printf("[WS] Failed to build message for event %s: %v", event.Type, err)
+		return
+	}
+
+	if event.UserID != nil {
+		h.trySendToUser(&DirectMessage{TenantID: event.TenantID, UserID: *event.UserID, Message: msg})
+		return
+	}
+
+	topics := eventTopics(event)
+	if len(topics) == 0 {
+		h.tryBroadcast(&BroadcastMessage{TenantID: event.TenantID, Message: msg})
+		return
+	}
+	for _, topic := range topics {
+		h.tryPublishToTopic(&TopicMessage{TenantID: event.TenantID, Topic: topic.String(), Message: msg})
+	}
+}
+
+// eventTopics lists the topics an event is scoped to. Events without topics
+// (new and deleted posts) are global and go to the whole tenant.
+func eventTopics(event events.Event) []Topic {
+	switch p := event.Payload.(type) {
+	case events.PostPayload:
+		if event.Type != events.PostUpdated {
+			return nil
+		}
+		topics := []Topic{PostTopic(p.ID)}
+		if p.CategoryID != nil {
+			topics = append(topics, CategoryTopic(*p.CategoryID))
+		}
+		return topics
+	case events.CommentPayload:
+		return []Topic{PostTopic(p.PostID)}
+	case events.CommentDeletedPayload:
+		return []Topic{PostTopic(p.PostID)}
+	case events.LikePayload:
+		return []Topic{PostTopic(p.PostID)}
+	case events.LessonChatPayload:
+		return []Topic{LessonTopic(p.LessonID)}
+	case events.LessonChatDeletedPayload:
+		return []Topic{LessonTopic(p.LessonID)}
+	default:
+		return nil
+	}
+}
+
+// toMessagePayload maps an event onto the client-facing message type and payload
+func toMessagePayload(event events.Event) (MessageType, any, bool) {
+	switch p := event.Payload.(type) {
+	case events.PostPayload:
+		payload := PostCreatedPayload{
+			ID:         p.ID,
+			Title:      p.Title,
+			AuthorID:   p.AuthorID,
+			AuthorName: p.AuthorName,
+			CategoryID: p.CategoryID,
+			CreatedAt:  p.CreatedAt,
+		}
+		if event.Type == events.PostUpdated {
+			return MessageTypePostUpdated, payload, true
+		}
+		return MessageTypePostCreated, payload, true
+
+	case events.PostDeletedPayload:
+		return MessageTypePostDeleted, PostDeletedPayload{ID: p.ID}, true
+
+	case events.CommentPayload:
+		return MessageTypeCommentCreated, CommentCreatedPayload{
+			ID:         p.ID,
+			PostID:     p.PostID,
+			AuthorID:   p.AuthorID,
+			AuthorName: p.AuthorName,
+			ParentID:   p.ParentID,
+			CreatedAt:  p.CreatedAt,
+		}, true
+
+	case events.CommentDeletedPayload:
+		return MessageTypeCommentDeleted, CommentDeletedPayload{ID: p.ID, PostID: p.PostID}, true
+
+	case events.LikePayload:
+		return MessageTypeLikeUpdated, LikeUpdatedPayload{
+			TargetType: p.TargetType,
+			TargetID:   p.TargetID,
+			PostID:     p.PostID,
+			LikeCount:  p.LikeCount,
+		}, true
+
+	case events.NotificationPayload:
+		var data any
+		if len(p.Data) > 0 {
+			data = p.Data
+		}
+		payload := NotificationPayload{
+			ID:         p.ID,
+			Type:       p.Type,
+			Title:      p.Title,
+			Message:    p.Message,
+			Data:       data,
+			ActorCount: p.ActorCount,
+			CreatedAt:  p.CreatedAt,
+		}
+		if event.Type == events.NotificationUpdated {
+			return MessageTypeNotificationUpdated, payload, true
+		}
+		return MessageTypeNotificationNew, payload, true
+
+	case events.LessonChatPayload:
+		payload := ChatMessagePayload{
+			ID:             p.ID,
+			LessonID:       p.LessonID,
+			AuthorID:       p.AuthorID,
+			AuthorName:     p.AuthorName,
+			Content:        p.Content,
+			VideoTimestamp: p.VideoTimestamp,
+			CreatedAt:      p.CreatedAt,
+			EditedAt:       p.EditedAt,
+		}
+		if event.Type == events.LessonChatUpdated {
+			return MessageTypeChatUpdated, payload, true
+		}
+		return MessageTypeChatMessage, payload, true
+
+	case events.LessonChatDeletedPayload:
+		return MessageTypeChatDeleted, ChatDeletedPayload{ID: p.ID, LessonID: p.LessonID}, true
+
+	case events.DirectMessagePayload:
+		return MessageTypeDirectMessage, DirectMessagePayload{
+			ID:             p.ID,
+			ConversationID: p.ConversationID,
+			SenderID:       p.SenderID,
+			SenderName:     p.SenderName,
+			Content:        p.Content,
+			CreatedAt:      p.CreatedAt,
+		}, true
+
+	case events.ConversationReadPayload:
+		return MessageTypeConversationRead, ConversationReadPayload{
+			ConversationID: p.ConversationID,
+			UnreadCount:    p.UnreadCount,
+		}, true
+
+	default:
+		log.Printf("[WS] No message mapping for event %s", event.Type)
+		return "", nil, false
+	}
+}
+
+// tryBroadcast queues a tenant broadcast without blocking the caller
+func (h *Hub) tryBroadcast(msg *BroadcastMessage) {
+	select {
+	case h.broadcast <- msg:
+	default:
+		log.Printf("[WS] Broadcast buffer full, dropping %s for tenant=%s", msg.Message.Type, msg.TenantID)
+	}
+}
+
+// trySendToUser queues a direct message without blocking the caller
+func (h *Hub) trySendToUser(msg *DirectMessage) {
+	select {
+	case h.direct <- msg:
+	default:
+		log.Printf("[WS] Direct buffer full, dropping %s for user=%s", msg.Message.Type, msg.UserID)
+	}
+}
+
+// tryPublishToTopic queues a topic message without blocking the caller
+func (h *Hub) tryPublishToTopic(msg *TopicMessage) {
+	select {
+	case h.topic <- msg:
+	default:
+		log.Printf("[WS] Topic buffer full, dropping %s for topic=%s", msg.Message.Type, msg.Topic)
+	}
+}
@@ -1,46 +1,84 @@
 package websocket
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"nhooyr.io/websocket"
+
+	"github.com/nickkcj/orbit-backend/internal/middleware"
 )
 
 // Handler handles WebSocket connections
 type Handler struct {
 	hub           *Hub
 	authenticator *Authenticator
+	topics        *TopicAuthorizer
+	chat          *LessonChat
+	origins       *OriginPolicy
 }
 
 // NewHandler creates a new WebSocket handler
-func NewHandler(hub *Hub, authenticator *Authenticator) *Handler {
+func NewHandler(hub *Hub, authenticator *Authenticator, topics *TopicAuthorizer, chat *LessonChat, origins *OriginPolicy) *Handler {
 	return &Handler{
 		hub:           hub,
 		authenticator: authenticator,
+		topics:        topics,
+		chat:          chat,
+		origins:       origins,
 	}
 }
 
-// HandleWebSocket upgrades HTTP connection to WebSocket
-// Endpoint: GET /ws?token=xxx&tenant=slug
-func (h *Handler) HandleWebSocket(c echo.Context) error {
-	// Extract auth credentials
-	token := c.QueryParam("token")
-	if token == "" {
-		// Try cookie as fallback
-		if cookie, err := c.Cookie("auth_token"); err == nil {
-			token = cookie.Value
-		}
+// TicketResponse is returned by the ticket endpoint
+type TicketResponse struct {
+	Ticket    string `json:"ticket"`
+	ExpiresIn int    `json:"expires_in"` // Seconds
+}
+
+// IssueTicket returns a single-use ticket for opening a WebSocket
+// Endpoint: POST /api/v1/ws/ticket
+func (h *Handler) IssueTicket(c echo.Context) error {
+	tenant := middleware.GetTenantFromContext(c)
+	user := middleware.GetUserFromContext(c)
+	if tenant == nil || user == nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
 	}
 
-	tenantSlug := c.QueryParam("tenant")
-	if tenantSlug == "" {
-		tenantSlug = c.Request().Header.Get("X-Tenant-Slug")
+	// Same check as /ws, so a ticket is never handed to someone it would reject
+	if err := h.authenticator.authorize(c.Request().Context(), *user, *tenant); err != nil {
+		return denyAccess(c, err)
 	}
 
-	// Authenticate
-	authResult, err := h.authenticator.Authenticate(c.Request().Context(), token, tenantSlug)
+	var sessionID uuid.UUID
+	if claims := middleware.GetClaimsFromContext(c); claims != nil {
+		sessionID = claims.SessionID
+	}
+
+	ticket, err := h.authenticator.IssueTicket(c.Request().Context(), user.ID, tenant.ID, sessionID)
+	if err != nil {
+		log.Printf("[WS] Failed to issue ticket: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to issue ticket"})
+	}
+
+	return c.JSON(http.StatusOK, TicketResponse{
+		Ticket:    ticket,
+		ExpiresIn: int(ticketTTL.Seconds()),
+	})
+}
+
+// HandleWebSocket upgrades HTTP connection to WebSocket
+// Endpoint: GET /ws?ticket=xxx (ticket from POST /api/v1/ws/ticket)
+// Same-site clients may instead rely on the auth_token cookie with ?tenant=slug.
+// Reconnecting clients add since=<seq> to replay what they missed, and
+// topics=post:<id>,lesson:<id> to restore subscriptions before the replay.
+func (h *Handler) HandleWebSocket(c echo.Context) error {
+	authResult, err := h.authenticate(c)
 	if err != nil {
 		log.Printf("[WS] Authentication failed: %v", err)
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
@@ -48,29 +86,151 @@ func (h *Handler) HandleWebSocket(c echo.Context) error {
 
 	// Upgrade to WebSocket
 	conn, err := websocket.Accept(c.Response(), c.Request(), &websocket.AcceptOptions{
-		InsecureSkipVerify: false,
-		OriginPatterns:     []string{"*"}, // Configure based on environment
+		OriginPatterns: h.origins.Patterns(authResult.TenantSlug),
 	})
 	if err != nil {
 		log.Printf("[WS] Failed to accept connection: %v", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to upgrade connection"})
 	}
 
-	// Create client and register
-	client := NewClient(h.hub, conn, authResult.TenantID, authResult.UserID)
-	h.hub.Register(client)
+	ctx := c.Request().Context()
+	client := NewClient(h.hub, conn, authResult.TenantID, authResult.UserID, h.topics, h.chat)
+	client.sessionID = authResult.SessionID
+	client.sessions = h.authenticator
+	since, replay := parseSince(c.QueryParam("since"))
+	h.attach(ctx, client, c.QueryParam("topics"), replay)
+
+	// Start read/write pumps
+	go client.WritePump()
+	if replay {
+		h.hub.replayTo(ctx, client, since)
+	}
+	client.ReadPump() // Blocks until disconnect
 
+	return nil
+}
+
+// denyAccess answers a failed membership check
+func denyAccess(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, ErrNotMember), errors.Is(err, ErrInactiveMember),
+		errors.Is(err, ErrInactiveTenant), errors.Is(err, ErrInactiveUser):
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	}
+	log.Printf("[WS] Failed to check membership: %v", err)
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to check membership"})
+}
+
+// authenticate resolves the connecting user from a ticket, falling back to the
+// auth cookie. Tokens are not accepted in the query string.
+func (h *Handler) authenticate(c echo.Context) (*AuthResult, error) {
+	ctx := c.Request().Context()
+	if ticket := c.QueryParam("ticket"); ticket != "" {
+		return h.authenticator.AuthenticateTicket(ctx, ticket)
+	}
+
+	cookie, err := c.Cookie("auth_token")
+	if err != nil {
+		return nil, ErrMissingToken
+	}
+
+	tenantSlug := c.QueryParam("tenant")
+	if tenantSlug == "" {
+		tenantSlug = c.Request().Header.Get("X-Tenant-Slug")
+	}
+	return h.authenticator.Authenticate(ctx, cookie.Value, tenantSlug)
+}
+
+// attach confirms the connection, restores topic subscriptions and registers
+// the client with the hub. With replay set, live messages are held until the
+// caller runs hub.replayTo.
+func (h *Handler) attach(ctx context.Context, client *Client, topics string, replay bool) {
 	// Send connection confirmation
+	lastSeq, err := h.hub.replay.LastSeq(ctx, client.tenantID)
+	if err != nil {
+		log.Printf("[WS] Failed to read last sequence: %v", err)
+	}
 	connMsg, _ := NewMessage(MessageTypeConnected, ConnectedPayload{
 		Status:   "connected",
-		UserID:   authResult.UserID.String(),
-		TenantID: authResult.TenantID.String(),
+		UserID:   client.userID.String(),
+		TenantID: client.tenantID.String(),
+		LastSeq:  lastSeq,
 	})
 	client.Send(connMsg)
 
-	// Start read/write pumps
-	go client.WritePump()
-	client.ReadPump() // Blocks until disconnect
+	// Restore topic subscriptions so their missed messages are replayed too
+	for _, name := range strings.Split(topics, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			client.subscribeTo(name)
+		}
+	}
 
-	return nil
+	if replay {
+		client.beginReplay()
+	}
+	h.hub.Register(client)
+}
+
+// parseSince reads the ?since= sequence sent by reconnecting clients
+func parseSince(raw string) (uint64, bool) {
+	if raw == "" {
+		return 0, false
+	}
+	since, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return since, true
+}
+
+// PresenceResponse is the snapshot returned by the presence endpoint
+type PresenceResponse struct {
+	Resource string      `json:"resource,omitempty"`
+	Count    int         `json:"count"`
+	UserIDs  []uuid.UUID `json:"user_ids"`
+}
+
+// GetPresence returns who is online in the tenant, or viewing a resource
+// Endpoint: GET /api/v1/presence?resource=lesson:<id>
+func (h *Handler) GetPresence(c echo.Context) error {
+	tenant := middleware.GetTenantFromContext(c)
+	if tenant == nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "tenant context required"})
+	}
+
+	key := PresenceKey{TenantID: tenant.ID}
+	if resource := c.QueryParam("resource"); resource != "" {
+		parsed, err := ParsePresenceResource(resource)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		key.Resource = parsed
+
+		user := middleware.GetUserFromContext(c)
+		if user == nil {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		}
+		topic, err := ParseTopic(parsed)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		allowed, err := h.topics.CanSubscribe(c.Request().Context(), tenant.ID, user.ID, topic)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to check resource access"})
+		}
+		if !allowed {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": ErrTopicForbidden.Error()})
+		}
+	}
+
+	users, err := h.hub.Presence().Snapshot(c.Request().Context(), key)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load presence"})
+	}
+
+	return c.JSON(http.StatusOK, PresenceResponse{
+		Resource: key.Resource,
+		Count:    len(users),
+		UserIDs:  users,
+	})
 }
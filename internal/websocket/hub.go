@@ -2,12 +2,18 @@ package websocket
 
 import (
 	"context"
+	"encoding/binary"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+// sequencerShards is how many goroutines stamp and deliver messages. A tenant
+// always maps to the same one, so its messages keep their order.
+const sequencerShards = 16
+
 // BroadcastMessage sends to all clients in a tenant
 type BroadcastMessage struct {
 	TenantID uuid.UUID
@@ -21,6 +27,19 @@ type DirectMessage struct {
 	Message  *Message
 }
 
+// TopicMessage sends to the clients of a tenant subscribed to a topic
+type TopicMessage struct {
+	TenantID uuid.UUID
+	Topic    string
+	Message  *Message
+}
+
+// topicKey scopes a topic to its tenant
+type topicKey struct {
+	tenantID uuid.UUID
+	topic    string
+}
+
 // Hub manages all WebSocket connections across tenants
 type Hub struct {
 	// Tenant rooms: tenantID -> set of clients
@@ -29,6 +48,9 @@ type Hub struct {
 	// User channels: userID -> set of clients (user may have multiple tabs)
 	userChannels map[uuid.UUID]map[*Client]struct{}
 
+	// Topic subscriptions: tenant+topic -> set of clients
+	topics map[topicKey]map[*Client]struct{}
+
 	// Channels for client registration/unregistration
 	register   chan *Client
 	unregister chan *Client
@@ -39,23 +61,89 @@ type Hub struct {
 	// Direct message channel for user-specific messages
 	direct chan *DirectMessage
 
+	// Topic channel for messages scoped to a post, lesson or category
+	topic chan *TopicMessage
+
+	// Access channel for sessions to close or re-check
+	access chan *AccessChange
+
+	// Cross-instance relay (nil when running a single node)
+	nodeID    string
+	backplane Backplane
+	outbound  chan *Envelope
+
+	// Online users per tenant and per viewed resource
+	presence *Presence
+
+	// Sequence numbers and history for reconnecting clients
+	replay ReplayLog
+
+	// Messages waiting to be sequenced and delivered, sharded by tenant so a
+	// slow replay log never stalls the Run loop
+	sequencers []chan *Envelope
+
 	mu sync.RWMutex
 }
 
 // NewHub creates a new WebSocket hub
 func NewHub() *Hub {
-	return &Hub{
+	h := &Hub{
 		tenantRooms:  make(map[uuid.UUID]map[*Client]struct{}),
 		userChannels: make(map[uuid.UUID]map[*Client]struct{}),
+		topics:       make(map[topicKey]map[*Client]struct{}),
 		register:     make(chan *Client),
 		unregister:   make(chan *Client),
 		broadcast:    make(chan *BroadcastMessage, 256),
 		direct:       make(chan *DirectMessage, 256),
+		topic:        make(chan *TopicMessage, 256),
+		access:       make(chan *AccessChange, 64),
+		nodeID:       uuid.NewString(),
+		outbound:     make(chan *Envelope, 256),
+		replay:       NewMemoryReplayLog(replayLogSize),
+		sequencers:   make([]chan *Envelope, sequencerShards),
+	}
+	for i := range h.sequencers {
+		h.sequencers[i] = make(chan *Envelope, 256)
 	}
+	h.presence = newPresence(h, NewMemoryPresenceStore())
+	return h
+}
+
+// UseBackplane relays messages through the given backplane so clients on other
+// instances receive them too. Must be called before Run.
+func (h *Hub) UseBackplane(backplane Backplane) {
+	h.backplane = backplane
+}
+
+// UsePresenceStore replaces the in-memory presence store, typically with a
+// shared one when running several instances. Must be called before Run.
+func (h *Hub) UsePresenceStore(store PresenceStore) {
+	h.presence = newPresence(h, store)
+}
+
+// UseReplayLog replaces the in-memory replay log, typically with a shared one
+// when running several instances. Must be called before Run.
+func (h *Hub) UseReplayLog(replay ReplayLog) {
+	h.replay = replay
+}
+
+// Presence returns the hub's presence tracker
+func (h *Hub) Presence() *Presence {
+	return h.presence
 }
 
 // Run starts the hub's main loop
 func (h *Hub) Run(ctx context.Context) {
+	go h.presence.run(ctx)
+	for _, queue := range h.sequencers {
+		go h.runSequencer(ctx, queue)
+	}
+
+	if h.backplane != nil {
+		go h.publishLoop(ctx)
+		go h.subscribeLoop(ctx)
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -65,13 +153,144 @@ func (h *Hub) Run(ctx context.Context) {
 		case client := <-h.unregister:
 			h.unregisterClient(client)
 		case msg := <-h.broadcast:
-			h.broadcastToTenant(msg)
+			h.dispatch(&Envelope{Kind: EnvelopeBroadcast, TenantID: msg.TenantID, Message: msg.Message})
 		case msg := <-h.direct:
-			h.sendToUser(msg)
+			h.dispatch(&Envelope{Kind: EnvelopeDirect, TenantID: msg.TenantID, UserID: msg.UserID, Message: msg.Message})
+		case msg := <-h.topic:
+			h.dispatch(&Envelope{Kind: EnvelopeTopic, TenantID: msg.TenantID, Topic: msg.Topic, Message: msg.Message})
+		case change := <-h.access:
+			h.applyAccess(change)
+			h.relay(&Envelope{Kind: EnvelopeAccess, TenantID: change.TenantID, UserID: change.UserID, Access: change})
+		}
+	}
+}
+
+// dispatch hands a message to its tenant's sequencer without blocking Run
+func (h *Hub) dispatch(env *Envelope) {
+	select {
+	case h.sequencers[sequencerShard(env.TenantID)] <- env:
+	default:
+		log.Printf("[WS] Sequencer buffer full, dropping %s for tenant=%s", env.Message.Type, env.TenantID)
+	}
+}
+
+// sequencerShard picks the sequencer that owns a tenant
+func sequencerShard(tenantID uuid.UUID) int {
+	return int(binary.BigEndian.Uint32(tenantID[:4]) % sequencerShards)
+}
+
+// runSequencer stamps, delivers and relays the messages of its tenants in order
+func (h *Hub) runSequencer(ctx context.Context, queue chan *Envelope) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case env := <-queue:
+			env = h.sequence(env)
+			h.deliver(env)
+			h.relay(env)
+		}
+	}
+}
+
+// sequence stamps a message with the next tenant sequence and records it for
+// replay. On failure the message is still delivered, just without a sequence.
+func (h *Hub) sequence(env *Envelope) *Envelope {
+	if isEphemeral(env.Message.Type) {
+		return env
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	seq, err := h.replay.Append(ctx, env)
+	if err != nil {
+		log.Printf("[WS] Failed to record %s for replay: %v", env.Message.Type, err)
+		return env
+	}
+	env.Message.Seq = seq
+	return env
+}
+
+// relay queues a locally delivered message for the other nodes
+func (h *Hub) relay(env *Envelope) {
+	if h.backplane == nil {
+		return
+	}
+
+	env.ID = uuid.New()
+	env.NodeID = h.nodeID
+
+	select {
+	case h.outbound <- env:
+	default:
+		log.Printf("[WS] Backplane buffer full, dropping %s envelope for tenant=%s", env.Kind, env.TenantID)
+	}
+}
+
+// publishLoop drains the outbound queue into the backplane
+func (h *Hub) publishLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case env := <-h.outbound:
+			if err := h.backplane.Publish(ctx, env); err != nil {
+				log.Printf("[WS] Backplane publish failed: %v", err)
+			}
 		}
 	}
 }
 
+// subscribeLoop receives messages from other nodes, resubscribing on failure
+func (h *Hub) subscribeLoop(ctx context.Context) {
+	for {
+		err := h.backplane.Subscribe(ctx, h.receiveEnvelope)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("[WS] Backplane subscription lost: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+// receiveEnvelope delivers a relayed message to local clients. Messages that
+// originated on this node were already delivered and are skipped.
+func (h *Hub) receiveEnvelope(env *Envelope) {
+	if env.NodeID == h.nodeID {
+		return
+	}
+	if env.Kind == EnvelopeAccess {
+		if env.Access != nil {
+			h.applyAccess(env.Access)
+		}
+		return
+	}
+	if env.Message == nil {
+		return
+	}
+	h.deliver(env)
+}
+
+// deliver sends a message to the matching local clients
+func (h *Hub) deliver(env *Envelope) {
+	switch env.Kind {
+	case EnvelopeBroadcast:
+		h.broadcastToTenant(&BroadcastMessage{TenantID: env.TenantID, Message: env.Message})
+	case EnvelopeDirect:
+		h.sendToUser(&DirectMessage{TenantID: env.TenantID, UserID: env.UserID, Message: env.Message})
+	case EnvelopeTopic:
+		h.sendToTopic(&TopicMessage{TenantID: env.TenantID, Topic: env.Topic, Message: env.Message})
+	}
+}
+
 // registerClient adds a client to the hub
 func (h *Hub) registerClient(client *Client) {
 	h.mu.Lock()
@@ -113,27 +332,28 @@ func (h *Hub) unregisterClient(client *Client) {
 		}
 	}
 
+	// Drop topic subscriptions
+	for topic := range client.topics {
+		h.removeFromTopic(client, topic)
+	}
+	client.topics = nil
+	client.closed = true
+
+	h.presence.disconnected(client)
+
 	log.Printf("[WS] Client unregistered: user=%s tenant=%s", client.userID, client.tenantID)
 }
 
 // broadcastToTenant sends a message to all clients in a tenant
 func (h *Hub) broadcastToTenant(msg *BroadcastMessage) {
-	h.mu.RLock()
-	clients := h.tenantRooms[msg.TenantID]
-	h.mu.RUnlock()
-
-	for client := range clients {
+	for _, client := range h.snapshot(h.tenantRooms, msg.TenantID) {
 		client.Send(msg.Message)
 	}
 }
 
 // sendToUser sends a message to all connections of a specific user
 func (h *Hub) sendToUser(msg *DirectMessage) {
-	h.mu.RLock()
-	clients := h.userChannels[msg.UserID]
-	h.mu.RUnlock()
-
-	for client := range clients {
+	for _, client := range h.snapshot(h.userChannels, msg.UserID) {
 		// Only send if client is in the correct tenant
 		if client.tenantID == msg.TenantID {
 			client.Send(msg.Message)
@@ -141,11 +361,110 @@ func (h *Hub) sendToUser(msg *DirectMessage) {
 	}
 }
 
+// sendToTopic sends a message to the clients subscribed to a topic
+func (h *Hub) sendToTopic(msg *TopicMessage) {
+	for _, client := range h.snapshotTopic(topicKey{tenantID: msg.TenantID, topic: msg.Topic}) {
+		client.Send(msg.Message)
+	}
+}
+
+// subscribe adds a client to a topic in its tenant
+func (h *Hub) subscribe(client *Client, topic Topic) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if client.closed {
+		return ErrClientUnavailable
+	}
+	name := topic.String()
+	if _, ok := client.topics[name]; ok {
+		return nil
+	}
+	if len(client.topics) >= maxSubscriptions {
+		return ErrTooManyTopics
+	}
+
+	if client.topics == nil {
+		client.topics = make(map[string]struct{})
+	}
+	client.topics[name] = struct{}{}
+
+	key := topicKey{tenantID: client.tenantID, topic: name}
+	if h.topics[key] == nil {
+		h.topics[key] = make(map[*Client]struct{})
+	}
+	h.topics[key][client] = struct{}{}
+	return nil
+}
+
+// unsubscribe removes a client from a topic
+func (h *Hub) unsubscribe(client *Client, topic Topic) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	name := topic.String()
+	delete(client.topics, name)
+	h.removeFromTopic(client, name)
+}
+
+// removeFromTopic drops a client from the topic index. Caller holds h.mu.
+func (h *Hub) removeFromTopic(client *Client, topic string) {
+	key := topicKey{tenantID: client.tenantID, topic: topic}
+	if clients, ok := h.topics[key]; ok {
+		delete(clients, client)
+		if len(clients) == 0 {
+			delete(h.topics, key)
+		}
+	}
+}
+
+// clientTopics copies the topics a client is subscribed to
+func (h *Hub) clientTopics(client *Client) []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	topics := make([]string, 0, len(client.topics))
+	for topic := range client.topics {
+		topics = append(topics, topic)
+	}
+	return topics
+}
+
+// snapshotTopic copies the subscribers of a topic under the read lock
+func (h *Hub) snapshotTopic(key topicKey) []*Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	clients := make([]*Client, 0, len(h.topics[key]))
+	for client := range h.topics[key] {
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+// snapshot copies a client set under the read lock so delivery can happen
+// concurrently with registration (backplane messages arrive off the Run loop)
+func (h *Hub) snapshot(index map[uuid.UUID]map[*Client]struct{}, id uuid.UUID) []*Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	clients := make([]*Client, 0, len(index[id]))
+	for client := range index[id] {
+		clients = append(clients, client)
+	}
+	return clients
+}
+
 // BroadcastToTenant is a public method to send messages to all clients in a tenant
 func (h *Hub) BroadcastToTenant(tenantID uuid.UUID, msg *Message) {
 	h.broadcast <- &BroadcastMessage{TenantID: tenantID, Message: msg}
 }
 
+// PublishToTopic is a public method to send messages to a topic's subscribers
+func (h *Hub) PublishToTopic(tenantID uuid.UUID, topic Topic, msg *Message) {
+	h.topic <- &TopicMessage{TenantID: tenantID, Topic: topic.String(), Message: msg}
+}
+
 // SendToUser is a public method to send messages to a specific user
 func (h *Hub) SendToUser(tenantID, userID uuid.UUID, msg *Message) {
 	h.direct <- &DirectMessage{TenantID: tenantID, UserID: userID, Message: msg}
@@ -167,6 +486,8 @@ func (h *Hub) GetUserClientCount(userID uuid.UUID) int {
 
 // Register adds a client to the hub (public method)
 func (h *Hub) Register(client *Client) {
+	// Queue presence first so it is ordered before anything the client sends
+	h.presence.connected(client)
 	h.register <- client
 }
 
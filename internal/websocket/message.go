@@ -12,7 +12,8 @@ type MessageType string
 
 const (
 	// Notifications
-	MessageTypeNotificationNew MessageType = "notification:new"
+	MessageTypeNotificationNew     MessageType = "notification:new"
+	MessageTypeNotificationUpdated MessageType = "notification:updated"
 
 	// Posts
 	MessageTypePostCreated MessageType = "post:created"
@@ -26,14 +27,43 @@ const (
 	// Likes
 	MessageTypeLikeUpdated MessageType = "like:updated"
 
+	// Presence
+	MessageTypePresenceJoin  MessageType = "presence:join"
+	MessageTypePresenceLeave MessageType = "presence:leave"
+	MessageTypePresenceView  MessageType = "presence:view" // Client -> server
+
+	// Topic subscriptions
+	MessageTypeSubscribe    MessageType = "subscribe"   // Client -> server
+	MessageTypeUnsubscribe  MessageType = "unsubscribe" // Client -> server
+	MessageTypeSubscribed   MessageType = "subscribed"
+	MessageTypeUnsubscribed MessageType = "unsubscribed"
+
+	// Lesson chat
+	MessageTypeChatSend    MessageType = "chat:send"   // Client -> server
+	MessageTypeChatEdit    MessageType = "chat:edit"   // Client -> server
+	MessageTypeChatDelete  MessageType = "chat:delete" // Client -> server
+	MessageTypeChatMessage MessageType = "chat:message"
+	MessageTypeChatUpdated MessageType = "chat:updated"
+	MessageTypeChatDeleted MessageType = "chat:deleted"
+
+	// Direct messages
+	MessageTypeDirectMessage    MessageType = "dm:message"
+	MessageTypeConversationRead MessageType = "dm:read"
+
 	// Connection
-	MessageTypeConnected MessageType = "connected"
-	MessageTypePing      MessageType = "ping"
-	MessageTypePong      MessageType = "pong"
+	MessageTypeConnected    MessageType = "connected"
+	MessageTypeDisconnected MessageType = "disconnected" // SSE only; WebSockets get a close code
+	MessageTypePing         MessageType = "ping"
+	MessageTypePong         MessageType = "pong"
+	MessageTypeError        MessageType = "error"
+
+	// Replay
+	MessageTypeResyncRequired MessageType = "resync_required"
 )
 
 // Message represents a WebSocket message
 type Message struct {
+	Seq       uint64          `json:"seq,omitempty"` // Per-tenant sequence, set on replayable messages
 	Type      MessageType     `json:"type"`
 	Payload   json.RawMessage `json:"payload"`
 	Timestamp time.Time       `json:"timestamp"`
@@ -41,12 +71,13 @@ type Message struct {
 
 // NotificationPayload for notification messages
 type NotificationPayload struct {
-	ID        uuid.UUID `json:"id"`
-	Type      string    `json:"notification_type"`
-	Title     string    `json:"title"`
-	Message   string    `json:"message"`
-	Data      any       `json:"data,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         uuid.UUID `json:"id"`
+	Type       string    `json:"notification_type"`
+	Title      string    `json:"title"`
+	Message    string    `json:"message"`
+	Data       any       `json:"data,omitempty"`
+	ActorCount int       `json:"actor_count"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 // PostCreatedPayload for new post messages
@@ -69,18 +100,120 @@ type CommentCreatedPayload struct {
 	CreatedAt  time.Time  `json:"created_at"`
 }
 
+// PostDeletedPayload for removed post messages
+type PostDeletedPayload struct {
+	ID uuid.UUID `json:"id"`
+}
+
+// CommentDeletedPayload for removed comment messages
+type CommentDeletedPayload struct {
+	ID     uuid.UUID `json:"id"`
+	PostID uuid.UUID `json:"post_id"`
+}
+
 // LikeUpdatedPayload for like count changes
 type LikeUpdatedPayload struct {
 	TargetType string    `json:"target_type"` // "post" or "comment"
 	TargetID   uuid.UUID `json:"target_id"`
+	PostID     uuid.UUID `json:"post_id"`
 	LikeCount  int       `json:"like_count"`
 }
 
+// PresencePayload for users arriving or leaving a tenant or resource
+type PresencePayload struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Resource string    `json:"resource,omitempty"` // Empty for tenant-wide presence
+	Count    int       `json:"count"`
+}
+
+// PresenceViewPayload is sent by clients to announce what they are viewing.
+// An empty resource clears the current one.
+type PresenceViewPayload struct {
+	Resource string `json:"resource"` // "lesson:<id>" or "post:<id>"
+}
+
+// SubscriptionPayload names a topic to subscribe to or unsubscribe from, and
+// echoes it back in the acknowledgement
+type SubscriptionPayload struct {
+	Topic string `json:"topic"` // "post:<id>", "lesson:<id>" or "category:<id>"
+}
+
+// ChatSendPayload is sent by clients to post in a lesson chat
+type ChatSendPayload struct {
+	LessonID       uuid.UUID `json:"lesson_id"`
+	Content        string    `json:"content"`
+	VideoTimestamp *int32    `json:"video_timestamp,omitempty"` // Seconds into the lesson video
+}
+
+// ChatEditPayload is sent by clients to edit their own chat message
+type ChatEditPayload struct {
+	MessageID uuid.UUID `json:"message_id"`
+	Content   string    `json:"content"`
+}
+
+// ChatDeletePayload is sent by clients to delete a chat message
+type ChatDeletePayload struct {
+	MessageID uuid.UUID `json:"message_id"`
+}
+
+// ChatMessagePayload for new and edited lesson chat messages
+type ChatMessagePayload struct {
+	ID             uuid.UUID  `json:"id"`
+	LessonID       uuid.UUID  `json:"lesson_id"`
+	AuthorID       uuid.UUID  `json:"author_id"`
+	AuthorName     string     `json:"author_name"`
+	Content        string     `json:"content"`
+	VideoTimestamp *int32     `json:"video_timestamp,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	EditedAt       *time.Time `json:"edited_at,omitempty"`
+}
+
+// ChatDeletedPayload for removed lesson chat messages
+type ChatDeletedPayload struct {
+	ID       uuid.UUID `json:"id"`
+	LessonID uuid.UUID `json:"lesson_id"`
+}
+
+// DirectMessagePayload for a new message in one of the user's conversations
+type DirectMessagePayload struct {
+	ID             uuid.UUID `json:"id"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	SenderID       uuid.UUID `json:"sender_id"`
+	SenderName     string    `json:"sender_name"`
+	Content        string    `json:"content"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// ConversationReadPayload tells the user's other sessions a conversation was read
+type ConversationReadPayload struct {
+	ConversationID uuid.UUID `json:"conversation_id"`
+	UnreadCount    int64     `json:"unread_count"`
+}
+
+// ErrorPayload reports a rejected client message
+type ErrorPayload struct {
+	Error string `json:"error"`
+}
+
+// ResyncRequiredPayload tells a reconnecting client that the missed messages
+// are no longer available and it should refetch state over REST
+type ResyncRequiredPayload struct {
+	LastSeq uint64 `json:"last_seq"` // Resume from here after refetching
+}
+
+// DisconnectedPayload carries the close code and reason of a session the
+// server ended
+type DisconnectedPayload struct {
+	Code   int    `json:"code"`
+	Reason string `json:"reason"`
+}
+
 // ConnectedPayload for connection confirmation
 type ConnectedPayload struct {
 	Status   string `json:"status"`
 	UserID   string `json:"user_id"`
 	TenantID string `json:"tenant_id"`
+	LastSeq  uint64 `json:"last_seq"` // Latest sequence in the tenant, for ?since= on reconnect
 }
 
 // NewMessage creates a new message with the given type and payload
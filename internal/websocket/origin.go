@@ -0,0 +1,55 @@
+package websocket
+
+import (
+	"net/url"
+	"strings"
+)
+
+// OriginPolicy decides which browser origins may open a WebSocket for a tenant.
+// Without it any site could connect with the user's auth cookie.
+type OriginPolicy struct {
+	baseDomain string
+	extra      []string
+}
+
+// NewOriginPolicy allows the tenant's own subdomain and the base domain, plus
+// the extra origins given (full URLs or host patterns such as "*.example.com")
+func NewOriginPolicy(baseDomain string, extra ...string) *OriginPolicy {
+	p := &OriginPolicy{baseDomain: strings.ToLower(baseDomain)}
+	for _, origin := range extra {
+		if host := originHost(origin); host != "" {
+			p.extra = append(p.extra, host)
+		}
+	}
+	return p
+}
+
+// Patterns returns the origin host patterns accepted for a tenant, in the
+// format of websocket.AcceptOptions.OriginPatterns
+func (p *OriginPolicy) Patterns(tenantSlug string) []string {
+	patterns := make([]string, 0, len(p.extra)+2)
+	if p.baseDomain != "" {
+		patterns = append(patterns, p.baseDomain)
+		if tenantSlug != "" {
+			patterns = append(patterns, strings.ToLower(tenantSlug)+"."+p.baseDomain)
+		}
+	}
+	return append(patterns, p.extra...)
+}
+
+// originHost reduces a configured origin to the host[:port] pattern that
+// OriginPatterns matches against
+func originHost(origin string) string {
+	origin = strings.TrimSpace(origin)
+	if origin == "" {
+		return ""
+	}
+	if strings.Contains(origin, "://") {
+		u, err := url.Parse(origin)
+		if err != nil {
+			return ""
+		}
+		return strings.ToLower(u.Host)
+	}
+	return strings.ToLower(origin)
+}
@@ -0,0 +1,372 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	presenceTTL     = 90 * time.Second // Entries expire if a node dies without cleanup
+	presenceRefresh = 30 * time.Second
+	presenceBacklog = 256 // Pending views beyond this are dropped
+)
+
+var ErrInvalidResource = errors.New("invalid presence resource")
+
+// Resource types clients may announce they are viewing
+var presenceResourceTypes = map[string]bool{
+	"lesson": true,
+	"post":   true,
+}
+
+// PresenceKey scopes presence to a tenant, optionally narrowed to a resource
+// such as "lesson:<id>". An empty Resource means the tenant as a whole.
+type PresenceKey struct {
+	TenantID uuid.UUID
+	Resource string
+}
+
+// PresenceStore keeps track of which users are online. Entries are recorded
+// per node so a user connected to several instances stays online until the
+// last one leaves.
+type PresenceStore interface {
+	Add(ctx context.Context, key PresenceKey, userID uuid.UUID, nodeID string, ttl time.Duration) error
+	Remove(ctx context.Context, key PresenceKey, userID uuid.UUID, nodeID string) error
+	Users(ctx context.Context, key PresenceKey) ([]uuid.UUID, error)
+}
+
+// ParsePresenceResource validates a "type:uuid" resource identifier
+func ParsePresenceResource(resource string) (string, error) {
+	kind, id, ok := strings.Cut(resource, ":")
+	if !ok || !presenceResourceTypes[kind] {
+		return "", ErrInvalidResource
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return "", ErrInvalidResource
+	}
+	return kind + ":" + parsed.String(), nil
+}
+
+type presenceOpKind int
+
+const (
+	presenceConnect presenceOpKind = iota
+	presenceDisconnect
+	presenceView
+)
+
+type presenceOp struct {
+	kind     presenceOpKind
+	client   *Client
+	resource string
+}
+
+// Presence tracks online users per tenant and per viewed resource. All state
+// changes are serialized through a single loop so join/leave ordering holds.
+type Presence struct {
+	hub   *Hub
+	store PresenceStore
+
+	// Changes waiting for the run loop, in arrival order. Connects and
+	// disconnects are never dropped: a lost disconnect would keep the user
+	// online for as long as this node keeps refreshing the entry.
+	mu      sync.Mutex
+	pending []presenceOp
+	wake    chan struct{}
+
+	// Owned by the run loop
+	viewing map[*Client]string
+	local   map[PresenceKey]map[uuid.UUID]int
+}
+
+func newPresence(hub *Hub, store PresenceStore) *Presence {
+	return &Presence{
+		hub:     hub,
+		store:   store,
+		wake:    make(chan struct{}, 1),
+		viewing: make(map[*Client]string),
+		local:   make(map[PresenceKey]map[uuid.UUID]int),
+	}
+}
+
+// Snapshot returns the users currently present for the key
+func (p *Presence) Snapshot(ctx context.Context, key PresenceKey) ([]uuid.UUID, error) {
+	return p.store.Users(ctx, key)
+}
+
+// enqueue queues a change without blocking the caller. Only views are shed
+// when the loop falls behind; the client's next view supersedes them anyway.
+func (p *Presence) enqueue(op presenceOp) {
+	p.mu.Lock()
+	if op.kind == presenceView && len(p.pending) >= presenceBacklog {
+		p.mu.Unlock()
+		log.Printf("[WS] Presence backlog full, dropping view for user=%s", op.client.userID)
+		return
+	}
+	p.pending = append(p.pending, op)
+	p.mu.Unlock()
+
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// takePending hands the queued changes to the run loop
+func (p *Presence) takePending() []presenceOp {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ops := p.pending
+	p.pending = nil
+	return ops
+}
+
+func (p *Presence) connected(client *Client) {
+	p.enqueue(presenceOp{kind: presenceConnect, client: client})
+}
+
+func (p *Presence) disconnected(client *Client) {
+	p.enqueue(presenceOp{kind: presenceDisconnect, client: client})
+}
+
+func (p *Presence) view(client *Client, resource string) {
+	p.enqueue(presenceOp{kind: presenceView, client: client, resource: resource})
+}
+
+// run processes presence changes and refreshes entries before they expire
+func (p *Presence) run(ctx context.Context) {
+	ticker := time.NewTicker(presenceRefresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.removeAll()
+			return
+		case <-p.wake:
+			for _, op := range p.takePending() {
+				p.apply(ctx, op)
+			}
+		case <-ticker.C:
+			p.refresh(ctx)
+		}
+	}
+}
+
+func (p *Presence) apply(ctx context.Context, op presenceOp) {
+	client := op.client
+	tenantKey := PresenceKey{TenantID: client.tenantID}
+
+	switch op.kind {
+	case presenceConnect:
+		if _, ok := p.viewing[client]; ok {
+			return
+		}
+		p.viewing[client] = ""
+		p.join(ctx, tenantKey, client.userID)
+
+	case presenceDisconnect:
+		resource, ok := p.viewing[client]
+		if !ok {
+			return // Already removed (both pumps unregister)
+		}
+		delete(p.viewing, client)
+		if resource != "" {
+			p.leave(ctx, PresenceKey{TenantID: client.tenantID, Resource: resource}, client.userID)
+		}
+		p.leave(ctx, tenantKey, client.userID)
+
+	case presenceView:
+		current, ok := p.viewing[client]
+		if !ok || current == op.resource {
+			return
+		}
+		if current != "" {
+			p.leave(ctx, PresenceKey{TenantID: client.tenantID, Resource: current}, client.userID)
+		}
+		p.viewing[client] = op.resource
+		if op.resource != "" {
+			p.join(ctx, PresenceKey{TenantID: client.tenantID, Resource: op.resource}, client.userID)
+		}
+	}
+}
+
+// join records a local connection and announces the user if they just arrived
+func (p *Presence) join(ctx context.Context, key PresenceKey, userID uuid.UUID) {
+	if p.local[key] == nil {
+		p.local[key] = make(map[uuid.UUID]int)
+	}
+	p.local[key][userID]++
+	if p.local[key][userID] > 1 {
+		return // Another tab on this node already holds the entry
+	}
+
+	wasPresent := p.isPresent(ctx, key, userID)
+	if err := p.store.Add(ctx, key, userID, p.hub.nodeID, presenceTTL); err != nil {
+		log.Printf("[WS] Presence add failed: %v", err)
+		return
+	}
+	if !wasPresent {
+		p.announce(ctx, MessageTypePresenceJoin, key, userID)
+	}
+}
+
+// leave drops a local connection and announces the user once they are gone everywhere
+func (p *Presence) leave(ctx context.Context, key PresenceKey, userID uuid.UUID) {
+	users := p.local[key]
+	if users == nil || users[userID] == 0 {
+		return
+	}
+	users[userID]--
+	if users[userID] > 0 {
+		return
+	}
+	delete(users, userID)
+	if len(users) == 0 {
+		delete(p.local, key)
+	}
+
+	if err := p.store.Remove(ctx, key, userID, p.hub.nodeID); err != nil {
+		log.Printf("[WS] Presence remove failed: %v", err)
+		return
+	}
+	if !p.isPresent(ctx, key, userID) {
+		p.announce(ctx, MessageTypePresenceLeave, key, userID)
+	}
+}
+
+func (p *Presence) isPresent(ctx context.Context, key PresenceKey, userID uuid.UUID) bool {
+	users, err := p.store.Users(ctx, key)
+	if err != nil {
+		return false
+	}
+	for _, id := range users {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// announce broadcasts a presence change with the resulting user count.
+// Tenant presence goes to the whole tenant; resource presence only to the
+// subscribers of the resource's topic, whose name is the resource itself.
+func (p *Presence) announce(ctx context.Context, msgType MessageType, key PresenceKey, userID uuid.UUID) {
+	count := 0
+	if users, err := p.store.Users(ctx, key); err == nil {
+		count = len(users)
+	}
+
+	msg, err := NewMessage(msgType, PresencePayload{
+		UserID:   userID,
+		Resource: key.Resource,
+		Count:    count,
+	})
+	if err != nil {
+		return
+	}
+	if key.Resource != "" {
+		p.hub.tryPublishToTopic(&TopicMessage{TenantID: key.TenantID, Topic: key.Resource, Message: msg})
+		return
+	}
+	p.hub.tryBroadcast(&BroadcastMessage{TenantID: key.TenantID, Message: msg})
+}
+
+// refresh extends the TTL of every entry held by this node
+func (p *Presence) refresh(ctx context.Context) {
+	for key, users := range p.local {
+		for userID := range users {
+			if err := p.store.Add(ctx, key, userID, p.hub.nodeID, presenceTTL); err != nil {
+				log.Printf("[WS] Presence refresh failed: %v", err)
+				return
+			}
+		}
+	}
+}
+
+// removeAll clears this node's entries on shutdown
+func (p *Presence) removeAll() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for key, users := range p.local {
+		for userID := range users {
+			_ = p.store.Remove(ctx, key, userID, p.hub.nodeID)
+		}
+	}
+	p.local = make(map[PresenceKey]map[uuid.UUID]int)
+}
+
+// MemoryPresenceStore is a PresenceStore for single-node deployments and tests
+type MemoryPresenceStore struct {
+	entries map[PresenceKey]map[string]presenceEntry
+	mu      sync.Mutex
+}
+
+type presenceEntry struct {
+	userID    uuid.UUID
+	expiresAt time.Time
+}
+
+// NewMemoryPresenceStore creates an in-memory presence store
+func NewMemoryPresenceStore() *MemoryPresenceStore {
+	return &MemoryPresenceStore{
+		entries: make(map[PresenceKey]map[string]presenceEntry),
+	}
+}
+
+func (s *MemoryPresenceStore) Add(ctx context.Context, key PresenceKey, userID uuid.UUID, nodeID string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.entries[key] == nil {
+		s.entries[key] = make(map[string]presenceEntry)
+	}
+	s.entries[key][presenceMember(userID, nodeID)] = presenceEntry{userID: userID, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryPresenceStore) Remove(ctx context.Context, key PresenceKey, userID uuid.UUID, nodeID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if members, ok := s.entries[key]; ok {
+		delete(members, presenceMember(userID, nodeID))
+		if len(members) == 0 {
+			delete(s.entries, key)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryPresenceStore) Users(ctx context.Context, key PresenceKey) ([]uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	seen := make(map[uuid.UUID]bool)
+	users := make([]uuid.UUID, 0)
+	for member, entry := range s.entries[key] {
+		if now.After(entry.expiresAt) {
+			delete(s.entries[key], member)
+			continue
+		}
+		if !seen[entry.userID] {
+			seen[entry.userID] = true
+			users = append(users, entry.userID)
+		}
+	}
+	return users, nil
+}
+
+// presenceMember identifies a user's presence on one node
+func presenceMember(userID uuid.UUID, nodeID string) string {
+	return userID.String() + "|" + nodeID
+}
@@ -0,0 +1,74 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisPresenceStore shares presence between API instances using sorted sets
+// scored by expiry time, so entries from crashed nodes age out on their own
+type RedisPresenceStore struct {
+	client *redis.Client
+}
+
+// NewRedisPresenceStore creates a presence store on an existing Redis client
+func NewRedisPresenceStore(client *redis.Client) *RedisPresenceStore {
+	return &RedisPresenceStore{client: client}
+}
+
+// presenceRedisKey returns the sorted set key for a presence scope
+func presenceRedisKey(key PresenceKey) string {
+	if key.Resource == "" {
+		return fmt.Sprintf("presence:%s:tenant", key.TenantID)
+	}
+	return fmt.Sprintf("presence:%s:%s", key.TenantID, key.Resource)
+}
+
+func (s *RedisPresenceStore) Add(ctx context.Context, key PresenceKey, userID uuid.UUID, nodeID string, ttl time.Duration) error {
+	redisKey := presenceRedisKey(key)
+	expiresAt := time.Now().Add(ttl)
+
+	pipe := s.client.TxPipeline()
+	pipe.ZAdd(ctx, redisKey, redis.Z{Score: float64(expiresAt.Unix()), Member: presenceMember(userID, nodeID)})
+	pipe.Expire(ctx, redisKey, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (s *RedisPresenceStore) Remove(ctx context.Context, key PresenceKey, userID uuid.UUID, nodeID string) error {
+	return s.client.ZRem(ctx, presenceRedisKey(key), presenceMember(userID, nodeID)).Err()
+}
+
+func (s *RedisPresenceStore) Users(ctx context.Context, key PresenceKey) ([]uuid.UUID, error) {
+	redisKey := presenceRedisKey(key)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+
+	// Drop expired entries before reading
+	if err := s.client.ZRemRangeByScore(ctx, redisKey, "-inf", "("+now).Err(); err != nil {
+		return nil, err
+	}
+
+	members, err := s.client.ZRange(ctx, redisKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	users := make([]uuid.UUID, 0, len(members))
+	for _, member := range members {
+		raw, _, _ := strings.Cut(member, "|")
+		userID, err := uuid.Parse(raw)
+		if err != nil || seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		users = append(users, userID)
+	}
+	return users, nil
+}
@@ -0,0 +1,161 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func waitForUsers(t *testing.T, presence *Presence, key PresenceKey, want int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		users, _ := presence.Snapshot(context.Background(), key)
+		if len(users) == want {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	users, _ := presence.Snapshot(context.Background(), key)
+	t.Fatalf("expected %d users for %+v, got %d", want, key, len(users))
+}
+
+func TestPresenceCountsUsersNotConnections(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hub := NewHub()
+	go hub.Run(ctx)
+
+	tenantID, userID := uuid.New(), uuid.New()
+	key := PresenceKey{TenantID: tenantID}
+
+	tab1 := newTestClient(hub, tenantID, userID)
+	tab2 := newTestClient(hub, tenantID, userID)
+	hub.Register(tab1)
+	hub.Register(tab2)
+	waitForUsers(t, hub.Presence(), key, 1)
+
+	hub.Unregister(tab1)
+	time.Sleep(20 * time.Millisecond)
+	waitForUsers(t, hub.Presence(), key, 1)
+
+	hub.Unregister(tab2)
+	waitForUsers(t, hub.Presence(), key, 0)
+}
+
+func TestPresenceTracksViewedResourceAcrossNodes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := NewMemoryPresenceStore()
+	backplane := NewMemoryBackplane()
+	nodeA, nodeB := NewHub(), NewHub()
+	for _, hub := range []*Hub{nodeA, nodeB} {
+		hub.UseBackplane(backplane)
+		hub.UsePresenceStore(store)
+		go hub.Run(ctx)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	tenantID, lessonID := uuid.New(), uuid.New()
+	resource := LessonTopic(lessonID).String()
+	lessonKey := PresenceKey{TenantID: tenantID, Resource: resource}
+
+	watcher := newTestClient(nodeB, tenantID, uuid.New())
+	bystander := newTestClient(nodeB, tenantID, uuid.New())
+	viewer := newTestClient(nodeA, tenantID, uuid.New())
+	nodeB.Register(watcher)
+	nodeB.Register(bystander)
+	nodeA.Register(viewer)
+	if err := nodeB.subscribe(watcher, LessonTopic(lessonID)); err != nil {
+		t.Fatal(err)
+	}
+
+	nodeA.Presence().view(viewer, resource)
+	waitForUsers(t, nodeB.Presence(), lessonKey, 1)
+
+	// The watcher on the other node hears about the lesson join
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case msg := <-watcher.send:
+			var payload PresencePayload
+			_ = json.Unmarshal(msg.Payload, &payload)
+			if msg.Type == MessageTypePresenceJoin && payload.Resource == resource {
+				if payload.UserID != viewer.userID || payload.Count != 1 {
+					t.Fatalf("unexpected join payload: %+v", payload)
+				}
+				nodeA.Unregister(viewer)
+				waitForUsers(t, nodeB.Presence(), lessonKey, 0)
+
+				// Tenant members not following the lesson never hear of it
+				for len(bystander.send) > 0 {
+					msg := <-bystander.send
+					var seen PresencePayload
+					_ = json.Unmarshal(msg.Payload, &seen)
+					if seen.Resource != "" {
+						t.Fatalf("bystander received lesson presence %s", msg.Type)
+					}
+				}
+				return
+			}
+		case <-deadline:
+			t.Fatal("timed out waiting for lesson presence:join")
+		}
+	}
+}
+
+func TestPresenceNeverDropsDisconnects(t *testing.T) {
+	hub := NewHub()
+	tenantID := uuid.New()
+	client := newTestClient(hub, tenantID, uuid.New())
+	resource := LessonTopic(uuid.New()).String()
+
+	// Back the queue up while the loop is not running
+	hub.presence.connected(client)
+	for i := 0; i < presenceBacklog*2; i++ {
+		hub.presence.view(client, resource)
+	}
+	hub.presence.disconnected(client)
+
+	pending := hub.presence.pending
+	if len(pending) > presenceBacklog+1 {
+		t.Fatalf("expected views to be shed, %d ops pending", len(pending))
+	}
+	if last := pending[len(pending)-1]; last.kind != presenceDisconnect {
+		t.Fatalf("disconnect was dropped, last op is %d", last.kind)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	waitForUsers(t, hub.Presence(), PresenceKey{TenantID: tenantID}, 0)
+	waitForUsers(t, hub.Presence(), PresenceKey{TenantID: tenantID, Resource: resource}, 0)
+	if len(hub.presence.local) != 0 {
+		t.Fatalf("node still refreshes %d presence keys", len(hub.presence.local))
+	}
+}
+
+func TestPresenceViewRequiresTopicAccess(t *testing.T) {
+	hub := NewHub()
+	client := newTestClient(hub, uuid.New(), uuid.New())
+	hub.presence.connected(client)
+
+	msg, _ := NewMessage(MessageTypePresenceView, PresenceViewPayload{Resource: LessonTopic(uuid.New()).String()})
+	client.handleMessage(msg)
+
+	reply := <-client.send
+	if reply.Type != MessageTypeError {
+		t.Fatalf("expected an error reply, got %s", reply.Type)
+	}
+	for _, op := range hub.presence.pending {
+		if op.kind == presenceView {
+			t.Fatalf("unauthorized view was recorded")
+		}
+	}
+}
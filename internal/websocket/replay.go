@@ -0,0 +1,169 @@
+package websocket
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// replayLogSize is how many messages are kept per tenant for replay
+	replayLogSize = 1000
+
+	// maxReplayMessages caps what a single reconnect may replay; larger gaps
+	// get resync_required instead
+	maxReplayMessages = 200
+)
+
+// ReplayLog assigns per-tenant sequence numbers to outgoing messages and keeps
+// a bounded history so reconnecting clients can catch up
+type ReplayLog interface {
+	// Append stores a message and returns its sequence number in the tenant
+	Append(ctx context.Context, env *Envelope) (uint64, error)
+
+	// Since returns the entries after seq, oldest first. ok is false when
+	// entries after seq have already been dropped from the log.
+	Since(ctx context.Context, tenantID uuid.UUID, seq uint64) (entries []*Envelope, ok bool, err error)
+
+	// LastSeq returns the latest sequence number assigned in the tenant
+	LastSeq(ctx context.Context, tenantID uuid.UUID) (uint64, error)
+}
+
+// replayTo sends a reconnecting client the messages it missed after since, or
+// resync_required when they are no longer available. The client must have
+// entered replay mode before registering so live messages queue behind the replay.
+func (h *Hub) replayTo(ctx context.Context, client *Client, since uint64) {
+	entries, ok, err := h.replay.Since(ctx, client.tenantID, since)
+	if err != nil {
+		log.Printf("[WS] Failed to load replay for user=%s: %v", client.userID, err)
+		ok = false
+	}
+
+	var missed []*Message
+	if ok {
+		missed = h.filterReplay(client, entries)
+		ok = len(missed) <= maxReplayMessages
+	}
+
+	if !ok {
+		last, _ := h.replay.LastSeq(ctx, client.tenantID)
+		resync, _ := NewMessage(MessageTypeResyncRequired, ResyncRequiredPayload{LastSeq: last})
+		client.finishReplay(nil, resync)
+		return
+	}
+	client.finishReplay(missed, nil)
+}
+
+// filterReplay keeps the entries the client would have received live
+func (h *Hub) filterReplay(client *Client, entries []*Envelope) []*Message {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var missed []*Message
+	for _, env := range entries {
+		switch env.Kind {
+		case EnvelopeBroadcast:
+		case EnvelopeDirect:
+			if env.UserID != client.userID {
+				continue
+			}
+		case EnvelopeTopic:
+			if _, ok := client.topics[env.Topic]; !ok {
+				continue
+			}
+		default:
+			continue
+		}
+		missed = append(missed, env.Message)
+	}
+	return missed
+}
+
+// isEphemeral reports whether a message describes transient state that is
+// refetched on reconnect rather than replayed
+func isEphemeral(msgType MessageType) bool {
+	return msgType == MessageTypePresenceJoin || msgType == MessageTypePresenceLeave
+}
+
+// MemoryReplayLog is a ReplayLog holding a ring of recent messages per tenant.
+// Sequences are local to the process, so it only suits single-node setups.
+type MemoryReplayLog struct {
+	size    int
+	tenants map[uuid.UUID]*tenantReplay
+	mu      sync.Mutex
+}
+
+type tenantReplay struct {
+	last    uint64
+	entries []*Envelope
+}
+
+// NewMemoryReplayLog creates an in-memory replay log keeping size messages per tenant
+func NewMemoryReplayLog(size int) *MemoryReplayLog {
+	return &MemoryReplayLog{
+		size:    size,
+		tenants: make(map[uuid.UUID]*tenantReplay),
+	}
+}
+
+func (l *MemoryReplayLog) Append(ctx context.Context, env *Envelope) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	tenant := l.tenants[env.TenantID]
+	if tenant == nil {
+		tenant = &tenantReplay{}
+		l.tenants[env.TenantID] = tenant
+	}
+	tenant.last++
+
+	// Store a copy so callers can keep using their envelope
+	msg := *env.Message
+	msg.Seq = tenant.last
+	entry := *env
+	entry.Message = &msg
+
+	tenant.entries = append(tenant.entries, &entry)
+	if len(tenant.entries) > l.size {
+		tenant.entries = tenant.entries[len(tenant.entries)-l.size:]
+	}
+	return tenant.last, nil
+}
+
+func (l *MemoryReplayLog) Since(ctx context.Context, tenantID uuid.UUID, seq uint64) ([]*Envelope, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	tenant := l.tenants[tenantID]
+	if tenant == nil {
+		return nil, seq == 0, nil
+	}
+	if seq > tenant.last {
+		return nil, false, nil
+	}
+	if seq == tenant.last {
+		return nil, true, nil
+	}
+
+	oldest := tenant.entries[0].Message.Seq
+	if seq+1 < oldest {
+		return nil, false, nil
+	}
+
+	start := int(seq + 1 - oldest)
+	entries := make([]*Envelope, len(tenant.entries)-start)
+	copy(entries, tenant.entries[start:])
+	return entries, true, nil
+}
+
+func (l *MemoryReplayLog) LastSeq(ctx context.Context, tenantID uuid.UUID) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if tenant := l.tenants[tenantID]; tenant != nil {
+		return tenant.last, nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,137 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// replayStreamTTL expires idle tenant streams; the sequence counter is kept
+const replayStreamTTL = 24 * time.Hour
+
+// appendScript bumps the tenant counter and adds the entry under that ID in one
+// step, so concurrent nodes never write stream IDs out of order
+var appendScript = redis.NewScript(`
+local seq = redis.call('INCR', KEYS[1])
+redis.call('XADD', KEYS[2], 'MAXLEN', '~', ARGV[2], seq .. '-1', 'entry', ARGV[1])
+redis.call('EXPIRE', KEYS[2], ARGV[3])
+return seq
+`)
+
+// RedisReplayLog shares sequence numbers and replay history between API
+// instances using a counter and a capped stream per tenant
+type RedisReplayLog struct {
+	client *redis.Client
+}
+
+// NewRedisReplayLog creates a replay log on an existing Redis client
+func NewRedisReplayLog(client *redis.Client) *RedisReplayLog {
+	return &RedisReplayLog{client: client}
+}
+
+func replaySeqKey(tenantID uuid.UUID) string {
+	return fmt.Sprintf("ws:seq:%s", tenantID)
+}
+
+func replayStreamKey(tenantID uuid.UUID) string {
+	return fmt.Sprintf("ws:replay:%s", tenantID)
+}
+
+func (l *RedisReplayLog) Append(ctx context.Context, env *Envelope) (uint64, error) {
+	data, err := json.Marshal(env)
+	if err != nil {
+		return 0, err
+	}
+
+	keys := []string{replaySeqKey(env.TenantID), replayStreamKey(env.TenantID)}
+	seq, err := appendScript.Run(ctx, l.client, keys, data, replayLogSize, int(replayStreamTTL.Seconds())).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(seq), nil
+}
+
+func (l *RedisReplayLog) Since(ctx context.Context, tenantID uuid.UUID, seq uint64) ([]*Envelope, bool, error) {
+	last, err := l.LastSeq(ctx, tenantID)
+	if err != nil {
+		return nil, false, err
+	}
+	if seq > last {
+		return nil, false, nil
+	}
+	if seq == last {
+		return nil, true, nil
+	}
+
+	streamKey := replayStreamKey(tenantID)
+	oldest, err := l.client.XRangeN(ctx, streamKey, "-", "+", 1).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	if len(oldest) == 0 {
+		return nil, false, nil
+	}
+	oldestSeq, err := streamSeq(oldest[0].ID)
+	if err != nil {
+		return nil, false, err
+	}
+	if seq+1 < oldestSeq {
+		return nil, false, nil
+	}
+
+	start := fmt.Sprintf("%d-0", seq+1)
+	messages, err := l.client.XRangeN(ctx, streamKey, start, "+", replayLogSize).Result()
+	if err != nil {
+		return nil, false, err
+	}
+
+	entries := make([]*Envelope, 0, len(messages))
+	for _, msg := range messages {
+		entry, err := decodeReplayEntry(msg)
+		if err != nil {
+			return nil, false, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, true, nil
+}
+
+func (l *RedisReplayLog) LastSeq(ctx context.Context, tenantID uuid.UUID) (uint64, error) {
+	last, err := l.client.Get(ctx, replaySeqKey(tenantID)).Uint64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return last, err
+}
+
+// decodeReplayEntry restores an envelope and stamps it with its stream sequence
+func decodeReplayEntry(msg redis.XMessage) (*Envelope, error) {
+	seq, err := streamSeq(msg.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, _ := msg.Values["entry"].(string)
+	var env Envelope
+	if err := json.Unmarshal([]byte(raw), &env); err != nil {
+		return nil, err
+	}
+	if env.Message == nil {
+		return nil, fmt.Errorf("replay entry %s has no message", msg.ID)
+	}
+	env.Message.Seq = seq
+	return &env, nil
+}
+
+// streamSeq extracts the sequence from a "<seq>-1" stream ID
+func streamSeq(id string) (uint64, error) {
+	seq, _, _ := strings.Cut(id, "-")
+	return strconv.ParseUint(seq, 10, 64)
+}
@@ -0,0 +1,152 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestMemoryReplayLogDetectsGaps(t *testing.T) {
+	ctx := context.Background()
+	replay := NewMemoryReplayLog(3)
+	tenantID := uuid.New()
+
+	for i := 0; i < 5; i++ {
+		msg, _ := NewMessage(MessageTypePostCreated, nil)
+		if _, err := replay.Append(ctx, &Envelope{Kind: EnvelopeBroadcast, TenantID: tenantID, Message: msg}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+
+	entries, ok, _ := replay.Since(ctx, tenantID, 2)
+	if !ok || len(entries) != 3 || entries[0].Message.Seq != 3 {
+		t.Fatalf("expected seq 3..5, got ok=%v entries=%d", ok, len(entries))
+	}
+
+	// Seq 2 has been dropped, so a client at seq 1 cannot catch up
+	if _, ok, _ := replay.Since(ctx, tenantID, 1); ok {
+		t.Fatal("expected gap to be reported")
+	}
+
+	// A client ahead of the log (e.g. after a restart) must resync
+	if _, ok, _ := replay.Since(ctx, tenantID, 9); ok {
+		t.Fatal("expected future sequence to be reported")
+	}
+}
+
+func TestReconnectReplaysMissedMessages(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hub := NewHub()
+	go hub.Run(ctx)
+
+	tenantID, userID := uuid.New(), uuid.New()
+	for i := 0; i < 3; i++ {
+		msg, _ := NewMessage(MessageTypePostCreated, nil)
+		hub.BroadcastToTenant(tenantID, msg)
+	}
+	deadline := time.Now().Add(time.Second)
+	for last, _ := hub.replay.LastSeq(ctx, tenantID); last < 3; last, _ = hub.replay.LastSeq(ctx, tenantID) {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for messages to be sequenced")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	client := newTestClient(hub, tenantID, userID)
+	client.beginReplay()
+	hub.Register(client)
+	hub.replayTo(ctx, client, 1)
+
+	for _, want := range []uint64{2, 3} {
+		if msg := expectMessage(t, client, MessageTypePostCreated); msg.Seq != want {
+			t.Fatalf("expected seq %d, got %d", want, msg.Seq)
+		}
+	}
+
+	// Live messages keep counting from there
+	msg, _ := NewMessage(MessageTypePostCreated, nil)
+	hub.BroadcastToTenant(tenantID, msg)
+	if got := expectMessage(t, client, MessageTypePostCreated); got.Seq != 4 {
+		t.Fatalf("expected seq 4, got %d", got.Seq)
+	}
+}
+
+func TestReconnectWithStaleSequenceRequiresResync(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hub := NewHub()
+	hub.UseReplayLog(NewMemoryReplayLog(1))
+	go hub.Run(ctx)
+
+	tenantID := uuid.New()
+	for i := 0; i < 2; i++ {
+		msg, _ := NewMessage(MessageTypePostCreated, nil)
+		hub.BroadcastToTenant(tenantID, msg)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	client := newTestClient(hub, tenantID, uuid.New())
+	client.beginReplay()
+	hub.Register(client)
+	hub.replayTo(ctx, client, 0)
+
+	expectMessage(t, client, MessageTypeResyncRequired)
+}
+
+// stallingReplayLog blocks appends for one tenant until released
+type stallingReplayLog struct {
+	*MemoryReplayLog
+	stalled uuid.UUID
+	release chan struct{}
+}
+
+func (r *stallingReplayLog) Append(ctx context.Context, env *Envelope) (uint64, error) {
+	if env.TenantID == r.stalled {
+		<-r.release
+	}
+	return r.MemoryReplayLog.Append(ctx, env)
+}
+
+func TestSlowReplayLogDoesNotStallOtherTenants(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hub := NewHub()
+	slowTenant := uuid.New()
+	replay := &stallingReplayLog{MemoryReplayLog: NewMemoryReplayLog(replayLogSize), stalled: slowTenant, release: make(chan struct{})}
+	defer close(replay.release)
+	hub.UseReplayLog(replay)
+	go hub.Run(ctx)
+
+	// A tenant on a different sequencer than the stalled one
+	otherTenant := uuid.New()
+	for sequencerShard(otherTenant) == sequencerShard(slowTenant) {
+		otherTenant = uuid.New()
+	}
+
+	stalled, _ := NewMessage(MessageTypePostCreated, nil)
+	hub.BroadcastToTenant(slowTenant, stalled)
+
+	// Registration and delivery elsewhere carry on while the append hangs
+	client := newTestClient(hub, otherTenant, uuid.New())
+	registered := make(chan struct{})
+	go func() {
+		hub.Register(client)
+		close(registered)
+	}()
+	select {
+	case <-registered:
+	case <-time.After(time.Second):
+		t.Fatal("registration blocked behind a stalled replay append")
+	}
+	msg, _ := NewMessage(MessageTypePostCreated, nil)
+	hub.BroadcastToTenant(otherTenant, msg)
+	if got := expectMessage(t, client, MessageTypePostCreated); got.Seq != 1 {
+		t.Fatalf("expected seq 1, got %d", got.Seq)
+	}
+}
@@ -0,0 +1,140 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/nickkcj/orbit-backend/internal/middleware"
+)
+
+// HandleEventStream streams the same messages as /ws over Server-Sent Events,
+// for clients behind proxies that drop WebSockets. The stream is read-only:
+// topics are chosen up front and chat is not available.
+// Endpoint: GET /api/v1/events/stream?topics=post:<id>,lesson:<id>
+// Resumes from the Last-Event-ID header (or ?since=<seq>) like /ws?since=
+func (h *Handler) HandleEventStream(c echo.Context) error {
+	tenant := middleware.GetTenantFromContext(c)
+	user := middleware.GetUserFromContext(c)
+	if tenant == nil || user == nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+	if err := h.authenticator.authorize(c.Request().Context(), *user, *tenant); err != nil {
+		return denyAccess(c, err)
+	}
+
+	res := c.Response()
+	if _, ok := res.Writer.(http.Flusher); !ok {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "streaming not supported"})
+	}
+
+	lastEventID := c.Request().Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.QueryParam("since")
+	}
+	since, replay := parseSince(lastEventID)
+
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no") // Disable proxy buffering
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	ctx := c.Request().Context()
+	client := NewClient(h.hub, nil, tenant.ID, user.ID, h.topics, nil)
+	if claims := middleware.GetClaimsFromContext(c); claims != nil {
+		client.sessionID = claims.SessionID
+		client.sessions = h.authenticator
+	}
+	h.attach(ctx, client, c.QueryParam("topics"), replay)
+
+	if replay {
+		go h.hub.replayTo(ctx, client, since)
+	}
+	client.StreamEvents(ctx, res)
+
+	return nil
+}
+
+// StreamEvents writes queued messages as SSE frames until the request ends,
+// sending a comment line periodically to keep proxies from timing out
+func (c *Client) StreamEvents(ctx context.Context, res *echo.Response) {
+	ticker := time.NewTicker(pingInterval)
+	defer func() {
+		ticker.Stop()
+		c.cancel()
+		c.hub.unregister <- c
+	}()
+	var sessionCheckedAt time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.ctx.Done():
+			c.writeDisconnected(res)
+			return
+		case msg, ok := <-c.send:
+			if !ok {
+				return
+			}
+			if err := writeEvent(res, msg); err != nil {
+				log.Printf("[SSE] Failed to write message: %v", err)
+				return
+			}
+			res.Flush()
+		case <-ticker.C:
+			if time.Since(sessionCheckedAt) >= sessionCheckInterval {
+				sessionCheckedAt = time.Now()
+				if c.sessionEnded() {
+					c.disconnect(StatusSessionEnded, "session ended")
+					continue
+				}
+			}
+
+			if _, err := io.WriteString(res, ": ping\n\n"); err != nil {
+				return
+			}
+			res.Flush()
+		}
+	}
+}
+
+// writeDisconnected tells an SSE client why the server ended its stream, so it
+// can stop reconnecting
+func (c *Client) writeDisconnected(res *echo.Response) {
+	if c.closeCode == 0 {
+		return
+	}
+	msg, err := NewMessage(MessageTypeDisconnected, DisconnectedPayload{Code: int(c.closeCode), Reason: c.closeReason})
+	if err != nil {
+		return
+	}
+	if err := writeEvent(res, msg); err == nil {
+		res.Flush()
+	}
+}
+
+// writeEvent encodes a message as one SSE frame. Sequenced messages carry
+// their sequence as the event ID so EventSource resumes with Last-Event-ID.
+func writeEvent(w io.Writer, msg *Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	if msg.Seq != 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", msg.Seq); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", msg.Type, data)
+	return err
+}
@@ -0,0 +1,158 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/nickkcj/orbit-backend/internal/database"
+	"github.com/nickkcj/orbit-backend/internal/middleware"
+)
+
+// newStreamServer serves the event stream as the given user and tenant, as
+// the tenant and auth middleware would
+func newStreamServer(hub *Hub, members MemberLookup, tenant *database.Tenant, user *database.User) *httptest.Server {
+	signedIn := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(middleware.TenantContextKey, tenant)
+			c.Set(middleware.UserContextKey, user)
+			return next(c)
+		}
+	}
+
+	e := echo.New()
+	handler := NewHandler(hub, &Authenticator{members: members}, nil, nil, nil)
+	e.GET("/events/stream", handler.HandleEventStream, signedIn)
+	e.POST("/ws/ticket", handler.IssueTicket, signedIn)
+	return httptest.NewServer(e)
+}
+
+func TestEventStreamRejectsBannedAndNonMembers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hub := NewHub()
+	go hub.Run(ctx)
+
+	tests := []struct {
+		name   string
+		status string // Membership status; empty for a non-member
+	}{
+		{name: "banned", status: "banned"},
+		{name: "suspended", status: "suspended"},
+		{name: "non-member"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenant := &database.Tenant{ID: uuid.New(), Status: "active"}
+			user := &database.User{ID: uuid.New(), Status: "active"}
+			members := newFakeMembers()
+			if tt.status != "" {
+				members.set(tenant.ID, user.ID, tt.status)
+			}
+
+			server := newStreamServer(hub, members, tenant, user)
+			defer server.Close()
+
+			for _, req := range []struct{ method, path string }{
+				{http.MethodGet, "/events/stream"},
+				{http.MethodPost, "/ws/ticket"},
+			} {
+				r, _ := http.NewRequestWithContext(ctx, req.method, server.URL+req.path, nil)
+				res, err := http.DefaultClient.Do(r)
+				if err != nil {
+					t.Fatalf("request failed: %v", err)
+				}
+				res.Body.Close()
+				if res.StatusCode != http.StatusForbidden {
+					t.Fatalf("%s %s: expected 403, got %d", req.method, req.path, res.StatusCode)
+				}
+			}
+			if count := hub.GetTenantClientCount(tenant.ID); count != 0 {
+				t.Fatalf("rejected caller was attached to the hub")
+			}
+		})
+	}
+}
+
+func TestEventStreamResumesFromLastEventID(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hub := NewHub()
+	go hub.Run(ctx)
+
+	tenant := &database.Tenant{ID: uuid.New(), Status: "active"}
+	user := &database.User{ID: uuid.New(), Status: "active"}
+	members := newFakeMembers()
+	members.set(tenant.ID, user.ID, "active")
+
+	// Two messages are sent before the client (re)connects
+	for i := 0; i < 2; i++ {
+		msg, _ := NewMessage(MessageTypePostCreated, nil)
+		hub.BroadcastToTenant(tenant.ID, msg)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	server := newStreamServer(hub, members, tenant, user)
+	defer server.Close()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/events/stream", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer res.Body.Close()
+
+	if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	expectLine := func(prefix string) string {
+		t.Helper()
+		timeout := time.After(time.Second)
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					t.Fatalf("stream closed waiting for %q", prefix)
+				}
+				if strings.HasPrefix(line, prefix) {
+					return line
+				}
+			case <-timeout:
+				t.Fatalf("timed out waiting for %q", prefix)
+			}
+		}
+	}
+
+	expectLine("event: " + string(MessageTypeConnected))
+	if line := expectLine("id: "); line != "id: 2" {
+		t.Fatalf("expected replay of seq 2, got %q", line)
+	}
+	expectLine("event: " + string(MessageTypePostCreated))
+
+	msg, _ := NewMessage(MessageTypePostCreated, nil)
+	hub.BroadcastToTenant(tenant.ID, msg)
+	if line := expectLine("id: "); line != "id: 3" {
+		t.Fatalf("expected live seq 3, got %q", line)
+	}
+}
@@ -0,0 +1,94 @@
+package websocket
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ticketTTL is how long a connection ticket can be redeemed after it is issued
+const ticketTTL = 30 * time.Second
+
+var ErrInvalidTicket = errors.New("invalid or expired ticket")
+
+// Ticket is a single-use credential for opening one WebSocket connection. It
+// keeps the JWT out of the /ws URL, which ends up in proxy and access logs.
+type Ticket struct {
+	UserID    uuid.UUID `json:"user_id"`
+	TenantID  uuid.UUID `json:"tenant_id"`
+	SessionID uuid.UUID `json:"session_id"` // Login session of the token the ticket was issued for
+}
+
+// TicketStore keeps issued tickets until they are redeemed or expire
+type TicketStore interface {
+	// Save stores a ticket under id for ttl
+	Save(ctx context.Context, id string, ticket Ticket, ttl time.Duration) error
+
+	// Take returns and deletes a ticket. It returns ErrInvalidTicket when the
+	// ticket does not exist, expired or was already used.
+	Take(ctx context.Context, id string) (*Ticket, error)
+}
+
+// newTicketID returns an unguessable ticket identifier
+func newTicketID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// MemoryTicketStore is a TicketStore for single-node setups
+type MemoryTicketStore struct {
+	tickets map[string]memoryTicket
+	mu      sync.Mutex
+}
+
+type memoryTicket struct {
+	ticket    Ticket
+	expiresAt time.Time
+}
+
+// NewMemoryTicketStore creates an in-memory ticket store
+func NewMemoryTicketStore() *MemoryTicketStore {
+	return &MemoryTicketStore{
+		tickets: make(map[string]memoryTicket),
+	}
+}
+
+func (s *MemoryTicketStore) Save(ctx context.Context, id string, ticket Ticket, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Drop expired tickets that were never redeemed
+	now := time.Now()
+	for key, entry := range s.tickets {
+		if now.After(entry.expiresAt) {
+			delete(s.tickets, key)
+		}
+	}
+
+	s.tickets[id] = memoryTicket{ticket: ticket, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (s *MemoryTicketStore) Take(ctx context.Context, id string) (*Ticket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.tickets[id]
+	if !ok {
+		return nil, ErrInvalidTicket
+	}
+	delete(s.tickets, id)
+
+	if time.Now().After(entry.expiresAt) {
+		return nil, ErrInvalidTicket
+	}
+	return &entry.ticket, nil
+}
@@ -0,0 +1,51 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisTicketStore shares connection tickets between API instances, so a
+// ticket issued by one node can be redeemed on another
+type RedisTicketStore struct {
+	client *redis.Client
+}
+
+// NewRedisTicketStore creates a ticket store on an existing Redis client
+func NewRedisTicketStore(client *redis.Client) *RedisTicketStore {
+	return &RedisTicketStore{client: client}
+}
+
+func ticketKey(id string) string {
+	return fmt.Sprintf("ws:ticket:%s", id)
+}
+
+func (s *RedisTicketStore) Save(ctx context.Context, id string, ticket Ticket, ttl time.Duration) error {
+	data, err := json.Marshal(ticket)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, ticketKey(id), data, ttl).Err()
+}
+
+func (s *RedisTicketStore) Take(ctx context.Context, id string) (*Ticket, error) {
+	// GETDEL makes redemption atomic, so a ticket works at most once
+	data, err := s.client.GetDel(ctx, ticketKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrInvalidTicket
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ticket Ticket
+	if err := json.Unmarshal(data, &ticket); err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
@@ -0,0 +1,57 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestMemoryTicketStoreSingleUse(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryTicketStore()
+	want := Ticket{UserID: uuid.New(), TenantID: uuid.New()}
+
+	if err := store.Save(ctx, "abc", want, time.Minute); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	got, err := store.Take(ctx, "abc")
+	if err != nil {
+		t.Fatalf("take: %v", err)
+	}
+	if *got != want {
+		t.Fatalf("expected %+v, got %+v", want, *got)
+	}
+
+	if _, err := store.Take(ctx, "abc"); !errors.Is(err, ErrInvalidTicket) {
+		t.Fatalf("expected second take to fail, got %v", err)
+	}
+}
+
+func TestMemoryTicketStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryTicketStore()
+
+	if err := store.Save(ctx, "abc", Ticket{UserID: uuid.New()}, time.Millisecond); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := store.Take(ctx, "abc"); !errors.Is(err, ErrInvalidTicket) {
+		t.Fatalf("expected expired ticket to fail, got %v", err)
+	}
+}
+
+func TestOriginPolicyPatterns(t *testing.T) {
+	policy := NewOriginPolicy("orbit.app.br", "http://localhost:3000", "*.preview.example.com", " ")
+
+	got := policy.Patterns("Acme")
+	want := []string{"orbit.app.br", "acme.orbit.app.br", "localhost:3000", "*.preview.example.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,153 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nickkcj/orbit-backend/internal/service"
+)
+
+// maxSubscriptions caps how many topics a single connection may follow
+const maxSubscriptions = 50
+
+var (
+	ErrInvalidTopic      = errors.New("topic must be post:<id>, lesson:<id> or category:<id>")
+	ErrTopicForbidden    = errors.New("not allowed to subscribe to topic")
+	ErrTooManyTopics     = errors.New("too many topic subscriptions")
+	ErrClientUnavailable = errors.New("connection is closing")
+)
+
+// TopicKind is the resource type a topic refers to
+type TopicKind string
+
+const (
+	TopicPost     TopicKind = "post"
+	TopicLesson   TopicKind = "lesson"
+	TopicCategory TopicKind = "category"
+)
+
+// Topic is a fine-grained channel within a tenant, e.g. "post:<id>"
+type Topic struct {
+	Kind TopicKind
+	ID   uuid.UUID
+}
+
+// String returns the wire form of the topic
+func (t Topic) String() string {
+	return string(t.Kind) + ":" + t.ID.String()
+}
+
+// ParseTopic validates a topic name sent by a client
+func ParseTopic(name string) (Topic, error) {
+	kind, id, ok := strings.Cut(name, ":")
+	if !ok {
+		return Topic{}, ErrInvalidTopic
+	}
+
+	switch TopicKind(kind) {
+	case TopicPost, TopicLesson, TopicCategory:
+	default:
+		return Topic{}, ErrInvalidTopic
+	}
+
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return Topic{}, ErrInvalidTopic
+	}
+	return Topic{Kind: TopicKind(kind), ID: parsed}, nil
+}
+
+// PostTopic returns the topic for a post's comments, likes and edits
+func PostTopic(postID uuid.UUID) Topic {
+	return Topic{Kind: TopicPost, ID: postID}
+}
+
+// LessonTopic returns the topic for activity on a lesson
+func LessonTopic(lessonID uuid.UUID) Topic {
+	return Topic{Kind: TopicLesson, ID: lessonID}
+}
+
+// CategoryTopic returns the topic for activity within a category
+func CategoryTopic(categoryID uuid.UUID) Topic {
+	return Topic{Kind: TopicCategory, ID: categoryID}
+}
+
+// TopicAuthorizer decides whether a user may subscribe to a topic, applying
+// the same permission rules as the matching REST endpoints
+type TopicAuthorizer struct {
+	permissionService *service.PermissionService
+	postService       *service.PostService
+	categoryService   *service.CategoryService
+	courseService     *service.CourseService
+	enrollmentService *service.EnrollmentService
+}
+
+// NewTopicAuthorizer creates a new topic authorizer
+func NewTopicAuthorizer(
+	permissionService *service.PermissionService,
+	postService *service.PostService,
+	categoryService *service.CategoryService,
+	courseService *service.CourseService,
+	enrollmentService *service.EnrollmentService,
+) *TopicAuthorizer {
+	return &TopicAuthorizer{
+		permissionService: permissionService,
+		postService:       postService,
+		categoryService:   categoryService,
+		courseService:     courseService,
+		enrollmentService: enrollmentService,
+	}
+}
+
+// CanSubscribe checks that the topic belongs to the tenant and that the user
+// is allowed to see it
+func (a *TopicAuthorizer) CanSubscribe(ctx context.Context, tenantID, userID uuid.UUID, topic Topic) (bool, error) {
+	switch topic.Kind {
+	case TopicPost:
+		post, err := a.postService.GetByID(ctx, topic.ID)
+		if err != nil || post.TenantID != tenantID {
+			return false, nil
+		}
+		if post.Status != "published" && post.AuthorID != userID {
+			// Drafts are only visible to their author and editors
+			return a.permissionService.HasPermission(ctx, tenantID, userID, "posts.edit")
+		}
+		return a.permissionService.HasPermission(ctx, tenantID, userID, "posts.view")
+
+	case TopicCategory:
+		category, err := a.categoryService.GetByID(ctx, topic.ID)
+		if err != nil || category.TenantID != tenantID {
+			return false, nil
+		}
+		return a.permissionService.HasPermission(ctx, tenantID, userID, "posts.view")
+
+	case TopicLesson:
+		lesson, err := a.courseService.GetLessonByID(ctx, topic.ID)
+		if err != nil || lesson.TenantID != tenantID {
+			return false, nil
+		}
+		allowed, err := a.permissionService.HasPermission(ctx, tenantID, userID, "enrollments.view")
+		if err != nil || !allowed {
+			return false, err
+		}
+		module, err := a.courseService.GetModuleByID(ctx, lesson.ModuleID)
+		if err != nil {
+			return false, nil
+		}
+		return a.enrollmentService.CanAccessLesson(ctx, userID, module.CourseID, lesson.ID)
+
+	default:
+		return false, fmt.Errorf("unknown topic kind %q", topic.Kind)
+	}
+}
+
+// invalidate drops cached permissions so the next checks see a role change
+func (a *TopicAuthorizer) invalidate(ctx context.Context, tenantID, userID uuid.UUID) {
+	if err := a.permissionService.InvalidateUserPermissions(ctx, tenantID, userID); err != nil {
+		log.Printf("[WS] Failed to invalidate permissions for user=%s: %v", userID, err)
+	}
+}
@@ -0,0 +1,74 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nickkcj/orbit-backend/internal/events"
+)
+
+func TestParseTopic(t *testing.T) {
+	id := uuid.New()
+	for _, name := range []string{"post:" + id.String(), "lesson:" + id.String(), "category:" + id.String()} {
+		topic, err := ParseTopic(name)
+		if err != nil {
+			t.Fatalf("ParseTopic(%q): %v", name, err)
+		}
+		if topic.String() != name {
+			t.Fatalf("expected %q, got %q", name, topic.String())
+		}
+	}
+
+	for _, name := range []string{"", "post", "post:nope", "course:" + id.String()} {
+		if _, err := ParseTopic(name); err == nil {
+			t.Fatalf("expected ParseTopic(%q) to fail", name)
+		}
+	}
+}
+
+func TestEventsRouteToTopicSubscribers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hub := NewHub()
+	go hub.Run(ctx)
+
+	tenantID, postID := uuid.New(), uuid.New()
+	subscriber := newTestClient(hub, tenantID, uuid.New())
+	bystander := newTestClient(hub, tenantID, uuid.New())
+	hub.Register(subscriber)
+	hub.Register(bystander)
+	time.Sleep(20 * time.Millisecond)
+
+	if err := hub.subscribe(subscriber, PostTopic(postID)); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	// Comments only reach clients following the post
+	hub.HandleEvent(ctx, events.Event{
+		Type:     events.CommentCreated,
+		TenantID: tenantID,
+		Payload:  events.CommentPayload{ID: uuid.New(), PostID: postID},
+	})
+	expectMessage(t, subscriber, MessageTypeCommentCreated)
+	expectNoMessage(t, bystander)
+
+	// New posts stay tenant-wide
+	hub.HandleEvent(ctx, events.Event{
+		Type:     events.PostCreated,
+		TenantID: tenantID,
+		Payload:  events.PostPayload{ID: uuid.New()},
+	})
+	expectMessage(t, subscriber, MessageTypePostCreated)
+	expectMessage(t, bystander, MessageTypePostCreated)
+
+	hub.unsubscribe(subscriber, PostTopic(postID))
+	hub.HandleEvent(ctx, events.Event{
+		Type:     events.LikeUpdated,
+		TenantID: tenantID,
+		Payload:  events.LikePayload{TargetType: "post", TargetID: postID, PostID: postID},
+	})
+	expectNoMessage(t, subscriber)
+}
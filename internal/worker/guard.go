@@ -0,0 +1,34 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+)
+
+// runOnce lets a task type run at most once per window across all workers.
+// The lock is released when the run fails so asynq retries can go through.
+func runOnce(rdb redis.UniversalClient, window time.Duration, next asynq.HandlerFunc) asynq.HandlerFunc {
+	return func(ctx context.Context, task *asynq.Task) error {
+		key := "scheduler:lock:" + task.Type()
+
+		acquired, err := rdb.SetNX(ctx, key, time.Now().Unix(), window).Result()
+		if err != nil {
+			return fmt.Errorf("failed to acquire run lock for %s: %w", task.Type(), err)
+		}
+		if !acquired {
+			log.Printf("[SCHEDULER] Skipping %s, it already ran within %s", task.Type(), window)
+			return nil
+		}
+
+		if err := next(ctx, task); err != nil {
+			rdb.Del(context.Background(), key)
+			return err
+		}
+		return nil
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/nickkcj/orbit-backend/internal/outbox"
+	"github.com/nickkcj/orbit-backend/internal/service"
+	"github.com/nickkcj/orbit-backend/internal/worker/tasks"
+)
+
+// DigestHandler finds the digests that are due and sends them
+type DigestHandler struct {
+	digestSvc *service.DigestService
+	enqueuer  outbox.Enqueuer
+}
+
+// NewDigestHandler creates a new digest handler
+func NewDigestHandler(digestSvc *service.DigestService, enqueuer outbox.Enqueuer) *DigestHandler {
+	return &DigestHandler{digestSvc: digestSvc, enqueuer: enqueuer}
+}
+
+// Schedule enqueues one task per member whose digest period came up
+func (h *DigestHandler) Schedule(ctx context.Context, task *asynq.Task) error {
+	due, err := h.digestSvc.Due(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to list due digests: %w", err)
+	}
+
+	enqueued := 0
+	for _, digest := range due {
+		digestTask, err := tasks.NewSendDigestTask(tasks.DigestPayload{
+			TenantID: digest.TenantID,
+			UserID:   digest.UserID,
+			Period:   digest.Period,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := h.enqueuer.Enqueue(digestTask); err != nil {
+			if errors.Is(err, asynq.ErrTaskIDConflict) {
+				continue
+			}
+			return fmt.Errorf("failed to enqueue digest: %w", err)
+		}
+		enqueued++
+	}
+
+	log.Printf("[DIGEST] Enqueued %d of %d due digests", enqueued, len(due))
+	return nil
+}
+
+// Send compiles and queues one member's digest email
+func (h *DigestHandler) Send(ctx context.Context, task *asynq.Task) error {
+	var payload tasks.DigestPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal digest payload: %v: %w", err, asynq.SkipRetry)
+	}
+
+	if err := h.digestSvc.Send(ctx, payload.TenantID, payload.UserID, payload.Period); err != nil {
+		return fmt.Errorf("failed to send digest %s to %s: %w", payload.Period, payload.UserID, err)
+	}
+	return nil
+}
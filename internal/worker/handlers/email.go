@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	"github.com/nickkcj/orbit-backend/internal/email"
+	"github.com/nickkcj/orbit-backend/internal/service"
+	"github.com/nickkcj/orbit-backend/internal/worker/tasks"
+)
+
+// EmailHandler processes email tasks
+type EmailHandler struct {
+	emailSvc *service.EmailService
+}
+
+// NewEmailHandler creates a new email handler
+func NewEmailHandler(svc *service.EmailService) *EmailHandler {
+	return &EmailHandler{emailSvc: svc}
+}
+
+// Handle renders and sends an email. Transient delivery errors are retried;
+// bad payloads, unknown templates and rejected recipients are not.
+func (h *EmailHandler) Handle(ctx context.Context, task *asynq.Task) error {
+	var payload tasks.EmailPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal email payload: %v: %w", err, asynq.SkipRetry)
+	}
+
+	if h.emailSvc == nil {
+		return fmt.Errorf("email service not configured")
+	}
+	if payload.To == "" {
+		return fmt.Errorf("missing recipient for %s email: %w", payload.Template, asynq.SkipRetry)
+	}
+
+	err := h.emailSvc.Send(ctx, service.SendEmailInput{
+		TenantID: payload.TenantID,
+		To:       payload.To,
+		ToName:   payload.ToName,
+		Template: payload.Template,
+		Data:     payload.Data,
+		Headers:  payload.Headers,
+	})
+	if err != nil {
+		if errors.Is(err, email.ErrUnknownTemplate) || errors.Is(err, email.ErrRenderTemplate) || email.IsPermanent(err) {
+			return fmt.Errorf("failed to send %s email: %v: %w", payload.Template, err, asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to send %s email: %w", payload.Template, err)
+	}
+	return nil
+}
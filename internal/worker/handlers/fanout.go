@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/nickkcj/orbit-backend/internal/outbox"
+	"github.com/nickkcj/orbit-backend/internal/service"
+	"github.com/nickkcj/orbit-backend/internal/worker/tasks"
+)
+
+// newPostBatchSize is how many followers one fan-out task notifies
+const newPostBatchSize = 500
+
+// FanOutHandler turns a published post into new_post notifications for the
+// followers of its category and author, one batch per task
+type FanOutHandler struct {
+	postSvc   *service.PostService
+	followSvc *service.FollowService
+	enqueuer  outbox.Enqueuer
+}
+
+// NewFanOutHandler creates a new fan-out handler
+func NewFanOutHandler(postSvc *service.PostService, followSvc *service.FollowService, enqueuer outbox.Enqueuer) *FanOutHandler {
+	return &FanOutHandler{postSvc: postSvc, followSvc: followSvc, enqueuer: enqueuer}
+}
+
+// Handle enqueues one notification per follower in the batch, then the next
+// batch when this one was full. Task IDs keep a retried batch from notifying
+// anyone twice.
+func (h *FanOutHandler) Handle(ctx context.Context, task *asynq.Task) error {
+	var payload tasks.NewPostFanOutPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal fan-out payload: %v: %w", err, asynq.SkipRetry)
+	}
+
+	post, err := h.postSvc.GetByID(ctx, payload.PostID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("failed to load post %s: %w", payload.PostID, err)
+	}
+	// Unpublished or deleted since: stop the fan-out
+	if post.Status != "published" {
+		return nil
+	}
+
+	page, err := h.followSvc.NewPostRecipients(ctx, post, payload.After, newPostBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to list followers of post %s: %w", post.ID, err)
+	}
+
+	recipients, next := newPostBatch(page, post.AuthorID, newPostBatchSize)
+	for _, recipientID := range recipients {
+		notification, err := tasks.NewSendNotificationTask(tasks.NotificationPayload{
+			Type:        string(service.NotificationTypeNewPost),
+			TenantID:    post.TenantID,
+			RecipientID: recipientID,
+			PostID:      &post.ID,
+			PostTitle:   post.Title,
+			AuthorID:    &post.AuthorID,
+			AuthorName:  payload.AuthorName,
+		})
+		if err != nil {
+			return err
+		}
+		taskID := asynq.TaskID("new_post:" + post.ID.String() + ":" + recipientID.String())
+		if err := h.enqueue(notification, taskID); err != nil {
+			return fmt.Errorf("failed to enqueue new_post notification: %w", err)
+		}
+	}
+
+	if next != nil {
+		payload.After = *next
+		nextTask, err := tasks.NewFanOutNewPostTask(payload)
+		if err != nil {
+			return err
+		}
+		if err := h.enqueue(nextTask); err != nil {
+			return fmt.Errorf("failed to enqueue next fan-out batch: %w", err)
+		}
+	}
+
+	log.Printf("[FANOUT] Post %s: notified %d followers", post.ID, len(recipients))
+	return nil
+}
+
+// newPostBatch splits one page of followers into the members to notify and
+// the cursor of the next page, nil when the page was the last. The cursor is
+// the last ID listed even if that member is skipped, and the author is never
+// notified of their own post.
+func newPostBatch(page []uuid.UUID, authorID uuid.UUID, batchSize int) ([]uuid.UUID, *uuid.UUID) {
+	recipients := make([]uuid.UUID, 0, len(page))
+	for _, userID := range page {
+		if userID != authorID {
+			recipients = append(recipients, userID)
+		}
+	}
+
+	if len(page) < batchSize {
+		return recipients, nil
+	}
+	next := page[len(page)-1]
+	return recipients, &next
+}
+
+// enqueue treats a task ID that is already queued as done
+func (h *FanOutHandler) enqueue(task *asynq.Task, opts ...asynq.Option) error {
+	_, err := h.enqueuer.Enqueue(task, opts...)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		return nil
+	}
+	return err
+}
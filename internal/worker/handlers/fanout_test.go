@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNewPostBatch(t *testing.T) {
+	author := uuid.New()
+	ids := make([]uuid.UUID, 4)
+	for i := range ids {
+		ids[i] = uuid.New()
+	}
+
+	cases := []struct {
+		name           string
+		page           []uuid.UUID
+		wantRecipients []uuid.UUID
+		wantNext       *uuid.UUID
+	}{
+		{"empty page", nil, []uuid.UUID{}, nil},
+		{"last page", ids[:2], ids[:2], nil},
+		{"full page continues", ids[:3], ids[:3], &ids[2]},
+		{"author skipped", []uuid.UUID{ids[0], author}, []uuid.UUID{ids[0]}, nil},
+		{"cursor past a skipped author", []uuid.UUID{ids[0], ids[1], author}, ids[:2], &author},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			recipients, next := newPostBatch(tc.page, author, 3)
+			if !reflect.DeepEqual(recipients, tc.wantRecipients) {
+				t.Fatalf("recipients %v, want %v", recipients, tc.wantRecipients)
+			}
+			if !reflect.DeepEqual(next, tc.wantNext) {
+				t.Fatalf("next %v, want %v", next, tc.wantNext)
+			}
+		})
+	}
+}
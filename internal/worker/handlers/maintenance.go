@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/nickkcj/orbit-backend/internal/service"
+	"github.com/nickkcj/orbit-backend/internal/worker/tasks"
+)
+
+// MaintenanceHandler processes the recurring maintenance tasks
+type MaintenanceHandler struct {
+	notificationSvc *service.NotificationService
+	webhookSvc      *service.WebhookService
+	videoSvc        *service.VideoService
+	analyticsSvc    *service.AnalyticsService
+}
+
+// NewMaintenanceHandler creates a new maintenance handler
+func NewMaintenanceHandler(services *service.Services) *MaintenanceHandler {
+	return &MaintenanceHandler{
+		notificationSvc: services.Notification,
+		webhookSvc:      services.Webhook,
+		videoSvc:        services.Video,
+		analyticsSvc:    services.Analytics,
+	}
+}
+
+// PurgeNotifications deletes read notifications past retention
+func (h *MaintenanceHandler) PurgeNotifications(ctx context.Context, task *asynq.Task) error {
+	payload, err := maintenancePayload(task)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := h.notificationSvc.PurgeRead(ctx, time.Now().Add(-payload.OlderThan))
+	if err != nil {
+		return fmt.Errorf("failed to purge notifications: %w", err)
+	}
+	log.Printf("[MAINTENANCE] Purged %d read notifications older than %s", deleted, payload.OlderThan)
+	return nil
+}
+
+// PruneWebhooks deletes webhook events past retention
+func (h *MaintenanceHandler) PruneWebhooks(ctx context.Context, task *asynq.Task) error {
+	payload, err := maintenancePayload(task)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := h.webhookSvc.Prune(ctx, time.Now().Add(-payload.OlderThan))
+	if err != nil {
+		return fmt.Errorf("failed to prune webhook events: %w", err)
+	}
+	log.Printf("[MAINTENANCE] Pruned %d webhook events older than %s", deleted, payload.OlderThan)
+	return nil
+}
+
+// ExpireVideos fails uploads that stayed pending or uploading for too long
+func (h *MaintenanceHandler) ExpireVideos(ctx context.Context, task *asynq.Task) error {
+	payload, err := maintenancePayload(task)
+	if err != nil {
+		return err
+	}
+
+	expired, err := h.videoSvc.ExpireStale(ctx, time.Now().Add(-payload.OlderThan))
+	if err != nil {
+		return fmt.Errorf("failed to expire stale videos: %w", err)
+	}
+	log.Printf("[MAINTENANCE] Expired %d uploads idle for more than %s", expired, payload.OlderThan)
+	return nil
+}
+
+// ReconcileVideos polls the provider for uploads whose webhook never arrived
+func (h *MaintenanceHandler) ReconcileVideos(ctx context.Context, task *asynq.Task) error {
+	payload, err := maintenancePayload(task)
+	if err != nil {
+		return err
+	}
+
+	settled, err := h.videoSvc.ReconcileStuck(ctx, time.Now().Add(-payload.OlderThan))
+	if err != nil {
+		return fmt.Errorf("failed to reconcile stuck videos: %w", err)
+	}
+	log.Printf("[MAINTENANCE] Settled %d videos stuck for more than %s", settled, payload.OlderThan)
+	return nil
+}
+
+// RollupAnalytics recomputes the daily tenant stats
+func (h *MaintenanceHandler) RollupAnalytics(ctx context.Context, task *asynq.Task) error {
+	payload, err := maintenancePayload(task)
+	if err != nil {
+		return err
+	}
+
+	rows, err := h.analyticsSvc.RollupDaily(ctx, payload.Days)
+	if err != nil {
+		return fmt.Errorf("failed to roll up analytics: %w", err)
+	}
+	log.Printf("[MAINTENANCE] Recomputed %d daily stats rows over %d days", rows, payload.Days)
+	return nil
+}
+
+func maintenancePayload(task *asynq.Task) (tasks.MaintenancePayload, error) {
+	var payload tasks.MaintenancePayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return payload, fmt.Errorf("failed to unmarshal maintenance payload: %v: %w", err, asynq.SkipRetry)
+	}
+	return payload, nil
+}
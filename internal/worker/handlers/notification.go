@@ -3,9 +3,14 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 
+	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
+	"github.com/nickkcj/orbit-backend/internal/database"
+	"github.com/nickkcj/orbit-backend/internal/outbox"
 	"github.com/nickkcj/orbit-backend/internal/service"
 	"github.com/nickkcj/orbit-backend/internal/worker/tasks"
 )
@@ -13,58 +18,224 @@ import (
 // NotificationHandler processes notification tasks
 type NotificationHandler struct {
 	notificationSvc *service.NotificationService
+	enqueuer        outbox.Enqueuer
+	push            bool
 }
 
-// NewNotificationHandler creates a new notification handler
-func NewNotificationHandler(svc *service.NotificationService) *NotificationHandler {
-	return &NotificationHandler{notificationSvc: svc}
+// NewNotificationHandler creates a new notification handler; emails and,
+// when push is configured, pushes are enqueued through enqueuer
+func NewNotificationHandler(svc *service.NotificationService, enqueuer outbox.Enqueuer, push bool) *NotificationHandler {
+	return &NotificationHandler{notificationSvc: svc, enqueuer: enqueuer, push: push}
 }
 
-// Handle processes a notification task
+// Handle delivers a notification on the channels the recipient kept on for
+// its type. The email is enqueued first under an ID derived from this task,
+// so a retry after a failed in-app insert does not send it twice.
 func (h *NotificationHandler) Handle(ctx context.Context, task *asynq.Task) error {
 	var payload tasks.NotificationPayload
 	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
 		return fmt.Errorf("failed to unmarshal notification payload: %w", err)
 	}
 
+	input, err := notificationInput(payload)
+	if err != nil {
+		return err
+	}
+
+	pref, err := h.notificationSvc.Preference(ctx, payload.TenantID, payload.RecipientID, input.Type)
+	if err != nil {
+		return fmt.Errorf("failed to load notification preference: %w", err)
+	}
+	if pref.Off() {
+		return nil
+	}
+
+	if pref.Email {
+		if err := h.enqueueEmail(ctx, input); err != nil {
+			return err
+		}
+	}
+
+	// Push without in-app: nothing is stored, and the push is keyed by this
+	// task so a retry after a failed enqueue does not send it twice
+	if !pref.InApp {
+		if pref.Push && h.push {
+			return h.enqueuePush(standalonePush(ctx, input))
+		}
+		return nil
+	}
+
+	notification, changed, err := h.notificationSvc.Create(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	// Devices get a push for every in-app notification they opted into, and
+	// again when a grouped one gains an actor. A failed enqueue is only
+	// logged: retrying would duplicate the notification.
+	if changed && pref.Push && h.push {
+		if err := h.enqueuePush(notificationPush(notification)); err != nil {
+			log.Printf("[PUSH] Notification %s: %v", notification.ID, err)
+		}
+	}
+	return nil
+}
+
+// notificationPush is the push for a stored notification
+func notificationPush(notification database.Notification) tasks.PushPayload {
+	return tasks.PushPayload{
+		TenantID:       notification.TenantID,
+		UserID:         notification.UserID,
+		NotificationID: notification.ID,
+		Type:           notification.Type,
+		Title:          notification.Title,
+		Message:        notification.Message.String,
+		Data:           notification.Data.RawMessage,
+		ActorCount:     int(notification.ActorCount),
+	}
+}
+
+// standalonePush is the push for a notification that is not stored. Its ID
+// comes from the task ID, so retries of the task map to the same push.
+func standalonePush(ctx context.Context, input service.CreateNotificationInput) tasks.PushPayload {
+	id := uuid.New()
+	if taskID, ok := asynq.GetTaskID(ctx); ok {
+		id = uuid.NewSHA1(uuid.NameSpaceOID, []byte("push:"+taskID))
+	}
+	if input.Group != nil {
+		input.Data.Actors = []service.NotificationActor{input.Group.Actor}
+	}
+	data, err := json.Marshal(input.Data)
+	if err != nil {
+		data = []byte("{}")
+	}
+
+	return tasks.PushPayload{
+		TenantID:       input.TenantID,
+		UserID:         input.UserID,
+		NotificationID: id,
+		Type:           string(input.Type),
+		Title:          input.Title,
+		Message:        input.Message,
+		Data:           data,
+	}
+}
+
+func (h *NotificationHandler) enqueuePush(payload tasks.PushPayload) error {
+	pushTask, err := tasks.NewSendPushTask(payload)
+	if err != nil {
+		return err
+	}
+	if _, err := h.enqueuer.Enqueue(pushTask); err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		return fmt.Errorf("failed to enqueue push: %w", err)
+	}
+	return nil
+}
+
+func (h *NotificationHandler) enqueueEmail(ctx context.Context, input service.CreateNotificationInput) error {
+	payload, err := h.notificationSvc.NotificationEmail(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to build notification email: %w", err)
+	}
+	emailTask, err := tasks.NewSendEmailTask(payload)
+	if err != nil {
+		return err
+	}
+
+	var opts []asynq.Option
+	if taskID, ok := asynq.GetTaskID(ctx); ok {
+		opts = append(opts, asynq.TaskID("email:"+taskID))
+	}
+	if _, err := h.enqueuer.Enqueue(emailTask, opts...); err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		return fmt.Errorf("failed to enqueue notification email: %w", err)
+	}
+	return nil
+}
+
+// notificationInput builds the notification a task payload describes
+func notificationInput(payload tasks.NotificationPayload) (service.CreateNotificationInput, error) {
 	switch payload.Type {
 	case "comment":
 		if payload.PostID == nil || payload.CommentID == nil {
-			return fmt.Errorf("missing post_id or comment_id for comment notification")
+			return service.CreateNotificationInput{}, fmt.Errorf("missing post_id or comment_id for comment notification")
 		}
-		return h.notificationSvc.NotifyComment(
-			ctx,
+		// Tasks queued before comments carried the author all count as
+		// one unknown actor of the group
+		commenterID := uuid.Nil
+		if payload.AuthorID != nil {
+			commenterID = *payload.AuthorID
+		}
+		return service.CommentNotification(
 			payload.TenantID,
 			payload.RecipientID,
+			commenterID,
 			payload.AuthorName,
 			payload.PostTitle,
 			*payload.PostID,
 			*payload.CommentID,
-		)
+		), nil
 
 	case "reply":
 		if payload.PostID == nil || payload.CommentID == nil {
-			return fmt.Errorf("missing post_id or comment_id for reply notification")
+			return service.CreateNotificationInput{}, fmt.Errorf("missing post_id or comment_id for reply notification")
 		}
-		return h.notificationSvc.NotifyReply(
-			ctx,
+		return service.ReplyNotification(
 			payload.TenantID,
 			payload.RecipientID,
 			payload.AuthorName,
 			payload.PostTitle,
 			*payload.PostID,
 			*payload.CommentID,
-		)
+		), nil
+
+	case "mention":
+		if payload.PostID == nil {
+			return service.CreateNotificationInput{}, fmt.Errorf("missing post_id for mention notification")
+		}
+		return service.MentionNotification(
+			payload.TenantID,
+			payload.RecipientID,
+			payload.AuthorName,
+			payload.PostTitle,
+			*payload.PostID,
+			payload.CommentID,
+		), nil
+
+	case "new_post":
+		if payload.PostID == nil || payload.AuthorID == nil {
+			return service.CreateNotificationInput{}, fmt.Errorf("missing post_id or author_id for new_post notification")
+		}
+		return service.NewPostNotification(
+			payload.TenantID,
+			payload.RecipientID,
+			payload.AuthorName,
+			payload.PostTitle,
+			*payload.PostID,
+			*payload.AuthorID,
+		), nil
+
+	case "like":
+		if payload.PostID == nil || payload.AuthorID == nil {
+			return service.CreateNotificationInput{}, fmt.Errorf("missing post_id or author_id for like notification")
+		}
+		return service.LikeNotification(
+			payload.TenantID,
+			payload.RecipientID,
+			*payload.AuthorID,
+			payload.AuthorName,
+			payload.PostTitle,
+			*payload.PostID,
+			payload.CommentID,
+		), nil
 
 	case "welcome":
-		return h.notificationSvc.NotifyWelcome(
-			ctx,
+		return service.WelcomeNotification(
 			payload.TenantID,
 			payload.RecipientID,
 			payload.CommunityName,
-		)
+		), nil
 
 	default:
-		return fmt.Errorf("unknown notification type: %s", payload.Type)
+		return service.CreateNotificationInput{}, fmt.Errorf("unknown notification type: %s", payload.Type)
 	}
 }
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/nickkcj/orbit-backend/internal/push"
+	"github.com/nickkcj/orbit-backend/internal/service"
+	"github.com/nickkcj/orbit-backend/internal/worker/tasks"
+)
+
+// pushTTL is how long push services hold a notification for an offline device
+const pushTTL = 24 * time.Hour
+
+// PushHandler delivers notifications to the recipient's devices
+type PushHandler struct {
+	pushSvc *service.PushService
+}
+
+// NewPushHandler creates a new push handler
+func NewPushHandler(pushSvc *service.PushService) *PushHandler {
+	return &PushHandler{pushSvc: pushSvc}
+}
+
+// Handle sends the notification to every subscribed device. Retries resend
+// to all of them, but the topic makes the push service replace a copy the
+// device has not received yet.
+func (h *PushHandler) Handle(ctx context.Context, task *asynq.Task) error {
+	var payload tasks.PushPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal push payload: %v: %w", err, asynq.SkipRetry)
+	}
+
+	if h.pushSvc == nil {
+		return fmt.Errorf("push service not configured: %w", asynq.SkipRetry)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"id":        payload.NotificationID,
+		"tenant_id": payload.TenantID,
+		"type":      payload.Type,
+		"title":     payload.Title,
+		"body":      payload.Message,
+		"data":      payload.Data,
+		"count":     payload.ActorCount,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal push message: %v: %w", err, asynq.SkipRetry)
+	}
+
+	_, err = h.pushSvc.SendToUser(ctx, payload.UserID, push.Message{
+		Payload: body,
+		TTL:     pushTTL,
+		Topic:   strings.ReplaceAll(payload.NotificationID.String(), "-", ""),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to push notification %s: %w", payload.NotificationID, err)
+	}
+	return nil
+}
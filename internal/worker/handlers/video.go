@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/hibiken/asynq"
+	"github.com/nickkcj/orbit-backend/internal/service"
+	"github.com/nickkcj/orbit-backend/internal/worker/tasks"
+)
+
+// VideoHandler reconciles uploaded videos with the stream provider
+type VideoHandler struct {
+	videoSvc *service.VideoService
+}
+
+// NewVideoHandler creates a new video handler
+func NewVideoHandler(videoSvc *service.VideoService) *VideoHandler {
+	return &VideoHandler{videoSvc: videoSvc}
+}
+
+// Handle polls the provider once. While the video is still processing the
+// error is returned so asynq polls again after tasks.VideoPollDelay.
+func (h *VideoHandler) Handle(ctx context.Context, task *asynq.Task) error {
+	var payload tasks.VideoPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal video payload: %v: %w", err, asynq.SkipRetry)
+	}
+
+	err := h.videoSvc.Reconcile(ctx, payload.VideoID)
+	switch {
+	case err == nil:
+		log.Printf("[VIDEO] Video %s reconciled", payload.VideoID)
+		return nil
+	case errors.Is(err, service.ErrVideoProcessing):
+		return err
+	case errors.Is(err, service.ErrVideoNotUploaded):
+		return fmt.Errorf("video %s: %v: %w", payload.VideoID, err, asynq.SkipRetry)
+	default:
+		return fmt.Errorf("failed to reconcile video %s: %w", payload.VideoID, err)
+	}
+}
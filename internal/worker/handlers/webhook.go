@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/hibiken/asynq"
+	"github.com/nickkcj/orbit-backend/internal/service"
+	"github.com/nickkcj/orbit-backend/internal/worker/tasks"
+)
+
+// WebhookHandler processes provider webhooks logged by the HTTP layer
+type WebhookHandler struct {
+	webhookSvc *service.WebhookService
+	videoSvc   *service.VideoService
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(webhookSvc *service.WebhookService, videoSvc *service.VideoService) *WebhookHandler {
+	return &WebhookHandler{webhookSvc: webhookSvc, videoSvc: videoSvc}
+}
+
+// Handle dispatches a webhook to its provider and records the outcome on the
+// webhook event. Errors are returned so asynq retries with backoff.
+func (h *WebhookHandler) Handle(ctx context.Context, task *asynq.Task) error {
+	var payload tasks.WebhookPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal webhook payload: %v: %w", err, asynq.SkipRetry)
+	}
+
+	if err := h.dispatch(ctx, payload); err != nil {
+		if markErr := h.webhookSvc.MarkFailed(ctx, payload.EventID, err.Error()); markErr != nil {
+			log.Printf("[WEBHOOK] Failed to mark event %s as failed: %v", payload.EventID, markErr)
+		}
+		return err
+	}
+
+	if err := h.webhookSvc.MarkProcessed(ctx, payload.EventID); err != nil {
+		return fmt.Errorf("failed to mark webhook %s as processed: %w", payload.EventID, err)
+	}
+	return nil
+}
+
+func (h *WebhookHandler) dispatch(ctx context.Context, payload tasks.WebhookPayload) error {
+	switch payload.Provider {
+	case tasks.ProviderCloudflareStream:
+		var event service.StreamWebhookPayload
+		if err := json.Unmarshal(payload.RawPayload, &event); err != nil {
+			return fmt.Errorf("invalid stream webhook payload: %v: %w", err, asynq.SkipRetry)
+		}
+		// A missing video is retried too: the webhook can arrive before the
+		// client confirms its upload
+		return h.videoSvc.ProcessWebhook(ctx, &event)
+
+	case tasks.ProviderR2:
+		// R2 serves objects as uploaded, so there is nothing to transcode or clean up
+		return nil
+
+	default:
+		return fmt.Errorf("unknown webhook provider %q: %w", payload.Provider, asynq.SkipRetry)
+	}
+}
@@ -0,0 +1,270 @@
+package worker
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+
+	"github.com/nickkcj/orbit-backend/internal/worker/tasks"
+)
+
+// maxScanPages bounds how many pages a filtered listing reads per queue and state
+const maxScanPages = 50
+
+var (
+	ErrUnknownTaskState = errors.New("unknown task state")
+	ErrTaskNotArchived  = errors.New("task is not archived")
+)
+
+// listFuncs are the task states the inspector exposes, in listing order
+var listFuncs = []struct {
+	state string
+	list  func(*asynq.Inspector, string, ...asynq.ListOption) ([]*asynq.TaskInfo, error)
+}{
+	{"pending", (*asynq.Inspector).ListPendingTasks},
+	{"retry", (*asynq.Inspector).ListRetryTasks},
+	{"archived", (*asynq.Inspector).ListArchivedTasks},
+}
+
+// Inspector exposes queue state and dead-lettered (archived) tasks to operators
+type Inspector struct {
+	inspector *asynq.Inspector
+}
+
+// NewInspector creates an inspector on the worker's Redis
+func NewInspector(redisOpt asynq.RedisClientOpt) *Inspector {
+	return &Inspector{inspector: asynq.NewInspector(redisOpt)}
+}
+
+// QueueStats is a snapshot of one queue
+type QueueStats struct {
+	Queue          string `json:"queue"`
+	Size           int    `json:"size"`
+	Pending        int    `json:"pending"`
+	Active         int    `json:"active"`
+	Scheduled      int    `json:"scheduled"`
+	Retry          int    `json:"retry"`
+	Archived       int    `json:"archived"`
+	Completed      int    `json:"completed"`
+	ProcessedToday int    `json:"processed_today"`
+	FailedToday    int    `json:"failed_today"`
+	LatencyMs      int64  `json:"latency_ms"`
+	Paused         bool   `json:"paused"`
+}
+
+// TaskSummary describes a queued task with the tenant found in its payload
+type TaskSummary struct {
+	ID            string          `json:"id"`
+	Queue         string          `json:"queue"`
+	Type          string          `json:"type"`
+	State         string          `json:"state"`
+	TenantID      *uuid.UUID      `json:"tenant_id,omitempty"`
+	Payload       json.RawMessage `json:"payload"`
+	Retried       int             `json:"retried"`
+	MaxRetry      int             `json:"max_retry"`
+	LastError     string          `json:"last_error,omitempty"`
+	LastFailedAt  *time.Time      `json:"last_failed_at,omitempty"`
+	NextProcessAt *time.Time      `json:"next_process_at,omitempty"`
+}
+
+// TaskFilter narrows a task listing; empty fields match everything
+type TaskFilter struct {
+	Queue    string
+	State    string // pending, retry or archived
+	Type     string
+	TenantID *uuid.UUID
+}
+
+// Queues returns the stats of every known queue, sorted by name
+func (i *Inspector) Queues() ([]QueueStats, error) {
+	names, err := i.inspector.Queues()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	stats := make([]QueueStats, 0, len(names))
+	for _, name := range names {
+		info, err := i.inspector.GetQueueInfo(name)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, QueueStats{
+			Queue:          info.Queue,
+			Size:           info.Size,
+			Pending:        info.Pending,
+			Active:         info.Active,
+			Scheduled:      info.Scheduled,
+			Retry:          info.Retry,
+			Archived:       info.Archived,
+			Completed:      info.Completed,
+			ProcessedToday: info.Processed,
+			FailedToday:    info.Failed,
+			LatencyMs:      info.Latency.Milliseconds(),
+			Paused:         info.Paused,
+		})
+	}
+	return stats, nil
+}
+
+// ListTasks returns one page of tasks matching the filter. Without a type or
+// tenant filter the page comes straight from Redis; otherwise tasks are
+// scanned and filtered here, so very deep queues may be partially covered.
+func (i *Inspector) ListTasks(filter TaskFilter, page, pageSize int) ([]TaskSummary, error) {
+	queues := []string{filter.Queue}
+	if filter.Queue == "" {
+		names, err := i.inspector.Queues()
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(names)
+		queues = names
+	}
+
+	states := listFuncs
+	if filter.State != "" {
+		states = nil
+		for _, lf := range listFuncs {
+			if lf.state == filter.State {
+				states = append(states, lf)
+			}
+		}
+		if len(states) == 0 {
+			return nil, ErrUnknownTaskState
+		}
+	}
+
+	// A single queue and state without filters pages natively
+	if len(queues) == 1 && len(states) == 1 && filter.Type == "" && filter.TenantID == nil {
+		infos, err := states[0].list(i.inspector, queues[0], asynq.Page(page), asynq.PageSize(pageSize))
+		if err != nil {
+			return nil, err
+		}
+		summaries := make([]TaskSummary, len(infos))
+		for n, info := range infos {
+			summaries[n] = summarize(info)
+		}
+		return summaries, nil
+	}
+
+	skip := (page - 1) * pageSize
+	summaries := make([]TaskSummary, 0, pageSize)
+	for _, queue := range queues {
+		for _, lf := range states {
+			for scan := 1; scan <= maxScanPages; scan++ {
+				infos, err := lf.list(i.inspector, queue, asynq.Page(scan), asynq.PageSize(100))
+				if err != nil {
+					return nil, err
+				}
+				for _, info := range infos {
+					summary := summarize(info)
+					if !filter.matches(summary) {
+						continue
+					}
+					if skip > 0 {
+						skip--
+						continue
+					}
+					summaries = append(summaries, summary)
+					if len(summaries) == pageSize {
+						return summaries, nil
+					}
+				}
+				if len(infos) < 100 {
+					break
+				}
+			}
+		}
+	}
+	return summaries, nil
+}
+
+// GetTask returns a single task
+func (i *Inspector) GetTask(queue, id string) (TaskSummary, error) {
+	info, err := i.inspector.GetTaskInfo(queue, id)
+	if err != nil {
+		return TaskSummary{}, err
+	}
+	return summarize(info), nil
+}
+
+// RetryArchived moves an archived task back to pending
+func (i *Inspector) RetryArchived(queue, id string) error {
+	if err := i.checkArchived(queue, id); err != nil {
+		return err
+	}
+	return i.inspector.RunTask(queue, id)
+}
+
+// DeleteArchived drops an archived task for good
+func (i *Inspector) DeleteArchived(queue, id string) error {
+	if err := i.checkArchived(queue, id); err != nil {
+		return err
+	}
+	return i.inspector.DeleteTask(queue, id)
+}
+
+// RetryAllArchived moves every archived task of a queue back to pending
+func (i *Inspector) RetryAllArchived(queue string) (int, error) {
+	return i.inspector.RunAllArchivedTasks(queue)
+}
+
+// DeleteAllArchived drops every archived task of a queue
+func (i *Inspector) DeleteAllArchived(queue string) (int, error) {
+	return i.inspector.DeleteAllArchivedTasks(queue)
+}
+
+// Close releases the Redis connection
+func (i *Inspector) Close() error {
+	return i.inspector.Close()
+}
+
+func (i *Inspector) checkArchived(queue, id string) error {
+	info, err := i.inspector.GetTaskInfo(queue, id)
+	if err != nil {
+		return err
+	}
+	if info.State != asynq.TaskStateArchived {
+		return ErrTaskNotArchived
+	}
+	return nil
+}
+
+func (f TaskFilter) matches(summary TaskSummary) bool {
+	if f.Type != "" && summary.Type != f.Type {
+		return false
+	}
+	if f.TenantID != nil && (summary.TenantID == nil || *summary.TenantID != *f.TenantID) {
+		return false
+	}
+	return true
+}
+
+func summarize(info *asynq.TaskInfo) TaskSummary {
+	summary := TaskSummary{
+		ID:        info.ID,
+		Queue:     info.Queue,
+		Type:      info.Type,
+		State:     info.State.String(),
+		TenantID:  tasks.TenantID(info.Payload),
+		Payload:   info.Payload,
+		Retried:   info.Retried,
+		MaxRetry:  info.MaxRetry,
+		LastError: info.LastErr,
+	}
+	// Payloads are JSON; anything else is returned as a string
+	if !json.Valid(info.Payload) {
+		summary.Payload, _ = json.Marshal(string(info.Payload))
+	}
+	if !info.LastFailedAt.IsZero() {
+		summary.LastFailedAt = &info.LastFailedAt
+	}
+	if !info.NextProcessAt.IsZero() {
+		summary.NextProcessAt = &info.NextProcessAt
+	}
+	return summary
+}
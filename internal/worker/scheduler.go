@@ -0,0 +1,121 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/nickkcj/orbit-backend/internal/worker/tasks"
+)
+
+// scheduledRunWindow is how long a scheduled task type stays locked after a
+// run. Cron ticks are at least a minute apart, so duplicates enqueued by other
+// scheduler instances for the same tick fall inside it.
+const scheduledRunWindow = 50 * time.Second
+
+// ScheduledJob is a task enqueued on a cron schedule
+type ScheduledJob struct {
+	Name string
+	Spec string // Cron spec; empty or "off" disables the job
+	Task *asynq.Task
+}
+
+// MaintenanceConfig configures the recurring maintenance jobs
+type MaintenanceConfig struct {
+	PurgeNotificationsSpec string
+	NotificationRetention  time.Duration
+
+	PruneWebhooksSpec string
+	WebhookRetention  time.Duration
+
+	ExpireVideosSpec string
+	StaleVideoAfter  time.Duration
+
+	AnalyticsRollupSpec string
+	AnalyticsRollupDays int
+
+	ReconcileVideosSpec string
+	VideoReconcileAfter time.Duration
+
+	ScheduleDigestsSpec string
+}
+
+// MaintenanceJobs builds the registry of recurring maintenance jobs
+func MaintenanceJobs(cfg MaintenanceConfig) ([]ScheduledJob, error) {
+	definitions := []struct {
+		name     string
+		spec     string
+		taskType string
+		payload  tasks.MaintenancePayload
+	}{
+		{"purge-notifications", cfg.PurgeNotificationsSpec, tasks.TypePurgeNotifications, tasks.MaintenancePayload{OlderThan: cfg.NotificationRetention}},
+		{"prune-webhooks", cfg.PruneWebhooksSpec, tasks.TypePruneWebhooks, tasks.MaintenancePayload{OlderThan: cfg.WebhookRetention}},
+		{"expire-videos", cfg.ExpireVideosSpec, tasks.TypeExpireVideos, tasks.MaintenancePayload{OlderThan: cfg.StaleVideoAfter}},
+		{"analytics-rollup", cfg.AnalyticsRollupSpec, tasks.TypeAnalyticsRollup, tasks.MaintenancePayload{Days: cfg.AnalyticsRollupDays}},
+		{"reconcile-videos", cfg.ReconcileVideosSpec, tasks.TypeReconcileVideos, tasks.MaintenancePayload{OlderThan: cfg.VideoReconcileAfter}},
+		{"schedule-digests", cfg.ScheduleDigestsSpec, tasks.TypeScheduleDigests, tasks.MaintenancePayload{}},
+	}
+
+	var jobs []ScheduledJob
+	for _, def := range definitions {
+		task, err := tasks.NewMaintenanceTask(def.taskType, def.payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s task: %w", def.name, err)
+		}
+		jobs = append(jobs, ScheduledJob{Name: def.name, Spec: def.spec, Task: task})
+	}
+	return jobs, nil
+}
+
+// Scheduler enqueues recurring jobs. Every instance may run one: duplicate
+// enqueues of a tick are collapsed by asynq.Unique, and the worker skips runs
+// of a scheduled task type that already ran within scheduledRunWindow.
+type Scheduler struct {
+	scheduler *asynq.Scheduler
+}
+
+// NewScheduler registers the enabled jobs on a new asynq scheduler
+func NewScheduler(redisOpt asynq.RedisClientOpt, jobs []ScheduledJob) (*Scheduler, error) {
+	scheduler := asynq.NewScheduler(redisOpt, &asynq.SchedulerOpts{
+		Location: time.UTC,
+		Logger:   &workerLogger{},
+		PostEnqueueFunc: func(info *asynq.TaskInfo, err error) {
+			if err != nil {
+				// Another instance already enqueued this tick
+				if errors.Is(err, asynq.ErrDuplicateTask) {
+					return
+				}
+				log.Printf("[SCHEDULER] Failed to enqueue task: %v", err)
+				return
+			}
+			log.Printf("[SCHEDULER] Enqueued %s (id=%s)", info.Type, info.ID)
+		},
+	})
+
+	for _, job := range jobs {
+		if job.Spec == "" || job.Spec == "off" {
+			log.Printf("[SCHEDULER] Job %s disabled", job.Name)
+			continue
+		}
+		if _, err := scheduler.Register(job.Spec, job.Task, asynq.Unique(scheduledRunWindow)); err != nil {
+			return nil, fmt.Errorf("invalid schedule %q for job %s: %w", job.Spec, job.Name, err)
+		}
+		log.Printf("[SCHEDULER] Job %s scheduled (%s)", job.Name, job.Spec)
+	}
+
+	return &Scheduler{scheduler: scheduler}, nil
+}
+
+// Start begins enqueueing jobs on their schedule
+func (s *Scheduler) Start() error {
+	log.Println("Starting scheduler...")
+	return s.scheduler.Start()
+}
+
+// Shutdown stops the scheduler
+func (s *Scheduler) Shutdown() {
+	log.Println("Shutting down scheduler...")
+	s.scheduler.Shutdown()
+}
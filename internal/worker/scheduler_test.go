@@ -0,0 +1,123 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/nickkcj/orbit-backend/internal/worker/tasks"
+)
+
+// Every scheduler instance registers the same jobs, so two instances enqueue
+// each tick twice. The first guard, asynq.Unique, is a Redis-side check inside
+// asynq and needs a live Redis, so it is not covered here. These tests cover
+// the second guard: the worker runs a scheduled task type once per window no
+// matter how many copies of the tick reach it.
+
+func TestRunOnceSkipsDuplicateTicks(t *testing.T) {
+	rdb := newLockRedis()
+	runs := 0
+	job := func(context.Context, *asynq.Task) error {
+		runs++
+		return nil
+	}
+	task := asynq.NewTask(tasks.TypePurgeNotifications, nil)
+
+	// One copy of the tick from each of two schedulers, picked up by two workers
+	first := runOnce(rdb, scheduledRunWindow, job)
+	second := runOnce(rdb, scheduledRunWindow, job)
+	for _, handler := range []asynq.HandlerFunc{first, second} {
+		if err := handler(context.Background(), task); err != nil {
+			t.Fatalf("run: %v", err)
+		}
+	}
+	if runs != 1 {
+		t.Fatalf("expected 1 run, got %d", runs)
+	}
+
+	// Other task types keep their own lock
+	if err := first(context.Background(), asynq.NewTask(tasks.TypePruneWebhooks, nil)); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if runs != 2 {
+		t.Fatalf("other task type was skipped")
+	}
+}
+
+func TestRunOnceReleasesLockOnFailure(t *testing.T) {
+	rdb := newLockRedis()
+	failures, runs := 1, 0
+	handler := runOnce(rdb, scheduledRunWindow, func(context.Context, *asynq.Task) error {
+		runs++
+		if failures > 0 {
+			failures--
+			return errors.New("database unavailable")
+		}
+		return nil
+	})
+	task := asynq.NewTask(tasks.TypeScheduleDigests, nil)
+
+	if err := handler(context.Background(), task); err == nil {
+		t.Fatalf("expected the failure to reach asynq")
+	}
+	// The asynq retry of the failed run goes through
+	if err := handler(context.Background(), task); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if runs != 2 {
+		t.Fatalf("expected the retry to run, got %d runs", runs)
+	}
+}
+
+func TestNewSchedulerRejectsInvalidSpecs(t *testing.T) {
+	task := asynq.NewTask(tasks.TypePurgeNotifications, nil)
+	// The asynq client connects lazily, so no Redis is needed to register jobs
+	redisOpt := asynq.RedisClientOpt{Addr: "localhost:0"}
+
+	if _, err := NewScheduler(redisOpt, []ScheduledJob{{Name: "purge", Spec: "every day", Task: task}}); err == nil {
+		t.Fatalf("invalid spec was accepted")
+	}
+	if _, err := NewScheduler(redisOpt, []ScheduledJob{{Name: "purge", Spec: "off", Task: task}, {Name: "prune", Spec: "0 3 * * *", Task: task}}); err != nil {
+		t.Fatalf("valid jobs rejected: %v", err)
+	}
+}
+
+// lockRedis implements the SETNX/DEL subset of Redis that runOnce uses; any
+// other command panics on the nil embedded client
+type lockRedis struct {
+	redis.UniversalClient
+	mu   sync.Mutex
+	keys map[string]time.Time // key -> expiry
+}
+
+func newLockRedis() *lockRedis {
+	return &lockRedis{keys: make(map[string]time.Time)}
+}
+
+func (r *lockRedis) SetNX(ctx context.Context, key string, _ interface{}, expiration time.Duration) *redis.BoolCmd {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if expiry, ok := r.keys[key]; ok && time.Now().Before(expiry) {
+		return redis.NewBoolResult(false, nil)
+	}
+	r.keys[key] = time.Now().Add(expiration)
+	return redis.NewBoolResult(true, nil)
+}
+
+func (r *lockRedis) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deleted int64
+	for _, key := range keys {
+		if _, ok := r.keys[key]; ok {
+			delete(r.keys, key)
+			deleted++
+		}
+	}
+	return redis.NewIntResult(deleted, nil)
+}
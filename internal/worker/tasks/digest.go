@@ -0,0 +1,31 @@
+package tasks
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+// DigestPayload is one member's digest for one period
+type DigestPayload struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	UserID   uuid.UUID `json:"user_id"`
+	Period   string    `json:"period"` // e.g. daily:2026-10-17
+}
+
+// NewSendDigestTask creates a digest task; the task ID keeps the hourly sweep
+// from queueing a period twice while the first is pending
+func NewSendDigestTask(payload DigestPayload) (*asynq.Task, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	opts := append(Options(TypeSendDigest), asynq.TaskID(digestTaskID(payload)))
+	return asynq.NewTask(TypeSendDigest, data, opts...), nil
+}
+
+// digestTaskID is unique per tenant, member and period
+func digestTaskID(payload DigestPayload) string {
+	return "digest:" + payload.TenantID.String() + ":" + payload.UserID.String() + ":" + payload.Period
+}
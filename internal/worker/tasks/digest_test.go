@@ -0,0 +1,27 @@
+package tasks
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestDigestTaskIDIsPerPeriodAndMember(t *testing.T) {
+	base := DigestPayload{TenantID: uuid.New(), UserID: uuid.New(), Period: "daily:2026-10-17"}
+
+	if digestTaskID(base) != digestTaskID(base) {
+		t.Fatalf("same period produced different task IDs")
+	}
+
+	nextDay := base
+	nextDay.Period = "daily:2026-10-18"
+	otherMember := base
+	otherMember.UserID = uuid.New()
+	otherTenant := base
+	otherTenant.TenantID = uuid.New()
+	for name, payload := range map[string]DigestPayload{"next period": nextDay, "other member": otherMember, "other tenant": otherTenant} {
+		if digestTaskID(payload) == digestTaskID(base) {
+			t.Errorf("%s shares the task ID %q", name, digestTaskID(base))
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package tasks
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+// EmailPayload contains data for sending a templated email
+type EmailPayload struct {
+	TenantID *uuid.UUID             `json:"tenant_id,omitempty"` // Branding; nil uses the platform's
+	To       string                 `json:"to"`
+	ToName   string                 `json:"to_name,omitempty"`
+	Template string                 `json:"template"` // "invitation", "password_reset", "notification", "digest", "email_verification"
+	Data     map[string]interface{} `json:"data,omitempty"`
+	Headers  map[string]string      `json:"headers,omitempty"`
+}
+
+// NewSendEmailTask creates a new email task
+func NewSendEmailTask(payload EmailPayload) (*asynq.Task, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return asynq.NewTask(TypeSendEmail, data, Options(TypeSendEmail)...), nil
+}
@@ -0,0 +1,23 @@
+package tasks
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+// MaintenancePayload configures a recurring maintenance run
+type MaintenancePayload struct {
+	OlderThan time.Duration `json:"older_than,omitempty"` // Retention cutoff
+	Days      int           `json:"days,omitempty"`       // Rollup window
+}
+
+// NewMaintenanceTask creates a maintenance task of the given type
+func NewMaintenanceTask(taskType string, payload MaintenancePayload) (*asynq.Task, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return asynq.NewTask(taskType, data, Options(taskType)...), nil
+}
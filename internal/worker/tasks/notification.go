@@ -2,7 +2,6 @@ package tasks
 
 import (
 	"encoding/json"
-	"time"
 
 	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
@@ -10,11 +9,11 @@ import (
 
 // NotificationPayload contains data for sending notifications
 type NotificationPayload struct {
-	Type        string    `json:"type"` // "comment", "reply", "welcome", "mention", "new_post"
+	Type        string    `json:"type"` // "comment", "reply", "welcome", "mention", "new_post", "like"
 	TenantID    uuid.UUID `json:"tenant_id"`
 	RecipientID uuid.UUID `json:"recipient_id"`
 
-	// For comment/reply notifications
+	// For comment/reply/like notifications; AuthorID is who acted
 	PostID    *uuid.UUID `json:"post_id,omitempty"`
 	PostTitle string     `json:"post_title,omitempty"`
 	CommentID *uuid.UUID `json:"comment_id,omitempty"`
@@ -31,12 +30,25 @@ func NewSendNotificationTask(payload NotificationPayload) (*asynq.Task, error) {
 	if err != nil {
 		return nil, err
 	}
-	return asynq.NewTask(
-		TypeSendNotification,
-		data,
-		asynq.Queue(QueueDefault),
-		asynq.MaxRetry(3),
-		asynq.Timeout(30*time.Second),
-		asynq.Retention(24*time.Hour),
-	), nil
+	return asynq.NewTask(TypeSendNotification, data, Options(TypeSendNotification)...), nil
+}
+
+// NewPostFanOutPayload is one batch of new_post notifications for the
+// followers of a post, resuming after the follower with user ID After
+type NewPostFanOutPayload struct {
+	TenantID   uuid.UUID `json:"tenant_id"`
+	PostID     uuid.UUID `json:"post_id"`
+	AuthorName string    `json:"author_name"`
+	After      uuid.UUID `json:"after"`
+}
+
+// NewFanOutNewPostTask creates the fan-out batch task; the task ID makes a
+// continuation enqueued twice run once
+func NewFanOutNewPostTask(payload NewPostFanOutPayload) (*asynq.Task, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	opts := append(Options(TypeFanOutNewPost), asynq.TaskID("fanout_new_post:"+payload.PostID.String()+":"+payload.After.String()))
+	return asynq.NewTask(TypeFanOutNewPost, data, opts...), nil
 }
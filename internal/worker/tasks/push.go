@@ -0,0 +1,36 @@
+package tasks
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+// PushPayload is a notification to deliver to the recipient's devices
+type PushPayload struct {
+	TenantID       uuid.UUID       `json:"tenant_id"`
+	UserID         uuid.UUID       `json:"user_id"`
+	NotificationID uuid.UUID       `json:"notification_id"` // No stored row when the member turned in-app off
+	Type           string          `json:"type"`
+	Title          string          `json:"title"`
+	Message        string          `json:"message,omitempty"`
+	Data           json.RawMessage `json:"data,omitempty"`
+	ActorCount     int             `json:"actor_count,omitempty"`
+}
+
+// NewSendPushTask creates a push task; one per notification, and one more
+// each time a grouped notification gains an actor
+func NewSendPushTask(payload PushPayload) (*asynq.Task, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	taskID := "push:" + payload.NotificationID.String()
+	if payload.ActorCount > 1 {
+		taskID += ":" + strconv.Itoa(payload.ActorCount)
+	}
+	opts := append(Options(TypeSendPush), asynq.TaskID(taskID))
+	return asynq.NewTask(TypeSendPush, data, opts...), nil
+}
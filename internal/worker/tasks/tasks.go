@@ -1,11 +1,30 @@
 package tasks
 
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
 // Task type constants
 const (
 	TypeSendNotification = "notification:send"
 	TypeProcessWebhook   = "webhook:process"
 	TypeSendEmail        = "email:send"
 	TypeProcessVideo     = "video:process"
+	TypeFanOutNewPost    = "notification:fanout_new_post"
+	TypeSendPush         = "push:send"
+	TypeSendDigest       = "digest:send"
+
+	// Recurring maintenance, enqueued by the scheduler
+	TypePurgeNotifications = "maintenance:purge_notifications"
+	TypePruneWebhooks      = "maintenance:prune_webhooks"
+	TypeExpireVideos       = "maintenance:expire_videos"
+	TypeAnalyticsRollup    = "maintenance:analytics_rollup"
+	TypeReconcileVideos    = "maintenance:reconcile_videos"
+	TypeScheduleDigests    = "digest:schedule"
 )
 
 // Queue names with priorities
@@ -14,3 +33,48 @@ const (
 	QueueDefault  = "default"  // Notifications
 	QueueLow      = "low"      // Analytics, batch jobs
 )
+
+// taskOptions are the enqueue options of each task type, shared by the task
+// constructors and the outbox relay
+var taskOptions = map[string][]asynq.Option{
+	TypeSendNotification: {asynq.Queue(QueueDefault), asynq.MaxRetry(3), asynq.Timeout(30 * time.Second), asynq.Retention(24 * time.Hour)},
+	TypeProcessWebhook:   {asynq.Queue(QueueCritical), asynq.MaxRetry(5), asynq.Timeout(2 * time.Minute), asynq.Retention(48 * time.Hour)},
+	TypeSendEmail:        {asynq.Queue(QueueDefault), asynq.MaxRetry(8), asynq.Timeout(1 * time.Minute), asynq.Retention(24 * time.Hour)},
+	// Each batch enqueues its own continuation, so one publish never becomes one giant task
+	TypeFanOutNewPost: {asynq.Queue(QueueLow), asynq.MaxRetry(5), asynq.Timeout(2 * time.Minute), asynq.Retention(24 * time.Hour)},
+	TypeSendPush:      {asynq.Queue(QueueDefault), asynq.MaxRetry(3), asynq.Timeout(1 * time.Minute), asynq.Retention(24 * time.Hour)},
+	TypeSendDigest:    {asynq.Queue(QueueLow), asynq.MaxRetry(3), asynq.Timeout(1 * time.Minute), asynq.Retention(24 * time.Hour)},
+	// Retries are polls (about 2h at VideoPollDelay); the sweep picks up the rest
+	TypeProcessVideo: {asynq.Queue(QueueDefault), asynq.MaxRetry(15), asynq.Timeout(1 * time.Minute), asynq.Retention(24 * time.Hour)},
+
+	TypePurgeNotifications: maintenanceOptions,
+	TypePruneWebhooks:      maintenanceOptions,
+	TypeExpireVideos:       maintenanceOptions,
+	TypeAnalyticsRollup:    maintenanceOptions,
+	TypeReconcileVideos:    maintenanceOptions,
+	TypeScheduleDigests:    maintenanceOptions,
+}
+
+var maintenanceOptions = []asynq.Option{asynq.Queue(QueueLow), asynq.MaxRetry(3), asynq.Timeout(10 * time.Minute), asynq.Retention(24 * time.Hour)}
+
+// Options returns the enqueue options of a task type
+func Options(taskType string) []asynq.Option {
+	opts := make([]asynq.Option, len(taskOptions[taskType]))
+	copy(opts, taskOptions[taskType])
+	return opts
+}
+
+// TenantID returns the tenant named in a task payload, or nil when the task
+// is not tenant-scoped
+func TenantID(payload []byte) *uuid.UUID {
+	var scoped struct {
+		TenantID *uuid.UUID `json:"tenant_id"`
+	}
+	if err := json.Unmarshal(payload, &scoped); err != nil {
+		return nil
+	}
+	if scoped.TenantID == nil || *scoped.TenantID == uuid.Nil {
+		return nil
+	}
+	return scoped.TenantID
+}
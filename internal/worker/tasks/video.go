@@ -0,0 +1,44 @@
+package tasks
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+// Polling backoff of video:process, used as its retry delay
+const (
+	videoPollBase = 30 * time.Second
+	videoPollMax  = 10 * time.Minute
+)
+
+// VideoPayload identifies a video to reconcile with its stream provider
+type VideoPayload struct {
+	VideoID  uuid.UUID `json:"video_id"`
+	TenantID uuid.UUID `json:"tenant_id"`
+}
+
+// NewProcessVideoTask creates a task that polls the provider until the video
+// is ready or failed, starting after the first backoff step
+func NewProcessVideoTask(payload VideoPayload) (*asynq.Task, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	opts := append(Options(TypeProcessVideo), asynq.ProcessIn(videoPollBase))
+	return asynq.NewTask(TypeProcessVideo, data, opts...), nil
+}
+
+// VideoPollDelay doubles from 30s up to 10m between polls
+func VideoPollDelay(retried int) time.Duration {
+	delay := videoPollBase
+	for i := 0; i < retried && delay < videoPollMax; i++ {
+		delay *= 2
+	}
+	if delay > videoPollMax {
+		delay = videoPollMax
+	}
+	return delay
+}
@@ -2,12 +2,17 @@ package tasks
 
 import (
 	"encoding/json"
-	"time"
 
 	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
 )
 
+// Webhook providers
+const (
+	ProviderR2               = "r2"
+	ProviderCloudflareStream = "cloudflare_stream"
+)
+
 // WebhookPayload contains data for processing webhooks
 type WebhookPayload struct {
 	Provider   string          `json:"provider"` // "r2", "cloudflare_stream", etc.
@@ -22,12 +27,7 @@ func NewProcessWebhookTask(payload WebhookPayload) (*asynq.Task, error) {
 	if err != nil {
 		return nil, err
 	}
-	return asynq.NewTask(
-		TypeProcessWebhook,
-		data,
-		asynq.Queue(QueueCritical),
-		asynq.MaxRetry(5),
-		asynq.Timeout(2*time.Minute),
-		asynq.Retention(48*time.Hour),
-	), nil
+	// Provider redeliveries collapse into one task
+	opts := append(Options(TypeProcessWebhook), asynq.TaskID("webhook:"+payload.EventID.String()))
+	return asynq.NewTask(TypeProcessWebhook, data, opts...), nil
 }
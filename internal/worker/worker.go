@@ -2,12 +2,15 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"log"
+	"time"
 
 	"github.com/hibiken/asynq"
 	"github.com/nickkcj/orbit-backend/internal/service"
 	"github.com/nickkcj/orbit-backend/internal/worker/handlers"
 	"github.com/nickkcj/orbit-backend/internal/worker/tasks"
+	"github.com/redis/go-redis/v9"
 )
 
 // Worker manages the Asynq server and task handlers
@@ -15,6 +18,7 @@ type Worker struct {
 	server   *asynq.Server
 	mux      *asynq.ServeMux
 	services *service.Services
+	rdb      redis.UniversalClient
 }
 
 // workerLogger implements asynq.Logger interface
@@ -40,8 +44,9 @@ func (l *workerLogger) Fatal(args ...interface{}) {
 	log.Fatal("[FATAL]", args)
 }
 
-// NewWorker creates a new worker with the given configuration
-func NewWorker(redisOpt asynq.RedisClientOpt, concurrency int, services *service.Services) *Worker {
+// NewWorker creates a new worker with the given configuration; handlers that
+// enqueue follow-up tasks use client
+func NewWorker(redisOpt asynq.RedisClientOpt, concurrency int, services *service.Services, client *TaskClient) *Worker {
 	srv := asynq.NewServer(redisOpt, asynq.Config{
 		Concurrency: concurrency,
 		Queues: map[string]int{
@@ -53,26 +58,72 @@ func NewWorker(redisOpt asynq.RedisClientOpt, concurrency int, services *service
 			retried, _ := asynq.GetRetryCount(ctx)
 			maxRetry, _ := asynq.GetMaxRetry(ctx)
 
+			// A video still processing is a poll, not a failure
+			if errors.Is(err, service.ErrVideoProcessing) && retried < maxRetry {
+				return
+			}
+
 			if retried >= maxRetry {
-				log.Printf("[DEAD] Task %s exhausted retries: %v", task.Type(), err)
+				// Archived: inspect and retry through the admin queue API
+				taskID, _ := asynq.GetTaskID(ctx)
+				tenant := "-"
+				if tenantID := tasks.TenantID(task.Payload()); tenantID != nil {
+					tenant = tenantID.String()
+				}
+				log.Printf("[DEAD] Task %s %s (tenant %s) exhausted retries: %v", task.Type(), taskID, tenant, err)
 			} else {
 				log.Printf("[RETRY] Task %s failed (attempt %d/%d): %v",
 					task.Type(), retried+1, maxRetry, err)
 			}
 		}),
+		RetryDelayFunc: func(n int, err error, task *asynq.Task) time.Duration {
+			if task.Type() == tasks.TypeProcessVideo {
+				return tasks.VideoPollDelay(n)
+			}
+			return asynq.DefaultRetryDelayFunc(n, err, task)
+		},
 		Logger: &workerLogger{},
 	})
 
 	mux := asynq.NewServeMux()
 
 	// Register handlers
-	notificationHandler := handlers.NewNotificationHandler(services.Notification)
+	notificationHandler := handlers.NewNotificationHandler(services.Notification, client, services.Push != nil)
 	mux.HandleFunc(tasks.TypeSendNotification, notificationHandler.Handle)
 
+	webhookHandler := handlers.NewWebhookHandler(services.Webhook, services.Video)
+	mux.HandleFunc(tasks.TypeProcessWebhook, webhookHandler.Handle)
+
+	emailHandler := handlers.NewEmailHandler(services.Email)
+	mux.HandleFunc(tasks.TypeSendEmail, emailHandler.Handle)
+
+	videoHandler := handlers.NewVideoHandler(services.Video)
+	mux.HandleFunc(tasks.TypeProcessVideo, videoHandler.Handle)
+
+	pushHandler := handlers.NewPushHandler(services.Push)
+	mux.HandleFunc(tasks.TypeSendPush, pushHandler.Handle)
+
+	fanOutHandler := handlers.NewFanOutHandler(services.Post, services.Follow, client)
+	mux.HandleFunc(tasks.TypeFanOutNewPost, fanOutHandler.Handle)
+
+	// Scheduled maintenance runs once per tick even with several schedulers
+	rdb := redisOpt.MakeRedisClient().(redis.UniversalClient)
+	maintenanceHandler := handlers.NewMaintenanceHandler(services)
+	mux.HandleFunc(tasks.TypePurgeNotifications, runOnce(rdb, scheduledRunWindow, maintenanceHandler.PurgeNotifications))
+	mux.HandleFunc(tasks.TypePruneWebhooks, runOnce(rdb, scheduledRunWindow, maintenanceHandler.PruneWebhooks))
+	mux.HandleFunc(tasks.TypeExpireVideos, runOnce(rdb, scheduledRunWindow, maintenanceHandler.ExpireVideos))
+	mux.HandleFunc(tasks.TypeAnalyticsRollup, runOnce(rdb, scheduledRunWindow, maintenanceHandler.RollupAnalytics))
+	mux.HandleFunc(tasks.TypeReconcileVideos, runOnce(rdb, scheduledRunWindow, maintenanceHandler.ReconcileVideos))
+
+	digestHandler := handlers.NewDigestHandler(services.Digest, client)
+	mux.HandleFunc(tasks.TypeScheduleDigests, runOnce(rdb, scheduledRunWindow, digestHandler.Schedule))
+	mux.HandleFunc(tasks.TypeSendDigest, digestHandler.Send)
+
 	return &Worker{
 		server:   srv,
 		mux:      mux,
 		services: services,
+		rdb:      rdb,
 	}
 }
 
@@ -86,4 +137,7 @@ func (w *Worker) Start() error {
 func (w *Worker) Shutdown() {
 	log.Println("Shutting down background worker...")
 	w.server.Shutdown()
+	if err := w.rdb.Close(); err != nil {
+		log.Printf("Worker redis close error: %v", err)
+	}
 }
@@ -0,0 +1,111 @@
+-- name: CreateConversation :one
+INSERT INTO conversations (tenant_id, is_group, title, created_by, direct_key)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetConversationByID :one
+SELECT * FROM conversations WHERE id = $1;
+
+-- name: GetDirectConversation :one
+SELECT * FROM conversations WHERE tenant_id = $1 AND direct_key = $2;
+
+-- name: ListConversationsByUser :many
+SELECT
+    c.*,
+    cp.last_read_at,
+    (
+        SELECT COUNT(*) FROM conversation_messages m
+        WHERE m.conversation_id = c.id AND m.created_at > cp.last_read_at AND m.sender_id <> cp.user_id
+    ) as unread_count,
+    lm.content as last_message_content,
+    lm.sender_id as last_message_sender_id
+FROM conversations c
+JOIN conversation_participants cp ON cp.conversation_id = c.id
+LEFT JOIN LATERAL (
+    SELECT m.content, m.sender_id FROM conversation_messages m
+    WHERE m.conversation_id = c.id
+    ORDER BY m.created_at DESC
+    LIMIT 1
+) lm ON TRUE
+WHERE c.tenant_id = $1 AND cp.user_id = $2
+ORDER BY COALESCE(c.last_message_at, c.created_at) DESC
+LIMIT $3 OFFSET $4;
+
+-- name: TouchConversation :exec
+UPDATE conversations SET last_message_at = $2 WHERE id = $1;
+
+-- name: AddConversationParticipant :exec
+INSERT INTO conversation_participants (conversation_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: IsConversationParticipant :one
+SELECT EXISTS(
+    SELECT 1 FROM conversation_participants WHERE conversation_id = $1 AND user_id = $2
+) as is_participant;
+
+-- name: ListConversationParticipants :many
+SELECT
+    cp.user_id,
+    u.name,
+    u.avatar_url,
+    cp.last_read_at
+FROM conversation_participants cp
+JOIN users u ON cp.user_id = u.id
+WHERE cp.conversation_id = $1
+ORDER BY cp.joined_at;
+
+-- name: MarkConversationRead :exec
+UPDATE conversation_participants SET last_read_at = NOW()
+WHERE conversation_id = $1 AND user_id = $2;
+
+-- name: CreateConversationMessage :one
+INSERT INTO conversation_messages (tenant_id, conversation_id, sender_id, content)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetConversationMessageByID :one
+SELECT * FROM conversation_messages WHERE id = $1;
+
+-- name: ListConversationMessages :many
+SELECT
+    m.*,
+    u.name as sender_name,
+    u.avatar_url as sender_avatar
+FROM conversation_messages m
+JOIN users u ON m.sender_id = u.id
+WHERE m.conversation_id = $1
+ORDER BY m.created_at DESC
+LIMIT $2;
+
+-- name: ListConversationMessagesBefore :many
+SELECT
+    m.*,
+    u.name as sender_name,
+    u.avatar_url as sender_avatar
+FROM conversation_messages m
+JOIN users u ON m.sender_id = u.id
+WHERE m.conversation_id = $1 AND m.created_at < $2
+ORDER BY m.created_at DESC
+LIMIT $3;
+
+-- name: CountUnreadConversationMessages :one
+SELECT COUNT(*) FROM conversation_messages m
+JOIN conversation_participants cp ON cp.conversation_id = m.conversation_id
+WHERE m.tenant_id = $1 AND cp.user_id = $2
+AND m.created_at > cp.last_read_at AND m.sender_id <> cp.user_id;
+
+-- name: BlockMember :exec
+INSERT INTO member_blocks (tenant_id, blocker_id, blocked_id)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
+
+-- name: UnblockMember :exec
+DELETE FROM member_blocks WHERE tenant_id = $1 AND blocker_id = $2 AND blocked_id = $3;
+
+-- name: IsBlockedBetween :one
+SELECT EXISTS(
+    SELECT 1 FROM member_blocks
+    WHERE tenant_id = $1
+    AND ((blocker_id = $2 AND blocked_id = $3) OR (blocker_id = $3 AND blocked_id = $2))
+) as is_blocked;
@@ -0,0 +1,60 @@
+-- name: GetDigestSettings :one
+SELECT * FROM digest_settings
+WHERE tenant_id = $1 AND user_id = $2;
+
+-- name: UpsertDigestSettings :one
+INSERT INTO digest_settings (tenant_id, user_id, frequency, hour, weekday, timezone, last_period)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (tenant_id, user_id) DO UPDATE
+SET frequency = EXCLUDED.frequency, hour = EXCLUDED.hour, weekday = EXCLUDED.weekday,
+    timezone = EXCLUDED.timezone, last_period = EXCLUDED.last_period, updated_at = NOW()
+RETURNING *;
+
+-- name: DisableDigest :exec
+UPDATE digest_settings
+SET frequency = 'off', updated_at = NOW()
+WHERE tenant_id = $1 AND user_id = $2;
+
+-- name: ListDigestSubscribers :many
+-- One page of active members with a digest, in (tenant_id, user_id) order
+-- after the cursor
+SELECT ds.* FROM digest_settings ds
+JOIN tenant_members tm ON tm.tenant_id = ds.tenant_id AND tm.user_id = ds.user_id
+JOIN tenants t ON t.id = ds.tenant_id
+JOIN users u ON u.id = ds.user_id
+WHERE ds.frequency <> 'off'
+AND tm.status = 'active'
+AND t.status = 'active'
+AND u.status = 'active'
+AND (ds.tenant_id, ds.user_id) > (sqlc.arg(after_tenant_id)::uuid, sqlc.arg(after_user_id)::uuid)
+ORDER BY ds.tenant_id, ds.user_id
+LIMIT sqlc.arg(max_results);
+
+-- name: ClaimDigestPeriod :execrows
+-- Marks the period sent; no row means another run already claimed it
+UPDATE digest_settings
+SET last_period = $3, last_sent_at = NOW()
+WHERE tenant_id = $1 AND user_id = $2 AND frequency <> 'off'
+AND last_period IS DISTINCT FROM $3;
+
+-- name: ListDigestTopPosts :many
+-- The most viewed posts published since the cutoff in visible categories,
+-- leaving out the member's own
+SELECT
+    p.id,
+    p.title,
+    p.slug,
+    p.excerpt,
+    p.like_count,
+    p.comment_count,
+    u.name as author_name
+FROM posts p
+JOIN users u ON p.author_id = u.id
+LEFT JOIN categories c ON p.category_id = c.id
+WHERE p.tenant_id = $1
+AND p.status = 'published'
+AND p.published_at >= $2
+AND p.author_id <> $3
+AND (c.id IS NULL OR c.is_visible)
+ORDER BY p.view_count DESC, p.like_count DESC
+LIMIT $4;
@@ -0,0 +1,59 @@
+-- name: FollowCategory :exec
+INSERT INTO category_follows (tenant_id, category_id, user_id)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
+
+-- name: UnfollowCategory :exec
+DELETE FROM category_follows WHERE category_id = $1 AND user_id = $2;
+
+-- name: ListFollowedCategories :many
+SELECT c.* FROM categories c
+JOIN category_follows f ON f.category_id = c.id
+WHERE f.tenant_id = $1 AND f.user_id = $2
+ORDER BY c.position ASC;
+
+-- name: FollowAuthor :exec
+INSERT INTO author_follows (tenant_id, author_id, follower_id)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
+
+-- name: UnfollowAuthor :exec
+DELETE FROM author_follows WHERE tenant_id = $1 AND author_id = $2 AND follower_id = $3;
+
+-- name: ListFollowedAuthors :many
+SELECT
+    f.author_id,
+    u.name as author_name,
+    u.avatar_url as author_avatar,
+    f.created_at
+FROM author_follows f
+JOIN users u ON f.author_id = u.id
+WHERE f.tenant_id = $1 AND f.follower_id = $2
+ORDER BY f.created_at DESC;
+
+-- name: ListNewPostFollowers :many
+-- One page of active members following the post's category or author who can
+-- read posts, in user_id order after the cursor
+SELECT tm.user_id
+FROM tenant_members tm
+WHERE tm.tenant_id = sqlc.arg(tenant_id)
+AND tm.status = 'active'
+AND tm.user_id <> sqlc.arg(author_id)
+AND tm.user_id > sqlc.arg(after_user_id)
+AND (
+    EXISTS (
+        SELECT 1 FROM category_follows cf
+        WHERE cf.category_id = sqlc.narg(category_id) AND cf.user_id = tm.user_id
+    )
+    OR EXISTS (
+        SELECT 1 FROM author_follows af
+        WHERE af.tenant_id = tm.tenant_id AND af.author_id = sqlc.arg(author_id) AND af.follower_id = tm.user_id
+    )
+)
+AND EXISTS (
+    SELECT 1 FROM role_permissions rp
+    JOIN permissions p ON rp.permission_id = p.id
+    WHERE rp.role_id = tm.role_id AND p.code = 'posts.view'
+)
+ORDER BY tm.user_id
+LIMIT sqlc.arg(max_results);
@@ -0,0 +1,38 @@
+-- name: CreateLessonChatMessage :one
+INSERT INTO lesson_chat_messages (tenant_id, lesson_id, author_id, content, video_timestamp_seconds)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetLessonChatMessageByID :one
+SELECT * FROM lesson_chat_messages WHERE id = $1;
+
+-- name: ListLessonChatMessages :many
+SELECT
+    m.*,
+    u.name as author_name,
+    u.avatar_url as author_avatar
+FROM lesson_chat_messages m
+JOIN users u ON m.author_id = u.id
+WHERE m.lesson_id = $1 AND m.status = 'visible'
+ORDER BY m.created_at DESC
+LIMIT $2;
+
+-- name: ListLessonChatMessagesBefore :many
+SELECT
+    m.*,
+    u.name as author_name,
+    u.avatar_url as author_avatar
+FROM lesson_chat_messages m
+JOIN users u ON m.author_id = u.id
+WHERE m.lesson_id = $1 AND m.status = 'visible' AND m.created_at < $2
+ORDER BY m.created_at DESC
+LIMIT $3;
+
+-- name: UpdateLessonChatMessage :one
+UPDATE lesson_chat_messages
+SET content = $2, edited_at = NOW()
+WHERE id = $1 AND status = 'visible'
+RETURNING *;
+
+-- name: DeleteLessonChatMessage :exec
+UPDATE lesson_chat_messages SET status = 'deleted', deleted_by = $2 WHERE id = $1;
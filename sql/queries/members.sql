@@ -79,3 +79,26 @@ UPDATE tenant_members
 SET display_name = $3, bio = $4, updated_at = NOW()
 WHERE tenant_id = $1 AND user_id = $2
 RETURNING *;
+
+-- name: GetMemberByHandle :one
+SELECT * FROM tenant_members WHERE tenant_id = $1 AND handle = $2;
+
+-- name: UpdateMemberHandle :one
+UPDATE tenant_members
+SET handle = $3, updated_at = NOW()
+WHERE tenant_id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: SearchMembersForMention :many
+SELECT
+    tm.user_id,
+    tm.handle,
+    tm.display_name,
+    u.name as user_name,
+    u.avatar_url as user_avatar
+FROM tenant_members tm
+JOIN users u ON tm.user_id = u.id
+WHERE tm.tenant_id = $1 AND tm.status = 'active'
+AND (tm.handle LIKE $2 OR tm.display_name ILIKE $2 OR u.name ILIKE $2)
+ORDER BY tm.handle
+LIMIT $3;
@@ -0,0 +1,20 @@
+-- name: ResolveMentionHandles :many
+-- Active members with the given handles who can read posts
+SELECT tm.user_id, tm.handle
+FROM tenant_members tm
+WHERE tm.tenant_id = $1 AND tm.handle = ANY($2::text[]) AND tm.status = 'active'
+AND EXISTS (
+    SELECT 1 FROM role_permissions rp
+    JOIN permissions p ON rp.permission_id = p.id
+    WHERE rp.role_id = tm.role_id AND p.code = 'posts.view'
+);
+
+-- name: CreatePostMention :execrows
+INSERT INTO mentions (tenant_id, post_id, author_id, mentioned_user_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (post_id, mentioned_user_id) WHERE comment_id IS NULL DO NOTHING;
+
+-- name: CreateCommentMention :execrows
+INSERT INTO mentions (tenant_id, post_id, comment_id, author_id, mentioned_user_id)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (comment_id, mentioned_user_id) WHERE comment_id IS NOT NULL DO NOTHING;
@@ -0,0 +1,15 @@
+-- name: GetNotificationPreference :one
+SELECT * FROM notification_preferences
+WHERE tenant_id = $1 AND user_id = $2 AND type = $3;
+
+-- name: ListNotificationPreferences :many
+SELECT * FROM notification_preferences
+WHERE tenant_id = $1 AND user_id = $2
+ORDER BY type;
+
+-- name: UpsertNotificationPreference :one
+INSERT INTO notification_preferences (tenant_id, user_id, type, in_app, email, push)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (tenant_id, user_id, type) DO UPDATE
+SET in_app = EXCLUDED.in_app, email = EXCLUDED.email, push = EXCLUDED.push, updated_at = NOW()
+RETURNING *;
@@ -1,6 +1,6 @@
 -- name: CreateNotification :one
-INSERT INTO notifications (tenant_id, user_id, type, title, message, data)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO notifications (tenant_id, user_id, type, title, message, data, group_key)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
 RETURNING *;
 
 -- name: GetNotification :one
@@ -34,6 +34,33 @@ WHERE tenant_id = $1 AND user_id = $2 AND read_at IS NULL;
 -- name: DeleteNotification :exec
 DELETE FROM notifications WHERE id = $1 AND user_id = $2;
 
--- name: DeleteOldNotifications :exec
+-- name: DeleteOldNotifications :execrows
 DELETE FROM notifications
-WHERE created_at < NOW() - INTERVAL '30 days' AND read_at IS NOT NULL;
+WHERE created_at < $1 AND read_at IS NOT NULL;
+
+-- name: LockNotificationGroup :exec
+-- Serializes grouping of one recipient's group until the transaction ends
+SELECT pg_advisory_xact_lock(hashtextextended(sqlc.arg(lock_key)::text, 0));
+
+-- name: GetOpenNotificationGroup :one
+-- The newest unread notification of the group still inside the window
+SELECT * FROM notifications
+WHERE user_id = $1 AND group_key = $2 AND read_at IS NULL AND created_at > $3
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: AddNotificationGroupActor :execrows
+-- No row means the actor is already counted in the group
+INSERT INTO notification_group_actors (notification_id, actor_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: CountNotificationGroupActors :one
+SELECT COUNT(*) FROM notification_group_actors WHERE notification_id = $1;
+
+-- name: UpdateNotificationGroup :one
+-- Folds a new event in; created_at moves so the group shows as recent again
+UPDATE notifications
+SET title = $2, message = $3, data = $4, actor_count = $5, created_at = NOW()
+WHERE id = $1
+RETURNING *;
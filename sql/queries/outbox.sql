@@ -0,0 +1,25 @@
+-- name: CreateOutboxMessage :one
+INSERT INTO outbox_messages (task_type, payload)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: ClaimOutboxMessages :many
+SELECT * FROM outbox_messages
+WHERE failed_at IS NULL AND available_at <= NOW()
+ORDER BY created_at
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: DeleteOutboxMessage :exec
+DELETE FROM outbox_messages WHERE id = $1;
+
+-- name: FailOutboxMessage :exec
+-- Gives up on a message; it stays for inspection but is never claimed again
+UPDATE outbox_messages
+SET attempts = attempts + 1, last_error = $2, failed_at = NOW()
+WHERE id = $1;
+
+-- name: RescheduleOutboxMessage :exec
+UPDATE outbox_messages
+SET attempts = attempts + 1, last_error = $2, available_at = $3
+WHERE id = $1;
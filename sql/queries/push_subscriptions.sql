@@ -0,0 +1,21 @@
+-- name: UpsertPushSubscription :one
+-- A browser re-subscribing, possibly as another user, takes over the endpoint
+INSERT INTO push_subscriptions (user_id, endpoint, p256dh, auth, user_agent)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (endpoint) DO UPDATE
+SET user_id = EXCLUDED.user_id, p256dh = EXCLUDED.p256dh, auth = EXCLUDED.auth, user_agent = EXCLUDED.user_agent
+RETURNING *;
+
+-- name: ListPushSubscriptionsByUser :many
+SELECT * FROM push_subscriptions
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: DeletePushSubscription :exec
+DELETE FROM push_subscriptions WHERE endpoint = $1 AND user_id = $2;
+
+-- name: DeletePushSubscriptionByID :exec
+DELETE FROM push_subscriptions WHERE id = $1;
+
+-- name: TouchPushSubscription :exec
+UPDATE push_subscriptions SET last_used_at = NOW() WHERE id = $1;
@@ -0,0 +1,53 @@
+-- name: CreateSession :one
+INSERT INTO sessions (user_id, device, ip_address, user_agent, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetSession :one
+SELECT * FROM sessions WHERE id = $1;
+
+-- name: ListActiveSessionsByUser :many
+SELECT * FROM sessions
+WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+ORDER BY last_seen_at DESC;
+
+-- name: TouchSession :exec
+UPDATE sessions
+SET last_seen_at = NOW(), expires_at = $2, ip_address = $3, user_agent = $4
+WHERE id = $1;
+
+-- name: RevokeSession :execrows
+UPDATE sessions
+SET revoked_at = NOW(), revoked_reason = $3
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: RevokeUserSessions :execrows
+-- Revokes every session of the user except the one to keep, if any
+UPDATE sessions
+SET revoked_at = NOW(), revoked_reason = sqlc.arg(reason)
+WHERE user_id = sqlc.arg(user_id) AND revoked_at IS NULL
+AND id IS DISTINCT FROM sqlc.narg(keep_session_id);
+
+-- name: CreateRefreshToken :exec
+INSERT INTO refresh_tokens (token_hash, session_id)
+VALUES ($1, $2);
+
+-- name: GetRefreshToken :one
+SELECT * FROM refresh_tokens WHERE token_hash = $1;
+
+-- name: RotateRefreshToken :execrows
+-- Marks the token used; no row means it was already rotated
+UPDATE refresh_tokens
+SET rotated_at = NOW()
+WHERE token_hash = $1 AND rotated_at IS NULL;
+
+
+-- name: CreateLoginCode :exec
+INSERT INTO login_codes (code_hash, session_id, expires_at)
+VALUES ($1, $2, $3);
+
+-- name: UseLoginCode :one
+-- Spends the code; no row means it is unknown, expired or already used
+DELETE FROM login_codes
+WHERE code_hash = $1 AND expires_at > NOW()
+RETURNING session_id;
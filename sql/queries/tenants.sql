@@ -40,3 +40,6 @@ UPDATE tenants
 SET logo_url = $2, updated_at = NOW()
 WHERE id = $1
 RETURNING *;
+
+-- name: UpdateTenantStatus :exec
+UPDATE tenants SET status = $2, updated_at = NOW() WHERE id = $1;
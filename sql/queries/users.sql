@@ -18,6 +18,9 @@ RETURNING *;
 -- name: UpdateUserPassword :exec
 UPDATE users SET password_hash = $2, updated_at = NOW() WHERE id = $1;
 
+-- name: UpdateUserStatus :exec
+UPDATE users SET status = $2, updated_at = NOW() WHERE id = $1;
+
 -- name: VerifyUserEmail :exec
 UPDATE users SET email_verified_at = NOW(), updated_at = NOW() WHERE id = $1;
 
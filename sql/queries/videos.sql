@@ -28,6 +28,12 @@ SET status = $2, error_message = $3, updated_at = NOW()
 WHERE id = $1
 RETURNING *;
 
+-- name: SetVideoExternalID :one
+UPDATE videos
+SET external_id = $2, status = $3, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
 -- name: UpdateVideoAfterProcessing :one
 UPDATE videos
 SET
@@ -46,3 +52,19 @@ UPDATE videos SET post_id = $2, updated_at = NOW() WHERE id = $1;
 
 -- name: DeleteVideo :exec
 DELETE FROM videos WHERE id = $1;
+
+-- name: ExpireStaleVideos :execrows
+UPDATE videos
+SET status = 'failed', error_message = 'upload was not completed', updated_at = NOW()
+WHERE status IN ('pending', 'uploading') AND updated_at < $1;
+
+-- name: ListStuckVideos :many
+-- Least recently polled first, so videos that stay stuck do not starve newer ones
+SELECT id FROM videos
+WHERE status IN ('uploading', 'processing') AND external_id IS NOT NULL AND updated_at < $1
+AND (last_reconciled_at IS NULL OR last_reconciled_at < $1)
+ORDER BY last_reconciled_at NULLS FIRST, updated_at
+LIMIT $2;
+
+-- name: MarkVideoReconciled :exec
+UPDATE videos SET last_reconciled_at = NOW() WHERE id = $1;
@@ -21,3 +21,6 @@ SELECT * FROM webhook_events
 WHERE status = 'pending'
 ORDER BY created_at ASC
 LIMIT $1;
+
+-- name: DeleteWebhookEventsBefore :execrows
+DELETE FROM webhook_events WHERE created_at < $1;
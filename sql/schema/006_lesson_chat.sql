@@ -0,0 +1,52 @@
+-- +goose Up
+-- ============================================================================
+-- ORBIT BACKEND - Lesson Chat Schema
+-- Live chat attached to lessons in the player
+-- ============================================================================
+
+CREATE TABLE lesson_chat_messages (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    lesson_id UUID NOT NULL REFERENCES lessons(id) ON DELETE CASCADE,
+    author_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    content TEXT NOT NULL,
+
+    -- Position in the lesson video when the message was sent
+    video_timestamp_seconds INT CHECK (video_timestamp_seconds >= 0),
+
+    -- Moderation
+    status VARCHAR(20) NOT NULL DEFAULT 'visible' CHECK (status IN ('visible', 'deleted')),
+    deleted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+
+    edited_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_lesson_chat_tenant ON lesson_chat_messages(tenant_id);
+CREATE INDEX idx_lesson_chat_lesson_recent ON lesson_chat_messages(lesson_id, created_at DESC) WHERE status = 'visible';
+
+CREATE TRIGGER update_lesson_chat_messages_updated_at BEFORE UPDATE ON lesson_chat_messages FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- ============================================================================
+-- PERMISSIONS: Lesson chat moderation
+-- ============================================================================
+
+INSERT INTO permissions (code, name, description, category) VALUES
+    ('lesson_chat.moderate', 'Moderar chat das aulas', 'Excluir mensagens de qualquer usuário no chat das aulas', 'courses');
+
+-- Owner and Admin can moderate
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.slug IN ('owner', 'admin') AND r.is_system = TRUE
+AND p.code = 'lesson_chat.moderate'
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DROP TRIGGER IF EXISTS update_lesson_chat_messages_updated_at ON lesson_chat_messages;
+DROP TABLE IF EXISTS lesson_chat_messages;
+DELETE FROM role_permissions WHERE permission_id IN (SELECT id FROM permissions WHERE code = 'lesson_chat.moderate');
+DELETE FROM permissions WHERE code = 'lesson_chat.moderate';
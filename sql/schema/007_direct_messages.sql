@@ -0,0 +1,185 @@
+-- +goose Up
+-- ============================================================================
+-- ORBIT BACKEND - Direct Messages Schema
+-- Private 1:1 and group conversations between members of a tenant
+-- ============================================================================
+
+CREATE TABLE conversations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+
+    is_group BOOLEAN NOT NULL DEFAULT FALSE,
+    title VARCHAR(200),
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    -- Sorted participant IDs of a 1:1 conversation, so each pair has one thread
+    direct_key VARCHAR(100),
+
+    last_message_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    UNIQUE(tenant_id, direct_key)
+);
+
+CREATE INDEX idx_conversations_tenant ON conversations(tenant_id);
+
+CREATE TABLE conversation_participants (
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    -- Messages after this are unread
+    last_read_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (conversation_id, user_id)
+);
+
+CREATE INDEX idx_conversation_participants_user ON conversation_participants(user_id);
+
+CREATE TABLE conversation_messages (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    sender_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    content TEXT NOT NULL,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_conversation_messages_recent ON conversation_messages(conversation_id, created_at DESC);
+
+-- Members a user does not want to hear from
+CREATE TABLE member_blocks (
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    blocker_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (tenant_id, blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX idx_member_blocks_blocked ON member_blocks(tenant_id, blocked_id);
+
+CREATE TRIGGER update_conversations_updated_at BEFORE UPDATE ON conversations FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- ============================================================================
+-- PERMISSIONS: Direct messages
+-- ============================================================================
+
+INSERT INTO permissions (code, name, description, category) VALUES
+    ('messages.send', 'Enviar mensagens', 'Enviar mensagens diretas a outros membros', 'members');
+
+-- All system roles can message
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.slug IN ('owner', 'admin', 'member') AND r.is_system = TRUE
+AND p.code = 'messages.send'
+ON CONFLICT DO NOTHING;
+
+-- New tenants: Owner and Admin already receive every permission, Member gets
+-- messages.send alongside the basic ones
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION create_default_roles()
+RETURNS TRIGGER AS $$
+DECLARE
+    owner_role_id UUID;
+    admin_role_id UUID;
+    member_role_id UUID;
+    perm RECORD;
+BEGIN
+    -- Create Owner role (all permissions, system role)
+    INSERT INTO roles (tenant_id, slug, name, description, priority, is_system)
+    VALUES (NEW.id, 'owner', 'Owner', 'Dono da comunidade com acesso total', 1000, TRUE)
+    RETURNING id INTO owner_role_id;
+
+    -- Create Admin role
+    INSERT INTO roles (tenant_id, slug, name, description, priority, is_system)
+    VALUES (NEW.id, 'admin', 'Admin', 'Administrador com amplos poderes', 500, TRUE)
+    RETURNING id INTO admin_role_id;
+
+    -- Create Member role (default)
+    INSERT INTO roles (tenant_id, slug, name, description, priority, is_default, is_system)
+    VALUES (NEW.id, 'member', 'Membro', 'Membro padrão da comunidade', 100, TRUE, TRUE)
+    RETURNING id INTO member_role_id;
+
+    -- Assign ALL permissions to Owner
+    FOR perm IN SELECT id FROM permissions LOOP
+        INSERT INTO role_permissions (role_id, permission_id) VALUES (owner_role_id, perm.id);
+    END LOOP;
+
+    -- Assign management permissions to Admin (excluding roles.manage)
+    FOR perm IN SELECT id FROM permissions WHERE code NOT IN ('roles.manage', 'settings.edit') LOOP
+        INSERT INTO role_permissions (role_id, permission_id) VALUES (admin_role_id, perm.id);
+    END LOOP;
+
+    -- Assign basic permissions to Member
+    FOR perm IN SELECT id FROM permissions WHERE code IN (
+        'posts.view', 'posts.create', 'posts.edit_own', 'posts.delete_own',
+        'videos.view', 'videos.upload', 'videos.delete_own',
+        'comments.view', 'comments.create', 'comments.edit_own', 'comments.delete_own',
+        'members.view', 'messages.send'
+    ) LOOP
+        INSERT INTO role_permissions (role_id, permission_id) VALUES (member_role_id, perm.id);
+    END LOOP;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION create_default_roles()
+RETURNS TRIGGER AS $$
+DECLARE
+    owner_role_id UUID;
+    admin_role_id UUID;
+    member_role_id UUID;
+    perm RECORD;
+BEGIN
+    INSERT INTO roles (tenant_id, slug, name, description, priority, is_system)
+    VALUES (NEW.id, 'owner', 'Owner', 'Dono da comunidade com acesso total', 1000, TRUE)
+    RETURNING id INTO owner_role_id;
+
+    INSERT INTO roles (tenant_id, slug, name, description, priority, is_system)
+    VALUES (NEW.id, 'admin', 'Admin', 'Administrador com amplos poderes', 500, TRUE)
+    RETURNING id INTO admin_role_id;
+
+    INSERT INTO roles (tenant_id, slug, name, description, priority, is_default, is_system)
+    VALUES (NEW.id, 'member', 'Membro', 'Membro padrão da comunidade', 100, TRUE, TRUE)
+    RETURNING id INTO member_role_id;
+
+    FOR perm IN SELECT id FROM permissions LOOP
+        INSERT INTO role_permissions (role_id, permission_id) VALUES (owner_role_id, perm.id);
+    END LOOP;
+
+    FOR perm IN SELECT id FROM permissions WHERE code NOT IN ('roles.manage', 'settings.edit') LOOP
+        INSERT INTO role_permissions (role_id, permission_id) VALUES (admin_role_id, perm.id);
+    END LOOP;
+
+    FOR perm IN SELECT id FROM permissions WHERE code IN (
+        'posts.view', 'posts.create', 'posts.edit_own', 'posts.delete_own',
+        'videos.view', 'videos.upload', 'videos.delete_own',
+        'comments.view', 'comments.create', 'comments.edit_own', 'comments.delete_own',
+        'members.view'
+    ) LOOP
+        INSERT INTO role_permissions (role_id, permission_id) VALUES (member_role_id, perm.id);
+    END LOOP;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+DROP TRIGGER IF EXISTS update_conversations_updated_at ON conversations;
+DROP TABLE IF EXISTS member_blocks;
+DROP TABLE IF EXISTS conversation_messages;
+DROP TABLE IF EXISTS conversation_participants;
+DROP TABLE IF EXISTS conversations;
+DELETE FROM role_permissions WHERE permission_id IN (SELECT id FROM permissions WHERE code = 'messages.send');
+DELETE FROM permissions WHERE code = 'messages.send';
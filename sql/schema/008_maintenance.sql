@@ -0,0 +1,25 @@
+-- +goose Up
+-- ============================================================================
+-- ORBIT BACKEND - Maintenance Schema
+-- Daily analytics rollups recomputed by the scheduler
+-- ============================================================================
+
+CREATE TABLE tenant_daily_stats (
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+
+    new_members INT NOT NULL DEFAULT 0,
+    new_posts INT NOT NULL DEFAULT 0,
+    new_comments INT NOT NULL DEFAULT 0,
+
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (tenant_id, day)
+);
+
+-- Stale uploads are found by status and age
+CREATE INDEX idx_videos_status_updated ON videos(status, updated_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_videos_status_updated;
+DROP TABLE IF EXISTS tenant_daily_stats;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- ============================================================================
+-- ORBIT BACKEND - Transactional Outbox
+-- Background tasks written in the same transaction as the domain change and
+-- relayed to the task queue afterwards
+-- ============================================================================
+
+CREATE TABLE outbox_messages (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+
+    task_type VARCHAR(100) NOT NULL,           -- Ex: "notification:send"
+    payload JSONB NOT NULL,
+
+    -- Relay bookkeeping
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    available_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_outbox_messages_available ON outbox_messages(available_at, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS outbox_messages;
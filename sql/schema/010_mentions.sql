@@ -0,0 +1,99 @@
+-- +goose Up
+-- ============================================================================
+-- ORBIT BACKEND - Mentions Schema
+-- Per-tenant member handles and @mentions in posts and comments
+-- ============================================================================
+
+-- Handle used to @mention a member inside the tenant (lowercase, a-z 0-9 _)
+ALTER TABLE tenant_members ADD COLUMN handle VARCHAR(30);
+
+-- Next free handle in the tenant derived from a name ("João Silva" -> "joao_silva", "joao_silva_2", ...)
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION next_member_handle(p_tenant_id UUID, p_name TEXT)
+RETURNS TEXT AS $$
+DECLARE
+    base TEXT;
+    candidate TEXT;
+    n INT := 1;
+BEGIN
+    base := translate(lower(COALESCE(p_name, '')), 'áàâãäéèêëíìîïóòôõöúùûüç', 'aaaaaeeeeiiiiooooouuuuc');
+    base := regexp_replace(base, '[^a-z0-9]+', '_', 'g');
+    base := trim(BOTH '_' FROM left(trim(BOTH '_' FROM base), 24));
+    IF length(base) < 2 THEN
+        base := 'membro';
+    END IF;
+
+    candidate := base;
+    WHILE EXISTS (SELECT 1 FROM tenant_members WHERE tenant_id = p_tenant_id AND handle = candidate) LOOP
+        n := n + 1;
+        candidate := base || '_' || n;
+    END LOOP;
+
+    RETURN candidate;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- Existing members, oldest first so early members keep the plain handle
+-- +goose StatementBegin
+DO $$
+DECLARE
+    m RECORD;
+BEGIN
+    FOR m IN
+        SELECT tm.id, tm.tenant_id, COALESCE(tm.display_name, u.name) AS name
+        FROM tenant_members tm
+        JOIN users u ON tm.user_id = u.id
+        ORDER BY tm.joined_at
+    LOOP
+        UPDATE tenant_members SET handle = next_member_handle(m.tenant_id, m.name) WHERE id = m.id;
+    END LOOP;
+END $$;
+-- +goose StatementEnd
+
+ALTER TABLE tenant_members ALTER COLUMN handle SET NOT NULL;
+CREATE UNIQUE INDEX idx_tenant_members_handle ON tenant_members(tenant_id, handle);
+
+-- New members get a handle from their display name or user name
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION assign_member_handle()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.handle IS NULL THEN
+        NEW.handle := next_member_handle(
+            NEW.tenant_id,
+            COALESCE(NEW.display_name, (SELECT name FROM users WHERE id = NEW.user_id))
+        );
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER assign_tenant_member_handle BEFORE INSERT ON tenant_members FOR EACH ROW EXECUTE FUNCTION assign_member_handle();
+
+-- One row per member mentioned in a post (comment_id NULL) or a comment
+CREATE TABLE mentions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    comment_id UUID REFERENCES comments(id) ON DELETE CASCADE,
+    author_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    mentioned_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Editing content only notifies members who were not mentioned before
+CREATE UNIQUE INDEX idx_mentions_post_user ON mentions(post_id, mentioned_user_id) WHERE comment_id IS NULL;
+CREATE UNIQUE INDEX idx_mentions_comment_user ON mentions(comment_id, mentioned_user_id) WHERE comment_id IS NOT NULL;
+CREATE INDEX idx_mentions_mentioned ON mentions(tenant_id, mentioned_user_id, created_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS mentions;
+DROP TRIGGER IF EXISTS assign_tenant_member_handle ON tenant_members;
+DROP FUNCTION IF EXISTS assign_member_handle();
+DROP INDEX IF EXISTS idx_tenant_members_handle;
+ALTER TABLE tenant_members DROP COLUMN IF EXISTS handle;
+DROP FUNCTION IF EXISTS next_member_handle(UUID, TEXT);
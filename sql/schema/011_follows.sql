@@ -0,0 +1,32 @@
+-- +goose Up
+-- ============================================================================
+-- ORBIT BACKEND - Follows Schema
+-- Members following categories and authors to hear about new posts
+-- ============================================================================
+
+CREATE TABLE category_follows (
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    category_id UUID NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (category_id, user_id)
+);
+
+CREATE INDEX idx_category_follows_user ON category_follows(tenant_id, user_id);
+
+CREATE TABLE author_follows (
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    author_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    follower_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (tenant_id, author_id, follower_id),
+    CHECK (author_id <> follower_id)
+);
+
+CREATE INDEX idx_author_follows_follower ON author_follows(tenant_id, follower_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS author_follows;
+DROP TABLE IF EXISTS category_follows;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- ============================================================================
+-- ORBIT BACKEND - Notification Preferences Schema
+-- Per-membership channel choices for each notification type
+-- ============================================================================
+
+-- Only types a member changed have a row; the rest use the defaults in code
+CREATE TABLE notification_preferences (
+    tenant_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    type VARCHAR(30) NOT NULL,             -- comment, reply, mention, new_post, welcome, like
+
+    in_app BOOLEAN NOT NULL,
+    email BOOLEAN NOT NULL,
+    push BOOLEAN NOT NULL,
+
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (tenant_id, user_id, type),
+    FOREIGN KEY (tenant_id, user_id) REFERENCES tenant_members(tenant_id, user_id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS notification_preferences;
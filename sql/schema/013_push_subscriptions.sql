@@ -0,0 +1,24 @@
+-- +goose Up
+-- ============================================================================
+-- ORBIT BACKEND - Push Subscriptions Schema
+-- Web Push endpoints of each user's browsers and devices
+-- ============================================================================
+
+CREATE TABLE push_subscriptions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    -- PushSubscription.toJSON() of the browser
+    endpoint TEXT NOT NULL UNIQUE,
+    p256dh VARCHAR(255) NOT NULL,
+    auth VARCHAR(255) NOT NULL,
+
+    user_agent TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_push_subscriptions_user ON push_subscriptions(user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS push_subscriptions;
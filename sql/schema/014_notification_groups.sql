@@ -0,0 +1,18 @@
+-- +goose Up
+-- ============================================================================
+-- ORBIT BACKEND - Notification Groups
+-- Collapse repeated events on one target ("Ana e mais 12 pessoas curtiram...")
+-- ============================================================================
+
+-- Type + target, e.g. "like:post:<id>"; NULL for notifications that never group
+ALTER TABLE notifications ADD COLUMN group_key VARCHAR(120);
+-- Distinct actors folded into the notification (the latest are in data.actors)
+ALTER TABLE notifications ADD COLUMN actor_count INT NOT NULL DEFAULT 1;
+
+CREATE INDEX idx_notifications_group ON notifications(user_id, group_key, created_at DESC)
+    WHERE group_key IS NOT NULL AND read_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_notifications_group;
+ALTER TABLE notifications DROP COLUMN IF EXISTS actor_count;
+ALTER TABLE notifications DROP COLUMN IF EXISTS group_key;
@@ -0,0 +1,31 @@
+-- +goose Up
+-- ============================================================================
+-- ORBIT BACKEND - Activity Digests Schema
+-- Daily/weekly email summaries for members who opted in
+-- ============================================================================
+
+-- Only members who configured a digest have a row
+CREATE TABLE digest_settings (
+    tenant_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+
+    frequency VARCHAR(10) NOT NULL DEFAULT 'off' CHECK (frequency IN ('off', 'daily', 'weekly')),
+    hour INT NOT NULL DEFAULT 8 CHECK (hour BETWEEN 0 AND 23),        -- Local hour it goes out
+    weekday INT NOT NULL DEFAULT 1 CHECK (weekday BETWEEN 0 AND 6),   -- 0 = Sunday; weekly only
+    timezone VARCHAR(64) NOT NULL DEFAULT 'America/Sao_Paulo',
+
+    -- The scheduled slot last sent, e.g. "daily:2026-10-17"; claiming it is
+    -- what keeps one digest per period
+    last_period VARCHAR(20),
+    last_sent_at TIMESTAMPTZ,
+
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (tenant_id, user_id),
+    FOREIGN KEY (tenant_id, user_id) REFERENCES tenant_members(tenant_id, user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_digest_settings_enabled ON digest_settings(tenant_id, user_id) WHERE frequency <> 'off';
+
+-- +goose Down
+DROP TABLE IF EXISTS digest_settings;
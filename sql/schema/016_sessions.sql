@@ -0,0 +1,39 @@
+-- +goose Up
+-- ============================================================================
+-- ORBIT BACKEND - Sessions Schema
+-- One row per login; access tokens name their session so it can be revoked
+-- ============================================================================
+
+CREATE TABLE sessions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    -- Where the login came from
+    device VARCHAR(255),
+    ip_address VARCHAR(45),
+    user_agent TEXT,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),   -- Last refresh
+    expires_at TIMESTAMPTZ NOT NULL,                   -- Pushed forward on every refresh
+
+    revoked_at TIMESTAMPTZ,
+    revoked_reason VARCHAR(30)                         -- logout, logout_all, password_change, reuse_detected
+);
+
+CREATE INDEX idx_sessions_user ON sessions(user_id) WHERE revoked_at IS NULL;
+
+-- Every refresh token a session was issued. Only the latest is unrotated;
+-- presenting a rotated one means it leaked, and the session is revoked.
+CREATE TABLE refresh_tokens (
+    token_hash VARCHAR(64) PRIMARY KEY,                -- SHA-256 hex, the token itself is never stored
+    session_id UUID NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    rotated_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_refresh_tokens_session ON refresh_tokens(session_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS sessions;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- ============================================================================
+-- ORBIT BACKEND - Email Verification Schema
+-- Single-use links that confirm a user owns their email address
+-- ============================================================================
+
+CREATE TABLE email_verification_tokens (
+    token_hash VARCHAR(64) PRIMARY KEY,                 -- SHA-256 of the token in the link
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,                        -- Address the link was sent to
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_email_verification_tokens_user ON email_verification_tokens(user_id, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS email_verification_tokens;
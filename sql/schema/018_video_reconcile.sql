@@ -0,0 +1,8 @@
+-- +goose Up
+-- When the reconcile sweep last polled the provider for a video. Lets the
+-- sweep rotate through stuck videos instead of retrying the oldest forever;
+-- updated_at is left alone so upload expiry still sees the real last change.
+ALTER TABLE videos ADD COLUMN last_reconciled_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE videos DROP COLUMN IF EXISTS last_reconciled_at;
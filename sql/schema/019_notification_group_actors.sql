@@ -0,0 +1,23 @@
+-- +goose Up
+-- ============================================================================
+-- ORBIT BACKEND - Notification Group Actors
+-- Every distinct actor folded into a grouped notification; actor_count is
+-- derived from it, so an actor repeating an event is never counted twice
+-- ============================================================================
+
+CREATE TABLE notification_group_actors (
+    notification_id UUID NOT NULL REFERENCES notifications(id) ON DELETE CASCADE,
+    actor_id UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(notification_id, actor_id)
+);
+
+-- Groups created before this table only kept their latest actors
+INSERT INTO notification_group_actors (notification_id, actor_id)
+SELECT n.id, (actor->>'id')::uuid
+FROM notifications n, jsonb_array_elements(n.data->'actors') AS actor
+WHERE n.group_key IS NOT NULL AND jsonb_typeof(n.data->'actors') = 'array'
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DROP TABLE IF EXISTS notification_group_actors;
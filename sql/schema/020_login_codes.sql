@@ -0,0 +1,16 @@
+-- +goose Up
+-- ============================================================================
+-- ORBIT BACKEND - Login Codes
+-- One-time codes handed to the frontend after an OAuth redirect, so tokens
+-- never travel in a URL; the frontend trades the code for them via POST
+-- ============================================================================
+
+CREATE TABLE login_codes (
+    code_hash VARCHAR(64) PRIMARY KEY,                 -- SHA-256 hex, the code itself is never stored
+    session_id UUID NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS login_codes;
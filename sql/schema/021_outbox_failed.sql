@@ -0,0 +1,12 @@
+-- +goose Up
+-- When the relay gave up on a message after too many failed enqueues. Failed
+-- messages stay in the table for inspection but are no longer claimed.
+ALTER TABLE outbox_messages ADD COLUMN failed_at TIMESTAMPTZ;
+
+DROP INDEX IF EXISTS idx_outbox_messages_available;
+CREATE INDEX idx_outbox_messages_available ON outbox_messages(available_at, created_at) WHERE failed_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_outbox_messages_available;
+CREATE INDEX idx_outbox_messages_available ON outbox_messages(available_at, created_at);
+ALTER TABLE outbox_messages DROP COLUMN IF EXISTS failed_at;